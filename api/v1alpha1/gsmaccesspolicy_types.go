@@ -0,0 +1,75 @@
+/*
+Copyright 2025 Zera Holladay.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+// GSMAccessPolicySpec allows GSMSecrets in the selected namespaces to read the
+// listed projects and secrets, optionally through the listed GSAs.
+type GSMAccessPolicySpec struct {
+	// NamespaceSelector selects the namespaces the policy applies to.
+	// An empty selector selects every namespace.
+	NamespaceSelector metav1.LabelSelector `json:"namespaceSelector"`
+
+	// ProjectIDs lists the GCP projects that may be read. Entries are globs,
+	// e.g. "team-a-*" or "*".
+	// +kubebuilder:validation:MinItems=1
+	ProjectIDs []string `json:"projectIds"`
+
+	// SecretIDs lists the Secret Manager secret names that may be read.
+	// Entries are globs, e.g. "app-*" or "*".
+	// +kubebuilder:validation:MinItems=1
+	SecretIDs []string `json:"secretIds"`
+
+	// Principals lists the Google Service Accounts that may be impersonated,
+	// including every delegate in a chain. Entries are globs, e.g.
+	// "*@team-a.iam.gserviceaccount.com". When empty, impersonation is not
+	// allowed under this policy.
+	// +optional
+	Principals []string `json:"principals,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:scope=Cluster
+
+// GSMAccessPolicy restricts which Secret Manager secrets GSMSecrets may read.
+// When policy enforcement is enabled, every read must be allowed by at least
+// one policy selecting the GSMSecret's namespace.
+type GSMAccessPolicy struct {
+	metav1.TypeMeta `json:",inline"`
+
+	// Metadata is standard object metadata.
+	// +optional
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	// Spec defines what the selected namespaces may read.
+	// +required
+	Spec GSMAccessPolicySpec `json:"spec"`
+}
+
+// +kubebuilder:object:root=true
+
+// GSMAccessPolicyList contains a list of GSMAccessPolicy.
+type GSMAccessPolicyList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []GSMAccessPolicy `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&GSMAccessPolicy{}, &GSMAccessPolicyList{})
+}
@@ -24,9 +24,22 @@ const (
 	AnnotationGSA         = "secrets.gsm-operator.io/gsa"
 	AnnotationWIFAudience = "secrets.gsm-operator.io/wif-audience"
 	AnnotationRelease     = "secrets.gsm-operator.io/release"
+	// AnnotationGSADelegates is an ordered, comma-separated delegation chain of
+	// GSAs to go through before impersonating the GSA.
+	AnnotationGSADelegates = "secrets.gsm-operator.io/gsa-delegates"
+	// AnnotationImpersonationLifetime is the lifetime of impersonated tokens as a
+	// Go duration (e.g. "30m"); at most 12h.
+	AnnotationImpersonationLifetime = "secrets.gsm-operator.io/impersonation-lifetime"
+	// AnnotationApprovedKSA records the KSA the GSMSecret's author was checked
+	// to be allowed to mint tokens for. It is set by the admission webhook; any
+	// value supplied by the user is replaced.
+	AnnotationApprovedKSA = "secrets.gsm-operator.io/approved-ksa"
 )
 
 // GSMSecretSpec defines the desired state of GSMSecret.
+// +kubebuilder:validation:XValidation:rule="!(has(self.storeRef) && has(self.credentialsSecretRef))",message="storeRef and credentialsSecretRef are mutually exclusive"
+// +kubebuilder:validation:XValidation:rule="!(has(self.storeRef) && has(self.authMode))",message="authMode cannot be set with storeRef; set the store's authMode instead"
+// +kubebuilder:validation:XValidation:rule="!has(self.authMode) || (self.authMode == 'CredentialsSecret') == has(self.credentialsSecretRef)",message="authMode CredentialsSecret requires credentialsSecretRef, and credentialsSecretRef requires authMode CredentialsSecret"
 type GSMSecretSpec struct {
 	// TargetSecret describes the Kubernetes Secret to create or update.
 	// +kubebuilder:validation:Required
@@ -35,14 +48,75 @@ type GSMSecretSpec struct {
 	// Secrets is the list of GSM secrets to materialize into the target Secret.
 	// +kubebuilder:validation:MinItems=1
 	Secrets []GSMSecretEntry `json:"gsmSecrets"`
+
+	// StoreRef references a GSMSecretStore or ClusterGSMSecretStore to take the
+	// auth configuration from. When set, identity annotations on the GSMSecret
+	// are ignored.
+	// +optional
+	StoreRef *GSMSecretStoreRef `json:"storeRef,omitempty"`
+
+	// CredentialsSecretRef reads Google credentials from a Secret in this
+	// namespace instead of using Workload Identity Federation. The gsa,
+	// gsa-delegates and impersonation-lifetime annotations still apply.
+	// Mutually exclusive with StoreRef; use a store's credentialsSecretRef
+	// instead.
+	// +optional
+	CredentialsSecretRef *CredentialsSecretRef `json:"credentialsSecretRef,omitempty"`
+
+	// AuthMode selects how the operator authenticates to Google Cloud for this
+	// GSMSecret. Defaults to CredentialsSecret when credentialsSecretRef is
+	// set, otherwise to the operator's MODE. The operator's AUTH_MODE_POLICY
+	// may restrict which modes each namespace can use. Mutually exclusive with
+	// StoreRef; use the store's authMode instead.
+	// +optional
+	AuthMode GSMSecretStoreAuthMode `json:"authMode,omitempty"`
+
+	// DryRun resolves the payloads and builds the target Secret without
+	// writing it. What a sync would change is recorded in status.plan
+	// instead, with hashes in place of values.
+	// +optional
+	DryRun bool `json:"dryRun,omitempty"`
 }
 
 // GSMSecretTargetSecret describes the Kubernetes Secret to materialize into.
+// +kubebuilder:validation:XValidation:rule="!has(self.immutable) || !self.immutable || size(self.name) <= 242",message="name may be at most 242 characters when immutable is set, leaving room for the -<hash> suffix"
 type GSMSecretTargetSecret struct {
 	// Name is the name of the Kubernetes Secret to create or update.
 	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:MaxLength=253
 	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`
 	Name string `json:"name"`
+
+	// Namespace is the namespace to create or update the Secret in.
+	// Defaults to the GSMSecret's own namespace. Writing into another namespace
+	// requires a GSMSecretGrant in the destination namespace that allows the
+	// GSMSecret's namespace.
+	// +kubebuilder:validation:MaxLength=63
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+
+	// Immutable switches the target to content-hashed naming. Every distinct
+	// payload set is written to a new immutable Secret named "<name>-<hash>",
+	// and the current name is published in status.currentSecretName.
+	// +optional
+	Immutable bool `json:"immutable,omitempty"`
+
+	// RetainGenerations is the number of previous immutable Secrets to keep in
+	// addition to the current one. Secrets still referenced by running pods or
+	// by the pod templates of Deployments, StatefulSets, DaemonSets, Jobs and
+	// CronJobs are never pruned; other workload kinds are not checked. Only
+	// used when Immutable is true.
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:default=3
+	// +optional
+	RetainGenerations *int32 `json:"retainGenerations,omitempty"`
+
+	// ForceConflicts takes ownership of fields of the target Secret that
+	// another field manager set to a different value. By default such a
+	// conflict fails the sync with reason ApplyConflict.
+	// +optional
+	ForceConflicts bool `json:"forceConflicts,omitempty"`
 }
 
 // GSMSecretEntry describes a single GSM secret to materialize.
@@ -63,9 +137,18 @@ type GSMSecretEntry struct {
 	Keys []SecretKeyMapping `json:"keys,omitempty"`
 
 	// ProjectID is the GCP project that owns the Secret Manager secret.
+	// May be omitted when the referenced store sets defaultProjectId.
 	// +kubebuilder:validation:MinLength=1
 	// +kubebuilder:validation:Pattern=`^[a-z][a-z0-9-]{4,28}[a-z0-9]$`
-	ProjectID string `json:"projectId"`
+	// +optional
+	ProjectID string `json:"projectId,omitempty"`
+
+	// QuotaProjectID is the project the Secret Manager call for this entry is
+	// billed to and counted against, sent as x-goog-user-project. Defaults to
+	// the store's quotaProjectId.
+	// +kubebuilder:validation:Pattern=`^[a-z][a-z0-9-]{4,28}[a-z0-9]$`
+	// +optional
+	QuotaProjectID string `json:"quotaProjectId,omitempty"`
 
 	// SecretID is the name of the Secret Manager secret.
 	// Example: "my-secret".
@@ -95,6 +178,68 @@ type SecretKeyMapping struct {
 	Value string `json:"value"`
 }
 
+// ResolvedSecretVersion is the version a requested secret version resolved to.
+type ResolvedSecretVersion struct {
+	// Secret is the requested version resource name, e.g.
+	// "projects/my-project/secrets/db-password/versions/latest".
+	Secret string `json:"secret"`
+
+	// Version is the version number it resolved to.
+	Version string `json:"version"`
+}
+
+// SecretPlanAction is what a sync would do to the target Secret.
+// +kubebuilder:validation:Enum=Create;Update;None
+type SecretPlanAction string
+
+const (
+	// SecretPlanCreate means the target Secret does not exist yet.
+	SecretPlanCreate SecretPlanAction = "Create"
+	// SecretPlanUpdate means the target Secret would be changed.
+	SecretPlanUpdate SecretPlanAction = "Update"
+	// SecretPlanNone means the target Secret is already up to date.
+	SecretPlanNone SecretPlanAction = "None"
+)
+
+// SecretPlan describes what a sync would change in the target Secret. It is
+// recorded while spec.dryRun is set.
+type SecretPlan struct {
+	// Secret is the target Secret, as "<namespace>/<name>".
+	Secret string `json:"secret"`
+
+	// Action is what a sync would do to the Secret.
+	Action SecretPlanAction `json:"action"`
+
+	// Added lists the keys a sync would add. Values and their hashes are
+	// never recorded.
+	// +optional
+	Added []string `json:"added,omitempty"`
+
+	// Changed lists the keys whose value a sync would change.
+	// +optional
+	Changed []string `json:"changed,omitempty"`
+
+	// Removed lists the keys the operator wrote earlier and a sync would
+	// remove.
+	// +optional
+	Removed []string `json:"removed,omitempty"`
+
+	// TypeChange is "<current> -> <desired>" when a sync would change the
+	// Secret type.
+	// +optional
+	TypeChange string `json:"typeChange,omitempty"`
+
+	// Metadata lists the label, annotation, owner reference and
+	// immutability changes a sync would make, without their values.
+	// +optional
+	Metadata []string `json:"metadata,omitempty"`
+
+	// Conflict is why a sync would fail, such as a server-side apply conflict
+	// with another field manager. Empty when a sync would succeed.
+	// +optional
+	Conflict string `json:"conflict,omitempty"`
+}
+
 // GSMSecretStatus defines the observed state of GSMSecret.
 type GSMSecretStatus struct {
 	// ObservedGeneration is the most recent generation observed by the controller.
@@ -102,6 +247,37 @@ type GSMSecretStatus struct {
 	// +optional
 	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
 
+	// CurrentSecretName is the name of the Secret holding the current payloads.
+	// It equals targetSecret.name unless targetSecret.immutable is set, in which
+	// case it carries the content hash suffix.
+	// +optional
+	CurrentSecretName string `json:"currentSecretName,omitempty"`
+
+	// CurrentSecretNamespace is the namespace of the Secret named by
+	// currentSecretName. When targetSecret.namespace changes, the Secrets the
+	// GSMSecret wrote into this namespace are deleted.
+	// +optional
+	CurrentSecretNamespace string `json:"currentSecretNamespace,omitempty"`
+
+	// EffectiveAuthMode is the auth mode resolved from the store, the spec and
+	// the operator default on the last reconcile.
+	// +optional
+	EffectiveAuthMode GSMSecretStoreAuthMode `json:"effectiveAuthMode,omitempty"`
+
+	// ResolvedVersions records the Secret Manager version each requested
+	// secret version resolved to on the last successful sync, so aliases
+	// such as "latest" moving to a new version can be reported, and resyncs
+	// can skip reading payloads when no version moved.
+	// +listType=map
+	// +listMapKey=secret
+	// +optional
+	ResolvedVersions []ResolvedSecretVersion `json:"resolvedVersions,omitempty"`
+
+	// Plan is what a sync would change in the target Secret, recorded while
+	// spec.dryRun is set. It is cleared once dryRun is unset.
+	// +optional
+	Plan *SecretPlan `json:"plan,omitempty"`
+
 	// For Kubernetes API conventions, see:
 	// https://github.com/kubernetes/community/blob/master/contributors/devel/sig-architecture/api-conventions.md#typical-status-properties
 
@@ -109,9 +285,9 @@ type GSMSecretStatus struct {
 	// Each condition has a unique type and reflects the status of a specific aspect of the resource.
 	//
 	// Standard condition types include:
-	// - "Ready": the Secret has been successfully materialized.
-	// - "Progressing": the Secret is being created or updated.
-	// - "Degraded": the controller failed to reach or maintain the desired state.
+	// - "Ready": the Secret for the current generation and GSM versions is live.
+	// - "Progressing": a new generation or GSM version is being applied.
+	// - "Degraded": the last sync failed while an older Secret is still in place.
 	//
 	// The status of each condition is one of True, False, or Unknown.
 	// +listType=map
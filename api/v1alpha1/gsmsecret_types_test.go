@@ -3,6 +3,7 @@ package v1alpha1
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
@@ -100,7 +101,8 @@ func TestGSMSecretEntryVersionRequiredNoDefault(t *testing.T) {
 	}
 }
 
-// gsmSecrets entries require projectId, secretId with minLength=1.
+// gsmSecrets entries require secretId with minLength=1. projectId keeps
+// minLength=1 but is optional so a store's defaultProjectId can fill it in.
 // Note: key is now optional (mutually exclusive with keys via XOR validation).
 func TestGSMSecretEntryRequiredCoreFields(t *testing.T) {
 	specSchema := loadSpecSchema(t)
@@ -113,9 +115,15 @@ func TestGSMSecretEntryRequiredCoreFields(t *testing.T) {
 	entry := prop.Items.Schema
 
 	// These fields are always required
-	requiredFieldsList := []string{"projectId", "secretId"}
+	requiredFieldsList := []string{"secretId"}
 
 	required := requiredFields(entry.Required)
+	if _, ok := required["projectId"]; ok {
+		t.Errorf("projectId should be optional; required fields: %v", entry.Required)
+	}
+	if p, ok := entry.Properties["projectId"]; !ok || p.MinLength == nil || *p.MinLength != 1 {
+		t.Errorf("projectId minLength should stay 1")
+	}
 	for _, name := range requiredFieldsList {
 		t.Run(name, func(t *testing.T) {
 			p, ok := entry.Properties[name]
@@ -428,6 +436,141 @@ func TestTargetSecretNameMinLength(t *testing.T) {
 	}
 }
 
+// targetSecret.name fits a Secret name, and leaves room for the hash suffix
+// in immutable mode.
+func TestTargetSecretNameMaxLength(t *testing.T) {
+	specSchema := loadSpecSchema(t)
+
+	target, ok := specSchema.Properties["targetSecret"]
+	if !ok {
+		t.Fatalf("targetSecret property missing from schema")
+	}
+	if nameProp := target.Properties["name"]; nameProp.MaxLength == nil || *nameProp.MaxLength != 253 {
+		t.Fatalf("targetSecret.name maxLength = %v, want 253", nameProp.MaxLength)
+	}
+
+	found := false
+	for _, v := range target.XValidations {
+		if strings.Contains(v.Rule, "self.immutable") && strings.Contains(v.Rule, "size(self.name) <= 242") {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatalf("targetSecret should limit name to 242 characters when immutable, got %v", target.XValidations)
+	}
+}
+
+// targetSecret.namespace is optional and must be a valid namespace name.
+func TestTargetSecretNamespaceIsOptional(t *testing.T) {
+	specSchema := loadSpecSchema(t)
+
+	target, ok := specSchema.Properties["targetSecret"]
+	if !ok {
+		t.Fatalf("targetSecret property missing from schema")
+	}
+
+	nsProp, ok := target.Properties["namespace"]
+	if !ok {
+		t.Fatalf("namespace property missing from targetSecret schema")
+	}
+
+	const expectedPattern = "^[a-z0-9]([-a-z0-9]*[a-z0-9])?$"
+	if nsProp.Pattern != expectedPattern {
+		t.Fatalf("targetSecret.namespace pattern = %q, want %q", nsProp.Pattern, expectedPattern)
+	}
+
+	if _, ok := requiredFields(target.Required)["namespace"]; ok {
+		t.Fatalf("targetSecret.namespace should be optional")
+	}
+}
+
+// storeRef is optional; its kind defaults to the namespaced GSMSecretStore.
+func TestGSMSecretSpecStoreRef(t *testing.T) {
+	specSchema := loadSpecSchema(t)
+
+	if _, ok := requiredFields(specSchema.Required)["storeRef"]; ok {
+		t.Fatalf("storeRef should be optional")
+	}
+
+	ref, ok := specSchema.Properties["storeRef"]
+	if !ok {
+		t.Fatalf("storeRef property missing from schema")
+	}
+	if _, ok := requiredFields(ref.Required)["name"]; !ok {
+		t.Fatalf("storeRef.name should be required")
+	}
+
+	kind := ref.Properties["kind"]
+	if kind.Default == nil || string(kind.Default.Raw) != `"GSMSecretStore"` {
+		t.Fatalf("storeRef.kind default = %v, want GSMSecretStore", kind.Default)
+	}
+	if len(kind.Enum) != 2 {
+		t.Fatalf("storeRef.kind enum = %v, want GSMSecretStore and ClusterGSMSecretStore", kind.Enum)
+	}
+}
+
+// credentialsSecretRef is optional and cannot be combined with storeRef.
+func TestGSMSecretSpecCredentialsSecretRef(t *testing.T) {
+	specSchema := loadSpecSchema(t)
+
+	ref, ok := specSchema.Properties["credentialsSecretRef"]
+	if !ok {
+		t.Fatalf("credentialsSecretRef property missing from schema")
+	}
+	if _, ok := requiredFields(specSchema.Required)["credentialsSecretRef"]; ok {
+		t.Fatalf("credentialsSecretRef should be optional")
+	}
+	if _, ok := requiredFields(ref.Required)["name"]; !ok {
+		t.Fatalf("credentialsSecretRef.name should be required")
+	}
+	if _, ok := requiredFields(ref.Required)["key"]; ok {
+		t.Fatalf("credentialsSecretRef.key should be optional")
+	}
+
+	found := false
+	for _, v := range specSchema.XValidations {
+		if strings.Contains(v.Rule, "storeRef") && strings.Contains(v.Rule, "credentialsSecretRef") {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatalf("spec should reject storeRef together with credentialsSecretRef, got %v", specSchema.XValidations)
+	}
+}
+
+// authMode is optional, shares the store enum, and cannot contradict storeRef
+// or credentialsSecretRef.
+func TestGSMSecretSpecAuthMode(t *testing.T) {
+	specSchema := loadSpecSchema(t)
+
+	mode, ok := specSchema.Properties["authMode"]
+	if !ok {
+		t.Fatalf("authMode property missing from schema")
+	}
+	if _, ok := requiredFields(specSchema.Required)["authMode"]; ok {
+		t.Fatalf("authMode should be optional")
+	}
+	if mode.Default != nil {
+		t.Fatalf("authMode should have no default so the operator MODE applies, got %s", mode.Default.Raw)
+	}
+	if len(mode.Enum) != 4 {
+		t.Fatalf("authMode enum = %v, want the four store auth modes", mode.Enum)
+	}
+
+	var withStore, withCredentials bool
+	for _, v := range specSchema.XValidations {
+		if strings.Contains(v.Rule, "authMode") && strings.Contains(v.Rule, "storeRef") {
+			withStore = true
+		}
+		if strings.Contains(v.Rule, "authMode") && strings.Contains(v.Rule, "credentialsSecretRef") {
+			withCredentials = true
+		}
+	}
+	if !withStore || !withCredentials {
+		t.Fatalf("spec should validate authMode against storeRef and credentialsSecretRef, got %v", specSchema.XValidations)
+	}
+}
+
 func loadSpecSchema(t *testing.T) *apiextensionsv1.JSONSchemaProps {
 	t.Helper()
 
@@ -497,6 +640,16 @@ func TestAnnotationConstants(t *testing.T) {
 			constant: AnnotationWIFAudience,
 			expected: "secrets.gsm-operator.io/wif-audience",
 		},
+		{
+			name:     "AnnotationGSADelegates",
+			constant: AnnotationGSADelegates,
+			expected: "secrets.gsm-operator.io/gsa-delegates",
+		},
+		{
+			name:     "AnnotationImpersonationLifetime",
+			constant: AnnotationImpersonationLifetime,
+			expected: "secrets.gsm-operator.io/impersonation-lifetime",
+		},
 	}
 
 	for _, tt := range tests {
@@ -511,7 +664,10 @@ func TestAnnotationConstants(t *testing.T) {
 func TestAnnotationConstantsHaveCorrectPrefix(t *testing.T) {
 	const expectedPrefix = "secrets.gsm-operator.io/"
 
-	annotations := []string{AnnotationKSA, AnnotationGSA, AnnotationWIFAudience}
+	annotations := []string{
+		AnnotationKSA, AnnotationGSA, AnnotationWIFAudience,
+		AnnotationGSADelegates, AnnotationImpersonationLifetime,
+	}
 	for _, ann := range annotations {
 		if len(ann) < len(expectedPrefix) || ann[:len(expectedPrefix)] != expectedPrefix {
 			t.Errorf("annotation %q does not have expected prefix %q", ann, expectedPrefix)
@@ -533,6 +689,11 @@ func TestGSMSecretStatusSchema(t *testing.T) {
 	if _, ok := statusSchema.Properties["conditions"]; !ok {
 		t.Fatal("conditions property missing from status schema")
 	}
+
+	// effectiveAuthMode should be present
+	if _, ok := statusSchema.Properties["effectiveAuthMode"]; !ok {
+		t.Fatal("effectiveAuthMode property missing from status schema")
+	}
 }
 
 func TestGSMSecretStatusObservedGenerationFormat(t *testing.T) {
@@ -0,0 +1,78 @@
+/*
+Copyright 2025 Zera Holladay.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+// GSMSecretGrantSpec defines which namespaces may write target Secrets into
+// the namespace the GSMSecretGrant lives in.
+type GSMSecretGrantSpec struct {
+	// From lists the namespaces whose GSMSecrets may write into this namespace.
+	// +kubebuilder:validation:MinItems=1
+	From []GSMSecretGrantFrom `json:"from"`
+
+	// To optionally restricts which Secret names may be written.
+	// When empty, any Secret name is allowed.
+	// +optional
+	To []GSMSecretGrantTo `json:"to,omitempty"`
+}
+
+// GSMSecretGrantFrom identifies a source namespace trusted by a GSMSecretGrant.
+type GSMSecretGrantFrom struct {
+	// Namespace is the namespace of the GSMSecrets allowed to write here.
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:MaxLength=63
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`
+	Namespace string `json:"namespace"`
+}
+
+// GSMSecretGrantTo identifies a Secret that may be written by a trusted namespace.
+type GSMSecretGrantTo struct {
+	// Name is the name of the Secret that may be written.
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`
+	Name string `json:"name"`
+}
+
+// +kubebuilder:object:root=true
+
+// GSMSecretGrant allows GSMSecrets in other namespaces to write target Secrets
+// into the GSMSecretGrant's namespace, similar to a Gateway API ReferenceGrant.
+type GSMSecretGrant struct {
+	metav1.TypeMeta `json:",inline"`
+
+	// Metadata is standard object metadata.
+	// +optional
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	// Spec defines which namespaces and Secrets are covered by the grant.
+	// +required
+	Spec GSMSecretGrantSpec `json:"spec"`
+}
+
+// +kubebuilder:object:root=true
+
+// GSMSecretGrantList contains a list of GSMSecretGrant.
+type GSMSecretGrantList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []GSMSecretGrant `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&GSMSecretGrant{}, &GSMSecretGrantList{})
+}
@@ -0,0 +1,313 @@
+/*
+Copyright 2025 Zera Holladay.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+// Store kinds that a GSMSecret may reference through spec.storeRef.
+const (
+	GSMSecretStoreKind        = "GSMSecretStore"
+	ClusterGSMSecretStoreKind = "ClusterGSMSecretStore"
+)
+
+// GSMSecretStoreAuthMode selects how the operator authenticates to Google Cloud.
+// +kubebuilder:validation:Enum=WorkloadIdentityFederation;TrustedSubsystem;CredentialsSecret;ExternalAccount
+type GSMSecretStoreAuthMode string
+
+const (
+	// AuthModeWorkloadIdentityFederation exchanges the tenant KSA token via
+	// Workload Identity Federation.
+	AuthModeWorkloadIdentityFederation GSMSecretStoreAuthMode = "WorkloadIdentityFederation"
+	// AuthModeTrustedSubsystem uses the operator's own identity.
+	AuthModeTrustedSubsystem GSMSecretStoreAuthMode = "TrustedSubsystem"
+	// AuthModeCredentialsSecret reads a GSA JSON key or an external_account
+	// credential configuration from a Kubernetes Secret.
+	AuthModeCredentialsSecret GSMSecretStoreAuthMode = "CredentialsSecret"
+	// AuthModeExternalAccount uses the external_account credential
+	// configuration the operator is deployed with.
+	AuthModeExternalAccount GSMSecretStoreAuthMode = "ExternalAccount"
+)
+
+// DefaultCredentialsSecretKey is the Secret data key read when a
+// CredentialsSecretRef does not set one.
+const DefaultCredentialsSecretKey = "credentials.json"
+
+// CredentialsSecretRef references a key of a Kubernetes Secret, in the
+// GSMSecret's namespace, that holds Google credentials: a service account
+// JSON key or an external_account credential configuration.
+type CredentialsSecretRef struct {
+	// Name is the name of the Secret.
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:MaxLength=253
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9.]*[a-z0-9])?$`
+	Name string `json:"name"`
+
+	// Key is the Secret data key holding the JSON. Defaults to "credentials.json".
+	// +kubebuilder:validation:Pattern=`^[-._a-zA-Z0-9]+$`
+	// +optional
+	Key string `json:"key,omitempty"`
+}
+
+// Kinds of object an ExternalAccountConfigRef may reference.
+const (
+	ExternalAccountConfigRefKindSecret    = "Secret"
+	ExternalAccountConfigRefKindConfigMap = "ConfigMap"
+)
+
+// ExternalAccountConfigRef references a key of a Secret or ConfigMap that
+// holds an external_account credential configuration, as written by
+// "gcloud iam workload-identity-pools create-cred-config".
+type ExternalAccountConfigRef struct {
+	// Kind is Secret or ConfigMap. Defaults to Secret.
+	// +kubebuilder:validation:Enum=Secret;ConfigMap
+	// +kubebuilder:default=Secret
+	// +optional
+	Kind string `json:"kind,omitempty"`
+
+	// Name is the name of the Secret or ConfigMap.
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:MaxLength=253
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9.]*[a-z0-9])?$`
+	Name string `json:"name"`
+
+	// Namespace is the namespace of the Secret or ConfigMap.
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:MaxLength=63
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`
+	Namespace string `json:"namespace"`
+
+	// Key is the data key holding the JSON. Defaults to "credentials.json".
+	// +kubebuilder:validation:Pattern=`^[-._a-zA-Z0-9]+$`
+	// +optional
+	Key string `json:"key,omitempty"`
+}
+
+// GSMSecretStoreSpec holds the auth configuration shared by every GSMSecret
+// that references the store. Fields left empty fall back to the operator's
+// environment defaults.
+type GSMSecretStoreSpec struct {
+	// AuthMode selects how the operator authenticates to Google Cloud.
+	// +kubebuilder:default=WorkloadIdentityFederation
+	// +optional
+	AuthMode GSMSecretStoreAuthMode `json:"authMode,omitempty"`
+
+	// Audience is the Workload Identity Federation provider audience, i.e.
+	// "//iam.googleapis.com/projects/<number>/locations/global/workloadIdentityPools/<pool>/providers/<provider>".
+	// Only used with WorkloadIdentityFederation.
+	// +kubebuilder:validation:Pattern=`^//iam\.googleapis\.com/.+$`
+	// +optional
+	Audience string `json:"audience,omitempty"`
+
+	// ServiceAccountName is the Kubernetes ServiceAccount, in the GSMSecret's
+	// namespace, whose token is exchanged. Defaults to "default".
+	// Only used with WorkloadIdentityFederation.
+	// +kubebuilder:validation:MaxLength=253
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9.]*[a-z0-9])?$`
+	// +optional
+	ServiceAccountName string `json:"serviceAccountName,omitempty"`
+
+	// CredentialsSecretRef is the Secret holding the Google credentials, resolved
+	// in each GSMSecret's namespace. Required with CredentialsSecret and not
+	// used with other modes.
+	// +optional
+	CredentialsSecretRef *CredentialsSecretRef `json:"credentialsSecretRef,omitempty"`
+
+	// ExternalAccountConfigRef is the external_account credential
+	// configuration used instead of the operator's EXTERNAL_ACCOUNT_CONFIG.
+	// Only used with ExternalAccount, which only a ClusterGSMSecretStore may
+	// select.
+	// +optional
+	ExternalAccountConfigRef *ExternalAccountConfigRef `json:"externalAccountConfigRef,omitempty"`
+
+	// Impersonation optionally impersonates a Google Service Account, through
+	// a delegation chain, after the federated exchange or with the credentials
+	// from the Secret.
+	// +optional
+	Impersonation *GSMSecretStoreImpersonation `json:"impersonation,omitempty"`
+
+	// DefaultProjectID is the GCP project used for gsmSecrets entries that do
+	// not set projectId.
+	// +kubebuilder:validation:Pattern=`^[a-z][a-z0-9-]{4,28}[a-z0-9]$`
+	// +optional
+	DefaultProjectID string `json:"defaultProjectId,omitempty"`
+
+	// QuotaProjectID is the project Secret Manager and IAM Credentials calls
+	// are billed to and counted against, sent as x-goog-user-project. The
+	// identity needs serviceusage.services.use on it. Entries may override it.
+	// +kubebuilder:validation:Pattern=`^[a-z][a-z0-9-]{4,28}[a-z0-9]$`
+	// +optional
+	QuotaProjectID string `json:"quotaProjectId,omitempty"`
+
+	// The endpoint and universe settings below are only accepted on a
+	// ClusterGSMSecretStore, and are ignored in TrustedSubsystem and
+	// ExternalAccount modes, so tenants cannot redirect operator tokens.
+
+	// Endpoint overrides the Secret Manager API endpoint as host:port,
+	// e.g. "secretmanager.us-central1.rep.googleapis.com:443".
+	// +kubebuilder:validation:Pattern=`^[A-Za-z0-9.-]+(:[0-9]+)?$`
+	// +optional
+	Endpoint string `json:"endpoint,omitempty"`
+
+	// STSEndpoint overrides the Security Token Service endpoint as a base URL,
+	// e.g. "https://sts-myendpoint.p.googleapis.com" for Private Service Connect.
+	// +kubebuilder:validation:Pattern=`^https://[A-Za-z0-9.-]+(:[0-9]+)?/?$`
+	// +optional
+	STSEndpoint string `json:"stsEndpoint,omitempty"`
+
+	// IAMCredentialsEndpoint overrides the IAM Credentials endpoint used for
+	// impersonation as a base URL,
+	// e.g. "https://iamcredentials-myendpoint.p.googleapis.com".
+	// +kubebuilder:validation:Pattern=`^https://[A-Za-z0-9.-]+(:[0-9]+)?/?$`
+	// +optional
+	IAMCredentialsEndpoint string `json:"iamCredentialsEndpoint,omitempty"`
+
+	// UniverseDomain is the Google Cloud universe the default endpoints are
+	// built from, for sovereign clouds. Defaults to "googleapis.com".
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9.]*[a-z0-9])?$`
+	// +optional
+	UniverseDomain string `json:"universeDomain,omitempty"`
+
+	// Timeouts overrides the operator's request and token timeouts.
+	// +optional
+	Timeouts *GSMSecretStoreTimeouts `json:"timeouts,omitempty"`
+}
+
+// GSMSecretStoreImpersonation describes the Google Service Account to
+// impersonate and the delegation chain leading to it.
+type GSMSecretStoreImpersonation struct {
+	// ServiceAccount is the email of the Google Service Account to impersonate.
+	// +kubebuilder:validation:MinLength=1
+	ServiceAccount string `json:"serviceAccount"`
+
+	// Delegates is the ordered delegation chain: the federated identity
+	// impersonates the first delegate, each delegate the next, and the last
+	// one impersonates ServiceAccount.
+	// +optional
+	Delegates []string `json:"delegates,omitempty"`
+
+	// Lifetime is the lifetime of impersonated tokens, at most 12h.
+	// Defaults to 1h.
+	// +optional
+	Lifetime *metav1.Duration `json:"lifetime,omitempty"`
+}
+
+// GSMSecretStoreTimeouts overrides the operator's timeouts.
+type GSMSecretStoreTimeouts struct {
+	// Request bounds each call to Google APIs.
+	// +optional
+	Request *metav1.Duration `json:"request,omitempty"`
+
+	// TokenExpiration is the lifetime requested for Kubernetes ServiceAccount
+	// tokens. Kubernetes enforces a minimum of 10m.
+	// +optional
+	TokenExpiration *metav1.Duration `json:"tokenExpiration,omitempty"`
+}
+
+// GSMSecretStoreStatus defines the observed state of a GSMSecretStore or
+// ClusterGSMSecretStore.
+type GSMSecretStoreStatus struct {
+	// ObservedGeneration is the most recent generation observed by the controller.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// Conditions represent the current state of the store. The "Ready"
+	// condition reports whether the store's configuration is valid.
+	// +listType=map
+	// +listMapKey=type
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// GSMSecretStoreRef references the store a GSMSecret takes its auth
+// configuration from.
+type GSMSecretStoreRef struct {
+	// Kind is GSMSecretStore (in the GSMSecret's namespace) or
+	// ClusterGSMSecretStore.
+	// +kubebuilder:validation:Enum=GSMSecretStore;ClusterGSMSecretStore
+	// +kubebuilder:default=GSMSecretStore
+	// +optional
+	Kind string `json:"kind,omitempty"`
+
+	// Name is the name of the store.
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+
+// GSMSecretStore holds reusable auth configuration for GSMSecrets in its namespace.
+type GSMSecretStore struct {
+	metav1.TypeMeta `json:",inline"`
+
+	// Metadata is standard object metadata.
+	// +optional
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	// Spec defines the store's auth configuration.
+	// +required
+	Spec GSMSecretStoreSpec `json:"spec"`
+
+	// Status defines the observed state of GSMSecretStore.
+	// +optional
+	Status GSMSecretStoreStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// GSMSecretStoreList contains a list of GSMSecretStore.
+type GSMSecretStoreList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []GSMSecretStore `json:"items"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:scope=Cluster
+
+// ClusterGSMSecretStore holds reusable auth configuration for GSMSecrets in
+// any namespace. The ServiceAccount is resolved in each GSMSecret's namespace.
+type ClusterGSMSecretStore struct {
+	metav1.TypeMeta `json:",inline"`
+
+	// Metadata is standard object metadata.
+	// +optional
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	// Spec defines the store's auth configuration.
+	// +required
+	Spec GSMSecretStoreSpec `json:"spec"`
+
+	// Status defines the observed state of ClusterGSMSecretStore.
+	// +optional
+	Status GSMSecretStoreStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// ClusterGSMSecretStoreList contains a list of ClusterGSMSecretStore.
+type ClusterGSMSecretStoreList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ClusterGSMSecretStore `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&GSMSecretStore{}, &GSMSecretStoreList{})
+	SchemeBuilder.Register(&ClusterGSMSecretStore{}, &ClusterGSMSecretStoreList{})
+}
@@ -25,6 +25,184 @@ import (
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterGSMSecretStore) DeepCopyInto(out *ClusterGSMSecretStore) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterGSMSecretStore.
+func (in *ClusterGSMSecretStore) DeepCopy() *ClusterGSMSecretStore {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterGSMSecretStore)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ClusterGSMSecretStore) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterGSMSecretStoreList) DeepCopyInto(out *ClusterGSMSecretStoreList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ClusterGSMSecretStore, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterGSMSecretStoreList.
+func (in *ClusterGSMSecretStoreList) DeepCopy() *ClusterGSMSecretStoreList {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterGSMSecretStoreList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ClusterGSMSecretStoreList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CredentialsSecretRef) DeepCopyInto(out *CredentialsSecretRef) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CredentialsSecretRef.
+func (in *CredentialsSecretRef) DeepCopy() *CredentialsSecretRef {
+	if in == nil {
+		return nil
+	}
+	out := new(CredentialsSecretRef)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ExternalAccountConfigRef) DeepCopyInto(out *ExternalAccountConfigRef) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExternalAccountConfigRef.
+func (in *ExternalAccountConfigRef) DeepCopy() *ExternalAccountConfigRef {
+	if in == nil {
+		return nil
+	}
+	out := new(ExternalAccountConfigRef)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GSMAccessPolicy) DeepCopyInto(out *GSMAccessPolicy) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GSMAccessPolicy.
+func (in *GSMAccessPolicy) DeepCopy() *GSMAccessPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(GSMAccessPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *GSMAccessPolicy) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GSMAccessPolicyList) DeepCopyInto(out *GSMAccessPolicyList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]GSMAccessPolicy, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GSMAccessPolicyList.
+func (in *GSMAccessPolicyList) DeepCopy() *GSMAccessPolicyList {
+	if in == nil {
+		return nil
+	}
+	out := new(GSMAccessPolicyList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *GSMAccessPolicyList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GSMAccessPolicySpec) DeepCopyInto(out *GSMAccessPolicySpec) {
+	*out = *in
+	in.NamespaceSelector.DeepCopyInto(&out.NamespaceSelector)
+	if in.ProjectIDs != nil {
+		in, out := &in.ProjectIDs, &out.ProjectIDs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.SecretIDs != nil {
+		in, out := &in.SecretIDs, &out.SecretIDs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Principals != nil {
+		in, out := &in.Principals, &out.Principals
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GSMAccessPolicySpec.
+func (in *GSMAccessPolicySpec) DeepCopy() *GSMAccessPolicySpec {
+	if in == nil {
+		return nil
+	}
+	out := new(GSMAccessPolicySpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *GSMSecret) DeepCopyInto(out *GSMSecret) {
 	*out = *in
@@ -72,6 +250,119 @@ func (in *GSMSecretEntry) DeepCopy() *GSMSecretEntry {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GSMSecretGrant) DeepCopyInto(out *GSMSecretGrant) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GSMSecretGrant.
+func (in *GSMSecretGrant) DeepCopy() *GSMSecretGrant {
+	if in == nil {
+		return nil
+	}
+	out := new(GSMSecretGrant)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *GSMSecretGrant) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GSMSecretGrantFrom) DeepCopyInto(out *GSMSecretGrantFrom) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GSMSecretGrantFrom.
+func (in *GSMSecretGrantFrom) DeepCopy() *GSMSecretGrantFrom {
+	if in == nil {
+		return nil
+	}
+	out := new(GSMSecretGrantFrom)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GSMSecretGrantList) DeepCopyInto(out *GSMSecretGrantList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]GSMSecretGrant, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GSMSecretGrantList.
+func (in *GSMSecretGrantList) DeepCopy() *GSMSecretGrantList {
+	if in == nil {
+		return nil
+	}
+	out := new(GSMSecretGrantList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *GSMSecretGrantList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GSMSecretGrantSpec) DeepCopyInto(out *GSMSecretGrantSpec) {
+	*out = *in
+	if in.From != nil {
+		in, out := &in.From, &out.From
+		*out = make([]GSMSecretGrantFrom, len(*in))
+		copy(*out, *in)
+	}
+	if in.To != nil {
+		in, out := &in.To, &out.To
+		*out = make([]GSMSecretGrantTo, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GSMSecretGrantSpec.
+func (in *GSMSecretGrantSpec) DeepCopy() *GSMSecretGrantSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(GSMSecretGrantSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GSMSecretGrantTo) DeepCopyInto(out *GSMSecretGrantTo) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GSMSecretGrantTo.
+func (in *GSMSecretGrantTo) DeepCopy() *GSMSecretGrantTo {
+	if in == nil {
+		return nil
+	}
+	out := new(GSMSecretGrantTo)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *GSMSecretList) DeepCopyInto(out *GSMSecretList) {
 	*out = *in
@@ -107,7 +398,7 @@ func (in *GSMSecretList) DeepCopyObject() runtime.Object {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *GSMSecretSpec) DeepCopyInto(out *GSMSecretSpec) {
 	*out = *in
-	out.TargetSecret = in.TargetSecret
+	in.TargetSecret.DeepCopyInto(&out.TargetSecret)
 	if in.Secrets != nil {
 		in, out := &in.Secrets, &out.Secrets
 		*out = make([]GSMSecretEntry, len(*in))
@@ -115,6 +406,16 @@ func (in *GSMSecretSpec) DeepCopyInto(out *GSMSecretSpec) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.StoreRef != nil {
+		in, out := &in.StoreRef, &out.StoreRef
+		*out = new(GSMSecretStoreRef)
+		**out = **in
+	}
+	if in.CredentialsSecretRef != nil {
+		in, out := &in.CredentialsSecretRef, &out.CredentialsSecretRef
+		*out = new(CredentialsSecretRef)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GSMSecretSpec.
@@ -130,6 +431,16 @@ func (in *GSMSecretSpec) DeepCopy() *GSMSecretSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *GSMSecretStatus) DeepCopyInto(out *GSMSecretStatus) {
 	*out = *in
+	if in.ResolvedVersions != nil {
+		in, out := &in.ResolvedVersions, &out.ResolvedVersions
+		*out = make([]ResolvedSecretVersion, len(*in))
+		copy(*out, *in)
+	}
+	if in.Plan != nil {
+		in, out := &in.Plan, &out.Plan
+		*out = new(SecretPlan)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.Conditions != nil {
 		in, out := &in.Conditions, &out.Conditions
 		*out = make([]v1.Condition, len(*in))
@@ -149,9 +460,195 @@ func (in *GSMSecretStatus) DeepCopy() *GSMSecretStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GSMSecretStore) DeepCopyInto(out *GSMSecretStore) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GSMSecretStore.
+func (in *GSMSecretStore) DeepCopy() *GSMSecretStore {
+	if in == nil {
+		return nil
+	}
+	out := new(GSMSecretStore)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *GSMSecretStore) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GSMSecretStoreImpersonation) DeepCopyInto(out *GSMSecretStoreImpersonation) {
+	*out = *in
+	if in.Delegates != nil {
+		in, out := &in.Delegates, &out.Delegates
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Lifetime != nil {
+		in, out := &in.Lifetime, &out.Lifetime
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GSMSecretStoreImpersonation.
+func (in *GSMSecretStoreImpersonation) DeepCopy() *GSMSecretStoreImpersonation {
+	if in == nil {
+		return nil
+	}
+	out := new(GSMSecretStoreImpersonation)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GSMSecretStoreList) DeepCopyInto(out *GSMSecretStoreList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]GSMSecretStore, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GSMSecretStoreList.
+func (in *GSMSecretStoreList) DeepCopy() *GSMSecretStoreList {
+	if in == nil {
+		return nil
+	}
+	out := new(GSMSecretStoreList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *GSMSecretStoreList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GSMSecretStoreRef) DeepCopyInto(out *GSMSecretStoreRef) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GSMSecretStoreRef.
+func (in *GSMSecretStoreRef) DeepCopy() *GSMSecretStoreRef {
+	if in == nil {
+		return nil
+	}
+	out := new(GSMSecretStoreRef)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GSMSecretStoreSpec) DeepCopyInto(out *GSMSecretStoreSpec) {
+	*out = *in
+	if in.CredentialsSecretRef != nil {
+		in, out := &in.CredentialsSecretRef, &out.CredentialsSecretRef
+		*out = new(CredentialsSecretRef)
+		**out = **in
+	}
+	if in.ExternalAccountConfigRef != nil {
+		in, out := &in.ExternalAccountConfigRef, &out.ExternalAccountConfigRef
+		*out = new(ExternalAccountConfigRef)
+		**out = **in
+	}
+	if in.Impersonation != nil {
+		in, out := &in.Impersonation, &out.Impersonation
+		*out = new(GSMSecretStoreImpersonation)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Timeouts != nil {
+		in, out := &in.Timeouts, &out.Timeouts
+		*out = new(GSMSecretStoreTimeouts)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GSMSecretStoreSpec.
+func (in *GSMSecretStoreSpec) DeepCopy() *GSMSecretStoreSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(GSMSecretStoreSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GSMSecretStoreStatus) DeepCopyInto(out *GSMSecretStoreStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GSMSecretStoreStatus.
+func (in *GSMSecretStoreStatus) DeepCopy() *GSMSecretStoreStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(GSMSecretStoreStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GSMSecretStoreTimeouts) DeepCopyInto(out *GSMSecretStoreTimeouts) {
+	*out = *in
+	if in.Request != nil {
+		in, out := &in.Request, &out.Request
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.TokenExpiration != nil {
+		in, out := &in.TokenExpiration, &out.TokenExpiration
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GSMSecretStoreTimeouts.
+func (in *GSMSecretStoreTimeouts) DeepCopy() *GSMSecretStoreTimeouts {
+	if in == nil {
+		return nil
+	}
+	out := new(GSMSecretStoreTimeouts)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *GSMSecretTargetSecret) DeepCopyInto(out *GSMSecretTargetSecret) {
 	*out = *in
+	if in.RetainGenerations != nil {
+		in, out := &in.RetainGenerations, &out.RetainGenerations
+		*out = new(int32)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GSMSecretTargetSecret.
@@ -164,6 +661,21 @@ func (in *GSMSecretTargetSecret) DeepCopy() *GSMSecretTargetSecret {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ResolvedSecretVersion) DeepCopyInto(out *ResolvedSecretVersion) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ResolvedSecretVersion.
+func (in *ResolvedSecretVersion) DeepCopy() *ResolvedSecretVersion {
+	if in == nil {
+		return nil
+	}
+	out := new(ResolvedSecretVersion)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SecretKeyMapping) DeepCopyInto(out *SecretKeyMapping) {
 	*out = *in
@@ -178,3 +690,38 @@ func (in *SecretKeyMapping) DeepCopy() *SecretKeyMapping {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SecretPlan) DeepCopyInto(out *SecretPlan) {
+	*out = *in
+	if in.Added != nil {
+		in, out := &in.Added, &out.Added
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Changed != nil {
+		in, out := &in.Changed, &out.Changed
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Removed != nil {
+		in, out := &in.Removed, &out.Removed
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Metadata != nil {
+		in, out := &in.Metadata, &out.Metadata
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SecretPlan.
+func (in *SecretPlan) DeepCopy() *SecretPlan {
+	if in == nil {
+		return nil
+	}
+	out := new(SecretPlan)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -36,7 +36,9 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
 
 	secretspizecomv1alpha1 "github.com/zeraholladay/gsm-operator/api/v1alpha1"
+	"github.com/zeraholladay/gsm-operator/internal/authmode"
 	"github.com/zeraholladay/gsm-operator/internal/controller"
+	webhooksecretsv1alpha1 "github.com/zeraholladay/gsm-operator/internal/webhook/v1alpha1"
 	// +kubebuilder:scaffold:imports
 )
 
@@ -178,13 +180,46 @@ func main() {
 		os.Exit(1)
 	}
 
+	// Refuse to start with an auth mode policy that would deny every GSMSecret.
+	if _, err := authmode.LoadPolicy(); err != nil {
+		setupLog.Error(err, "invalid auth mode policy")
+		os.Exit(1)
+	}
+
 	if err := (&controller.GSMSecretReconciler{
+		Client:    mgr.GetClient(),
+		Scheme:    mgr.GetScheme(),
+		APIReader: mgr.GetAPIReader(),
+		Recorder:  mgr.GetEventRecorderFor("gsmsecret-controller"),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "GSMSecret")
+		os.Exit(1)
+	}
+	if err := (&controller.GSMSecretStoreReconciler{
 		Client: mgr.GetClient(),
 		Scheme: mgr.GetScheme(),
 	}).SetupWithManager(mgr); err != nil {
-		setupLog.Error(err, "unable to create controller", "controller", "GSMSecret")
+		setupLog.Error(err, "unable to create controller", "controller", "GSMSecretStore")
 		os.Exit(1)
 	}
+	if err := (&controller.ClusterGSMSecretStoreReconciler{
+		Client: mgr.GetClient(),
+		Scheme: mgr.GetScheme(),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "ClusterGSMSecretStore")
+		os.Exit(1)
+	}
+	// nolint:goconst
+	if os.Getenv("ENABLE_WEBHOOKS") == "true" {
+		if err := webhooksecretsv1alpha1.SetupGSMSecretWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "GSMSecret")
+			os.Exit(1)
+		}
+		if err := webhooksecretsv1alpha1.SetupGSMSecretStoreWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "GSMSecretStore")
+			os.Exit(1)
+		}
+	}
 	// +kubebuilder:scaffold:builder
 
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
@@ -0,0 +1,141 @@
+/*
+Copyright 2025 Zera Holladay.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package accesspolicy evaluates GSMAccessPolicy objects against the Secret
+// Manager reads a GSMSecret would perform.
+package accesspolicy
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	secretspizecomv1alpha1 "github.com/zeraholladay/gsm-operator/api/v1alpha1"
+)
+
+// Enabled reports whether GSMAccessPolicy enforcement is turned on via the
+// ENFORCE_ACCESS_POLICY environment variable. It defaults to false so existing
+// installs keep working until policies are in place.
+func Enabled() bool {
+	if v := os.Getenv("ENFORCE_ACCESS_POLICY"); v != "" {
+		if enabled, err := strconv.ParseBool(v); err == nil {
+			return enabled
+		}
+	}
+	return false
+}
+
+// Access describes a single Secret Manager read.
+type Access struct {
+	ProjectID string
+	SecretID  string
+	// Principals are the GSAs impersonated for the read, delegates first.
+	Principals []string
+}
+
+func (a Access) String() string {
+	s := fmt.Sprintf("projects/%s/secrets/%s", a.ProjectID, a.SecretID)
+	if len(a.Principals) > 0 {
+		s += " as " + strings.Join(a.Principals, " → ")
+	}
+	return s
+}
+
+// DeniedError reports a read that no GSMAccessPolicy allows.
+type DeniedError struct {
+	Namespace string
+	Access    Access
+}
+
+func (e *DeniedError) Error() string {
+	return fmt.Sprintf("no GSMAccessPolicy allows namespace %q to read %s", e.Namespace, e.Access)
+}
+
+// Check lists the GSMAccessPolicies and the namespace from c and evaluates
+// accesses against them.
+func Check(ctx context.Context, c client.Reader, namespace string, accesses []Access) error {
+	var ns corev1.Namespace
+	if err := c.Get(ctx, types.NamespacedName{Name: namespace}, &ns); err != nil {
+		return fmt.Errorf("get namespace %q: %w", namespace, err)
+	}
+	var policies secretspizecomv1alpha1.GSMAccessPolicyList
+	if err := c.List(ctx, &policies); err != nil {
+		return fmt.Errorf("list GSMAccessPolicies: %w", err)
+	}
+	return Evaluate(policies.Items, &ns, accesses)
+}
+
+// Evaluate returns a *DeniedError for the first access that no policy
+// selecting ns allows. A policy allows an access when it matches the project,
+// the secret and every principal.
+func Evaluate(policies []secretspizecomv1alpha1.GSMAccessPolicy, ns *corev1.Namespace, accesses []Access) error {
+	var selected []*secretspizecomv1alpha1.GSMAccessPolicySpec
+	for i := range policies {
+		sel, err := metav1.LabelSelectorAsSelector(&policies[i].Spec.NamespaceSelector)
+		if err != nil {
+			return fmt.Errorf("GSMAccessPolicy %q: invalid namespaceSelector: %w", policies[i].Name, err)
+		}
+		if sel.Matches(labels.Set(ns.Labels)) {
+			selected = append(selected, &policies[i].Spec)
+		}
+	}
+
+	for _, a := range accesses {
+		allowed := false
+		for _, p := range selected {
+			if allows(p, a) {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return &DeniedError{Namespace: ns.Name, Access: a}
+		}
+	}
+	return nil
+}
+
+func allows(p *secretspizecomv1alpha1.GSMAccessPolicySpec, a Access) bool {
+	if !matchAny(p.ProjectIDs, a.ProjectID) || !matchAny(p.SecretIDs, a.SecretID) {
+		return false
+	}
+	for _, principal := range a.Principals {
+		if !matchAny(p.Principals, principal) {
+			return false
+		}
+	}
+	return true
+}
+
+// matchAny reports whether s matches any of the glob patterns. Malformed
+// patterns never match.
+func matchAny(patterns []string, s string) bool {
+	for _, pattern := range patterns {
+		if ok, err := path.Match(pattern, s); err == nil && ok {
+			return true
+		}
+	}
+	return false
+}
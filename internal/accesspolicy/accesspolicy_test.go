@@ -0,0 +1,198 @@
+/*
+Copyright 2025 Zera Holladay.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package accesspolicy
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	secretspizecomv1alpha1 "github.com/zeraholladay/gsm-operator/api/v1alpha1"
+)
+
+func newPolicy(name string, selector map[string]string, projects, secrets, principals []string) secretspizecomv1alpha1.GSMAccessPolicy {
+	return secretspizecomv1alpha1.GSMAccessPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec: secretspizecomv1alpha1.GSMAccessPolicySpec{
+			NamespaceSelector: metav1.LabelSelector{MatchLabels: selector},
+			ProjectIDs:        projects,
+			SecretIDs:         secrets,
+			Principals:        principals,
+		},
+	}
+}
+
+func TestEnabled(t *testing.T) {
+	t.Setenv("ENFORCE_ACCESS_POLICY", "")
+	if Enabled() {
+		t.Error("expected enforcement to be off by default")
+	}
+	t.Setenv("ENFORCE_ACCESS_POLICY", "true")
+	if !Enabled() {
+		t.Error("expected enforcement to be on")
+	}
+	t.Setenv("ENFORCE_ACCESS_POLICY", "not-a-bool")
+	if Enabled() {
+		t.Error("expected invalid value to fall back to off")
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	payments := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "payments", Labels: map[string]string{"team": "payments"}}}
+	policies := []secretspizecomv1alpha1.GSMAccessPolicy{
+		newPolicy("payments", map[string]string{"team": "payments"},
+			[]string{"payments-*"}, []string{"db-*", "api-key"}, []string{"*@payments-prod.iam.gserviceaccount.com"}),
+		newPolicy("shared", nil, []string{"shared-config"}, []string{"*"}, nil),
+	}
+
+	tests := []struct {
+		name     string
+		ns       *corev1.Namespace
+		accesses []Access
+		denied   string
+	}{
+		{
+			name:     "allowed by selected policy",
+			ns:       payments,
+			accesses: []Access{{ProjectID: "payments-prod", SecretID: "db-password"}},
+		},
+		{
+			name: "allowed principals",
+			ns:   payments,
+			accesses: []Access{{
+				ProjectID:  "payments-prod",
+				SecretID:   "api-key",
+				Principals: []string{"broker@payments-prod.iam.gserviceaccount.com", "reader@payments-prod.iam.gserviceaccount.com"},
+			}},
+		},
+		{
+			name:     "allowed by empty selector",
+			ns:       &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "other"}},
+			accesses: []Access{{ProjectID: "shared-config", SecretID: "anything"}},
+		},
+		{
+			name:     "project not allowed",
+			ns:       payments,
+			accesses: []Access{{ProjectID: "billing-prod", SecretID: "db-password"}},
+			denied:   "projects/billing-prod/secrets/db-password",
+		},
+		{
+			name:     "secret not allowed",
+			ns:       payments,
+			accesses: []Access{{ProjectID: "payments-prod", SecretID: "root-key"}},
+			denied:   "projects/payments-prod/secrets/root-key",
+		},
+		{
+			name: "one principal in chain not allowed",
+			ns:   payments,
+			accesses: []Access{{
+				ProjectID:  "payments-prod",
+				SecretID:   "db-password",
+				Principals: []string{"broker@shared.iam.gserviceaccount.com", "reader@payments-prod.iam.gserviceaccount.com"},
+			}},
+			denied: "broker@shared.iam.gserviceaccount.com",
+		},
+		{
+			name:     "impersonation under policy without principals",
+			ns:       payments,
+			accesses: []Access{{ProjectID: "shared-config", SecretID: "x", Principals: []string{"reader@payments-prod.iam.gserviceaccount.com"}}},
+			denied:   "projects/shared-config/secrets/x",
+		},
+		{
+			name:     "namespace not selected",
+			ns:       &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "other"}},
+			accesses: []Access{{ProjectID: "payments-prod", SecretID: "db-password"}},
+			denied:   `namespace "other"`,
+		},
+		{
+			name:     "second access denied",
+			ns:       payments,
+			accesses: []Access{{ProjectID: "payments-prod", SecretID: "db-password"}, {ProjectID: "payments-prod", SecretID: "other"}},
+			denied:   "projects/payments-prod/secrets/other",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Evaluate(policies, tt.ns, tt.accesses)
+			if tt.denied == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			var denied *DeniedError
+			if !errors.As(err, &denied) {
+				t.Fatalf("expected DeniedError, got %v", err)
+			}
+			if !strings.Contains(err.Error(), tt.denied) {
+				t.Errorf("expected error containing %q, got %v", tt.denied, err)
+			}
+		})
+	}
+}
+
+func TestEvaluate_NoPoliciesDenies(t *testing.T) {
+	ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team"}}
+	err := Evaluate(nil, ns, []Access{{ProjectID: "p-123456", SecretID: "s"}})
+	var denied *DeniedError
+	if !errors.As(err, &denied) {
+		t.Fatalf("expected DeniedError without policies, got %v", err)
+	}
+}
+
+func TestEvaluate_InvalidSelector(t *testing.T) {
+	policy := newPolicy("bad", nil, []string{"*"}, []string{"*"}, nil)
+	policy.Spec.NamespaceSelector.MatchExpressions = []metav1.LabelSelectorRequirement{{Key: "team", Operator: "Bogus"}}
+	ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team"}}
+
+	err := Evaluate([]secretspizecomv1alpha1.GSMAccessPolicy{policy}, ns, []Access{{ProjectID: "p-123456", SecretID: "s"}})
+	var denied *DeniedError
+	if err == nil || errors.As(err, &denied) {
+		t.Fatalf("expected selector error, got %v", err)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = clientgoscheme.AddToScheme(scheme)
+	_ = secretspizecomv1alpha1.AddToScheme(scheme)
+	policy := newPolicy("team", map[string]string{"team": "a"}, []string{"team-a-*"}, []string{"*"}, nil)
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-a", Labels: map[string]string{"team": "a"}}},
+		&policy,
+	).Build()
+
+	ctx := context.Background()
+	if err := Check(ctx, c, "team-a", []Access{{ProjectID: "team-a-prod", SecretID: "s"}}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	var denied *DeniedError
+	if err := Check(ctx, c, "team-a", []Access{{ProjectID: "team-b-prod", SecretID: "s"}}); !errors.As(err, &denied) {
+		t.Errorf("expected DeniedError, got %v", err)
+	}
+	if err := Check(ctx, c, "missing", nil); err == nil || !strings.Contains(err.Error(), `get namespace "missing"`) {
+		t.Errorf("expected namespace lookup error, got %v", err)
+	}
+}
@@ -0,0 +1,191 @@
+/*
+Copyright 2025 Zera Holladay.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package authmode resolves the auth mode a GSMSecret uses and checks it
+// against the operator's per-namespace auth mode policy.
+package authmode
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"strings"
+
+	secretspizecomv1alpha1 "github.com/zeraholladay/gsm-operator/api/v1alpha1"
+)
+
+// Default returns the operator-wide default auth mode from the MODE
+// environment variable (TRUSTED_SUBSYSTEM or EXTERNAL_ACCOUNT), falling back
+// to Workload Identity Federation.
+func Default() secretspizecomv1alpha1.GSMSecretStoreAuthMode {
+	switch os.Getenv("MODE") {
+	case "TRUSTED_SUBSYSTEM":
+		return secretspizecomv1alpha1.AuthModeTrustedSubsystem
+	case "EXTERNAL_ACCOUNT":
+		return secretspizecomv1alpha1.AuthModeExternalAccount
+	}
+	return secretspizecomv1alpha1.AuthModeWorkloadIdentityFederation
+}
+
+// Resolve returns the auth mode gsm uses: the store's authMode, then the
+// GSMSecret's spec.authMode, then CredentialsSecret when it sets
+// credentialsSecretRef, then the operator default.
+func Resolve(
+	gsm *secretspizecomv1alpha1.GSMSecret,
+	store *secretspizecomv1alpha1.GSMSecretStoreSpec,
+) secretspizecomv1alpha1.GSMSecretStoreAuthMode {
+	if store != nil {
+		if store.AuthMode != "" {
+			return store.AuthMode
+		}
+		return Default()
+	}
+	if gsm != nil {
+		if gsm.Spec.AuthMode != "" {
+			return gsm.Spec.AuthMode
+		}
+		if gsm.Spec.CredentialsSecretRef != nil {
+			return secretspizecomv1alpha1.AuthModeCredentialsSecret
+		}
+	}
+	return Default()
+}
+
+// OperatorIdentity reports whether mode reads Secret Manager with the
+// operator's own identity rather than one the tenant supplies.
+func OperatorIdentity(mode secretspizecomv1alpha1.GSMSecretStoreAuthMode) bool {
+	return mode == secretspizecomv1alpha1.AuthModeTrustedSubsystem ||
+		mode == secretspizecomv1alpha1.AuthModeExternalAccount
+}
+
+// CheckNamespacedStore rejects a GSMSecretStore that selects an auth mode
+// using the operator's identity or overrides where tokens are sent. Tenants
+// can edit namespaced stores, so those settings are only accepted on
+// ClusterGSMSecretStores. A store without an authMode uses the operator
+// default, which the operator's admin chose.
+func CheckNamespacedStore(spec *secretspizecomv1alpha1.GSMSecretStoreSpec) error {
+	if OperatorIdentity(spec.AuthMode) {
+		return fmt.Errorf("authMode %s uses the operator's identity and is only allowed on a %s",
+			spec.AuthMode, secretspizecomv1alpha1.ClusterGSMSecretStoreKind)
+	}
+	if spec.Endpoint != "" || spec.STSEndpoint != "" || spec.IAMCredentialsEndpoint != "" || spec.UniverseDomain != "" {
+		return fmt.Errorf("endpoint, stsEndpoint, iamCredentialsEndpoint and universeDomain are only allowed on a %s",
+			secretspizecomv1alpha1.ClusterGSMSecretStoreKind)
+	}
+	return nil
+}
+
+// Policy maps each auth mode to the namespaces allowed to use it. A nil
+// Policy fails closed: it allows the operator default and the modes that use
+// a tenant-supplied identity, but never an operator-identity mode the admin
+// did not choose as the default.
+type Policy map[secretspizecomv1alpha1.GSMSecretStoreAuthMode][]string
+
+// ParsePolicy parses a policy of the form
+//
+//	TrustedSubsystem=platform-*,kube-system;WorkloadIdentityFederation=*
+//
+// Each entry names an auth mode and the namespaces, as path.Match globs,
+// allowed to use it. Modes without an entry are allowed nowhere. An empty
+// string returns a nil Policy.
+func ParsePolicy(raw string) (Policy, error) {
+	if strings.TrimSpace(raw) == "" {
+		return nil, nil
+	}
+	p := Policy{}
+	for _, entry := range strings.Split(raw, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		name, globs, ok := strings.Cut(entry, "=")
+		if !ok {
+			return nil, fmt.Errorf("entry %q: expected <authMode>=<namespace>[,<namespace>...]", entry)
+		}
+		mode := secretspizecomv1alpha1.GSMSecretStoreAuthMode(strings.TrimSpace(name))
+		if !isKnown(mode) {
+			return nil, fmt.Errorf("entry %q: unknown auth mode %q", entry, mode)
+		}
+		for _, glob := range strings.Split(globs, ",") {
+			glob = strings.TrimSpace(glob)
+			if glob == "" {
+				continue
+			}
+			if _, err := path.Match(glob, ""); err != nil {
+				return nil, fmt.Errorf("entry %q: invalid namespace pattern %q: %w", entry, glob, err)
+			}
+			p[mode] = append(p[mode], glob)
+		}
+	}
+	return p, nil
+}
+
+// LoadPolicy parses the AUTH_MODE_POLICY environment variable.
+func LoadPolicy() (Policy, error) {
+	p, err := ParsePolicy(os.Getenv("AUTH_MODE_POLICY"))
+	if err != nil {
+		return nil, fmt.Errorf("AUTH_MODE_POLICY: %w", err)
+	}
+	return p, nil
+}
+
+// Allows reports whether namespace may use mode.
+func (p Policy) Allows(mode secretspizecomv1alpha1.GSMSecretStoreAuthMode, namespace string) bool {
+	if p == nil {
+		return mode == Default() || !OperatorIdentity(mode)
+	}
+	for _, glob := range p[mode] {
+		if ok, _ := path.Match(glob, namespace); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// NotAllowedError reports an auth mode the policy does not allow in a
+// namespace.
+type NotAllowedError struct {
+	Namespace string
+	Mode      secretspizecomv1alpha1.GSMSecretStoreAuthMode
+}
+
+func (e *NotAllowedError) Error() string {
+	return fmt.Sprintf("auth mode %s is not allowed in namespace %q by AUTH_MODE_POLICY", e.Mode, e.Namespace)
+}
+
+// Check returns a *NotAllowedError unless AUTH_MODE_POLICY allows mode in
+// namespace. An invalid policy allows nothing, so a typo never widens access.
+func Check(mode secretspizecomv1alpha1.GSMSecretStoreAuthMode, namespace string) error {
+	p, err := LoadPolicy()
+	if err != nil {
+		return err
+	}
+	if !p.Allows(mode, namespace) {
+		return &NotAllowedError{Namespace: namespace, Mode: mode}
+	}
+	return nil
+}
+
+func isKnown(mode secretspizecomv1alpha1.GSMSecretStoreAuthMode) bool {
+	switch mode {
+	case secretspizecomv1alpha1.AuthModeWorkloadIdentityFederation,
+		secretspizecomv1alpha1.AuthModeTrustedSubsystem,
+		secretspizecomv1alpha1.AuthModeCredentialsSecret,
+		secretspizecomv1alpha1.AuthModeExternalAccount:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,176 @@
+/*
+Copyright 2025 Zera Holladay.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package authmode
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	secretspizecomv1alpha1 "github.com/zeraholladay/gsm-operator/api/v1alpha1"
+)
+
+const (
+	wif     = secretspizecomv1alpha1.AuthModeWorkloadIdentityFederation
+	trusted = secretspizecomv1alpha1.AuthModeTrustedSubsystem
+	credSec = secretspizecomv1alpha1.AuthModeCredentialsSecret
+	extAcct = secretspizecomv1alpha1.AuthModeExternalAccount
+)
+
+func TestResolve(t *testing.T) {
+	credRef := &secretspizecomv1alpha1.CredentialsSecretRef{Name: "gsa-key"}
+
+	tests := []struct {
+		name  string
+		env   string
+		spec  secretspizecomv1alpha1.GSMSecretSpec
+		store *secretspizecomv1alpha1.GSMSecretStoreSpec
+		want  secretspizecomv1alpha1.GSMSecretStoreAuthMode
+	}{
+		{name: "default", want: wif},
+		{name: "operator default", env: "TRUSTED_SUBSYSTEM", want: trusted},
+		{name: "operator external account", env: "EXTERNAL_ACCOUNT", want: extAcct},
+		{name: "spec wins over operator default", env: "TRUSTED_SUBSYSTEM", spec: secretspizecomv1alpha1.GSMSecretSpec{AuthMode: wif}, want: wif},
+		{name: "spec trusted subsystem", spec: secretspizecomv1alpha1.GSMSecretSpec{AuthMode: trusted}, want: trusted},
+		{name: "credentials secret implied", env: "TRUSTED_SUBSYSTEM", spec: secretspizecomv1alpha1.GSMSecretSpec{CredentialsSecretRef: credRef}, want: credSec},
+		{name: "store wins", spec: secretspizecomv1alpha1.GSMSecretSpec{AuthMode: wif}, store: &secretspizecomv1alpha1.GSMSecretStoreSpec{AuthMode: trusted}, want: trusted},
+		{name: "store without mode uses operator default", env: "EXTERNAL_ACCOUNT", store: &secretspizecomv1alpha1.GSMSecretStoreSpec{}, want: extAcct},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("MODE", tt.env)
+			gsm := &secretspizecomv1alpha1.GSMSecret{Spec: tt.spec}
+			if got := Resolve(gsm, tt.store); got != tt.want {
+				t.Errorf("Resolve() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParsePolicy(t *testing.T) {
+	tests := []struct {
+		name    string
+		raw     string
+		wantNil bool
+		wantErr string
+	}{
+		{name: "unset", raw: "", wantNil: true},
+		{name: "blank", raw: "  ", wantNil: true},
+		{name: "valid", raw: "TrustedSubsystem=platform-*, kube-system; WorkloadIdentityFederation=*;"},
+		{name: "missing equals", raw: "TrustedSubsystem", wantErr: "expected <authMode>="},
+		{name: "unknown mode", raw: "Trusted=platform-*", wantErr: `unknown auth mode "Trusted"`},
+		{name: "bad pattern", raw: "TrustedSubsystem=platform-[", wantErr: "invalid namespace pattern"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := ParsePolicy(tt.raw)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if (p == nil) != tt.wantNil {
+				t.Errorf("expected nil policy %v, got %v", tt.wantNil, p)
+			}
+		})
+	}
+}
+
+func TestPolicyAllows(t *testing.T) {
+	p, err := ParsePolicy("TrustedSubsystem=platform-*,kube-system;WorkloadIdentityFederation=*")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		mode      secretspizecomv1alpha1.GSMSecretStoreAuthMode
+		namespace string
+		want      bool
+	}{
+		{trusted, "platform-ingress", true},
+		{trusted, "kube-system", true},
+		{trusted, "team-a", false},
+		{wif, "team-a", true},
+		{wif, "platform-ingress", true},
+		{credSec, "team-a", false},
+	}
+	for _, tt := range tests {
+		if got := p.Allows(tt.mode, tt.namespace); got != tt.want {
+			t.Errorf("Allows(%s, %q) = %v, want %v", tt.mode, tt.namespace, got, tt.want)
+		}
+	}
+}
+
+func TestPolicyAllows_Unset(t *testing.T) {
+	var unset Policy
+	t.Setenv("MODE", "")
+	for _, tt := range []struct {
+		mode secretspizecomv1alpha1.GSMSecretStoreAuthMode
+		want bool
+	}{
+		{wif, true},
+		{credSec, true},
+		{trusted, false},
+		{secretspizecomv1alpha1.AuthModeExternalAccount, false},
+	} {
+		if got := unset.Allows(tt.mode, "team-a"); got != tt.want {
+			t.Errorf("Allows(%s) = %v, want %v", tt.mode, got, tt.want)
+		}
+	}
+
+	// The operator default is the admin's choice, so it stays allowed.
+	t.Setenv("MODE", "TRUSTED_SUBSYSTEM")
+	if !unset.Allows(trusted, "team-a") {
+		t.Error("expected a nil policy to allow the operator default mode")
+	}
+	if unset.Allows(secretspizecomv1alpha1.AuthModeExternalAccount, "team-a") {
+		t.Error("expected a nil policy to deny a non-default operator-identity mode")
+	}
+}
+
+func TestCheck(t *testing.T) {
+	t.Setenv("AUTH_MODE_POLICY", "")
+	t.Setenv("MODE", "")
+	if err := Check(wif, "team-a"); err != nil {
+		t.Errorf("expected the default mode to be allowed without a policy, got %v", err)
+	}
+	if err := Check(trusted, "team-a"); err == nil {
+		t.Error("expected TrustedSubsystem to be denied without a policy")
+	}
+
+	t.Setenv("AUTH_MODE_POLICY", "TrustedSubsystem=platform-*;WorkloadIdentityFederation=*")
+	err := Check(trusted, "team-a")
+	var notAllowed *NotAllowedError
+	if !errors.As(err, &notAllowed) || notAllowed.Mode != trusted || notAllowed.Namespace != "team-a" {
+		t.Fatalf("expected NotAllowedError, got %v", err)
+	}
+	if err := Check(trusted, "platform-ingress"); err != nil {
+		t.Errorf("expected platform namespace to be allowed, got %v", err)
+	}
+
+	// An invalid policy denies rather than falling back to allow-all.
+	t.Setenv("AUTH_MODE_POLICY", "WorkloadIdentityFederation")
+	if err := Check(wif, "team-a"); err == nil || !strings.Contains(err.Error(), "AUTH_MODE_POLICY") {
+		t.Errorf("expected policy parse error, got %v", err)
+	}
+}
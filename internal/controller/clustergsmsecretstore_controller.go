@@ -0,0 +1,69 @@
+/*
+Copyright 2025 Zera Holladay.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	secretspizecomv1alpha1 "github.com/zeraholladay/gsm-operator/api/v1alpha1"
+)
+
+// ClusterGSMSecretStoreReconciler validates ClusterGSMSecretStores and reports
+// the result in their Ready condition.
+type ClusterGSMSecretStoreReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+}
+
+// +kubebuilder:rbac:groups=secrets.gsm-operator.io,resources=clustergsmsecretstores,verbs=get;list;watch
+// +kubebuilder:rbac:groups=secrets.gsm-operator.io,resources=clustergsmsecretstores/status,verbs=get;update;patch
+func (r *ClusterGSMSecretStoreReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := logf.FromContext(ctx)
+
+	var store secretspizecomv1alpha1.ClusterGSMSecretStore
+	if err := r.Get(ctx, req.NamespacedName, &store); err != nil {
+		if apierrors.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+		log.Error(err, "unable to fetch ClusterGSMSecretStore")
+		return ctrl.Result{}, err
+	}
+
+	setStoreReadyCondition(&store.Status, store.Generation, validateStoreSpec(&store.Spec, false))
+	if err := r.Status().Update(ctx, &store); err != nil {
+		log.Error(err, "failed to update ClusterGSMSecretStore status")
+		return ctrl.Result{}, err
+	}
+	return ctrl.Result{}, nil
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *ClusterGSMSecretStoreReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&secretspizecomv1alpha1.ClusterGSMSecretStore{},
+			builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Named("clustergsmsecretstore").
+		Complete(r)
+}
@@ -0,0 +1,173 @@
+/*
+Copyright 2025 Zera Holladay.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	secretspizecomv1alpha1 "github.com/zeraholladay/gsm-operator/api/v1alpha1"
+)
+
+func newTestAccessPolicy(name string, projects, secrets, principals []string) *secretspizecomv1alpha1.GSMAccessPolicy {
+	return &secretspizecomv1alpha1.GSMAccessPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec: secretspizecomv1alpha1.GSMAccessPolicySpec{
+			ProjectIDs: projects,
+			SecretIDs:  secrets,
+			Principals: principals,
+		},
+	}
+}
+
+func TestReconcile_PolicyDenied(t *testing.T) {
+	t.Setenv("REQUIRE_KSA_APPROVAL", "false")
+	t.Setenv("ENFORCE_ACCESS_POLICY", "true")
+	t.Setenv("RESYNC_JITTER_PERCENT", "0")
+
+	gsm := newStoreGSMSecret("team", "app", nil)
+	gsm.Spec.Secrets[0].ProjectID = "other-project"
+	c := newStoreTestClient(
+		gsm,
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team"}},
+		newTestAccessPolicy("team", []string{"team-project"}, []string{"*"}, nil),
+	)
+	recorder := record.NewFakeRecorder(10)
+	r := &GSMSecretReconciler{Client: c, Scheme: c.Scheme(), Recorder: recorder}
+
+	// No Google credentials are configured, so reaching GCP would fail with a
+	// different error; the denial must come first.
+	result, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "team", Name: "app"}})
+	if err != nil {
+		t.Fatalf("expected denial not to be returned as an error, got %v", err)
+	}
+	if result.RequeueAfter != getResyncInterval() {
+		t.Errorf("expected requeue after resync interval, got %v", result.RequeueAfter)
+	}
+
+	var got secretspizecomv1alpha1.GSMSecret
+	if err := c.Get(context.Background(), types.NamespacedName{Namespace: "team", Name: "app"}, &got); err != nil {
+		t.Fatalf("failed to get GSMSecret: %v", err)
+	}
+	if len(got.Status.Conditions) != 3 || got.Status.Conditions[0].Reason != "PolicyDenied" {
+		t.Fatalf("expected PolicyDenied condition, got %+v", got.Status.Conditions)
+	}
+	if !strings.Contains(got.Status.Conditions[0].Message, "projects/other-project/secrets/s") {
+		t.Errorf("expected message to name the denied secret, got %q", got.Status.Conditions[0].Message)
+	}
+
+	select {
+	case e := <-recorder.Events:
+		if !strings.HasPrefix(e, "Warning PolicyDenied") {
+			t.Errorf("expected PolicyDenied warning event, got %q", e)
+		}
+	default:
+		t.Error("expected a PolicyDenied event")
+	}
+}
+
+func TestReconcile_PolicyNotEnforcedByDefault(t *testing.T) {
+	t.Setenv("REQUIRE_KSA_APPROVAL", "false")
+	t.Setenv("ENFORCE_ACCESS_POLICY", "")
+	t.Setenv("MODE", "")
+	t.Setenv("WIFAUDIENCE", "")
+
+	gsm := newStoreGSMSecret("team", "app", nil)
+	gsm.Spec.Secrets[0].ProjectID = "other-project"
+	c := newStoreTestClient(gsm, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team"}})
+	r := &GSMSecretReconciler{Client: c, Scheme: c.Scheme()}
+
+	// Without enforcement the reconcile goes on to the credential exchange,
+	// which fails here for lack of a WIF audience.
+	if _, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "team", Name: "app"}}); err == nil {
+		t.Fatal("expected credential error")
+	}
+	var got secretspizecomv1alpha1.GSMSecret
+	if err := c.Get(context.Background(), types.NamespacedName{Namespace: "team", Name: "app"}, &got); err != nil {
+		t.Fatalf("failed to get GSMSecret: %v", err)
+	}
+	if len(got.Status.Conditions) != 3 || got.Status.Conditions[0].Reason != "AuthFailed" {
+		t.Errorf("expected AuthFailed condition, got %+v", got.Status.Conditions)
+	}
+}
+
+func TestPolicyAccesses(t *testing.T) {
+	t.Setenv("MODE", "")
+	gsm := newStoreGSMSecret("team", "app", nil)
+	gsm.Annotations = map[string]string{
+		secretspizecomv1alpha1.AnnotationGSA:          readerGSA,
+		secretspizecomv1alpha1.AnnotationGSADelegates: brokerGSA,
+	}
+	gsm.Spec.Secrets = []secretspizecomv1alpha1.GSMSecretEntry{
+		{Key: "A", ProjectID: "proj-a", SecretID: "one", Version: "1"},
+		{Key: "B", SecretID: "two", Version: "1"},
+	}
+	m := &secretMaterializer{
+		gsmSecret: gsm,
+		store:     &secretspizecomv1alpha1.GSMSecretStoreSpec{DefaultProjectID: "proj-default"},
+	}
+	// The store supplies identity, so the annotations are ignored.
+	accesses, err := m.policyAccesses()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(accesses) != 2 || accesses[0].ProjectID != "proj-a" || accesses[1].ProjectID != "proj-default" {
+		t.Fatalf("unexpected accesses: %+v", accesses)
+	}
+	if len(accesses[0].Principals) != 0 {
+		t.Errorf("expected no principals from a store without impersonation, got %v", accesses[0].Principals)
+	}
+
+	m.store = nil
+	gsm.Spec.Secrets = gsm.Spec.Secrets[:1]
+	accesses, err = m.policyAccesses()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := strings.Join(accesses[0].Principals, ","); got != brokerGSA+","+readerGSA {
+		t.Errorf("expected delegates then target, got %q", got)
+	}
+
+	t.Setenv("MODE", "TRUSTED_SUBSYSTEM")
+	accesses, err = m.policyAccesses()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(accesses[0].Principals) != 0 {
+		t.Errorf("expected no principals in trusted subsystem mode, got %v", accesses[0].Principals)
+	}
+}
+
+func TestMapAccessPolicyToGSMSecrets(t *testing.T) {
+	c := newStoreTestClient(
+		newStoreGSMSecret("team-a", "one", nil),
+		newStoreGSMSecret("team-b", "two", nil),
+	)
+	r := &GSMSecretReconciler{Client: c, Scheme: c.Scheme()}
+
+	requests := r.mapAccessPolicyToGSMSecrets(context.Background(), newTestAccessPolicy("any", []string{"*"}, []string{"*"}, nil))
+	if len(requests) != 2 {
+		t.Errorf("expected every GSMSecret to be enqueued, got %v", requests)
+	}
+}
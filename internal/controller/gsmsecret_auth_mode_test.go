@@ -0,0 +1,85 @@
+/*
+Copyright 2025 Zera Holladay.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	secretspizecomv1alpha1 "github.com/zeraholladay/gsm-operator/api/v1alpha1"
+)
+
+func TestReconcile_AuthModeNotAllowed(t *testing.T) {
+	t.Setenv("MODE", "")
+	t.Setenv("AUTH_MODE_POLICY", "TrustedSubsystem=platform-*;WorkloadIdentityFederation=*")
+
+	gsm := newStoreGSMSecret("team", "app", nil)
+	gsm.Spec.AuthMode = secretspizecomv1alpha1.AuthModeTrustedSubsystem
+	c := newStoreTestClient(gsm)
+	recorder := record.NewFakeRecorder(10)
+	r := &GSMSecretReconciler{Client: c, Scheme: c.Scheme(), Recorder: recorder}
+
+	if _, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "team", Name: "app"}}); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	var got secretspizecomv1alpha1.GSMSecret
+	if err := c.Get(context.Background(), types.NamespacedName{Namespace: "team", Name: "app"}, &got); err != nil {
+		t.Fatalf("failed to get GSMSecret: %v", err)
+	}
+	if got.Status.EffectiveAuthMode != secretspizecomv1alpha1.AuthModeTrustedSubsystem {
+		t.Errorf("expected effectiveAuthMode TrustedSubsystem, got %q", got.Status.EffectiveAuthMode)
+	}
+	if len(got.Status.Conditions) != 3 || got.Status.Conditions[0].Reason != "AuthModeNotAllowed" {
+		t.Fatalf("expected AuthModeNotAllowed condition, got %+v", got.Status.Conditions)
+	}
+	select {
+	case e := <-recorder.Events:
+		if !strings.HasPrefix(e, "Warning AuthModeNotAllowed") {
+			t.Errorf("expected AuthModeNotAllowed warning event, got %q", e)
+		}
+	default:
+		t.Error("expected an AuthModeNotAllowed event")
+	}
+}
+
+func TestReconcile_ReportsEffectiveAuthMode(t *testing.T) {
+	t.Setenv("MODE", "TRUSTED_SUBSYSTEM")
+	t.Setenv("AUTH_MODE_POLICY", "")
+
+	// Fetching fails outside a cluster, but the mode is resolved before any
+	// Google call and is still reported.
+	gsm := newStoreGSMSecret("team", "app", nil)
+	gsm.Spec.AuthMode = secretspizecomv1alpha1.AuthModeWorkloadIdentityFederation
+	gsm.Spec.Secrets[0].ProjectID = "team-prod"
+	c := newStoreTestClient(gsm)
+	r := &GSMSecretReconciler{Client: c, Scheme: c.Scheme()}
+	_, _ = r.Reconcile(context.Background(), ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "team", Name: "app"}})
+
+	var got secretspizecomv1alpha1.GSMSecret
+	if err := c.Get(context.Background(), types.NamespacedName{Namespace: "team", Name: "app"}, &got); err != nil {
+		t.Fatalf("failed to get GSMSecret: %v", err)
+	}
+	if got.Status.EffectiveAuthMode != secretspizecomv1alpha1.AuthModeWorkloadIdentityFederation {
+		t.Errorf("expected spec authMode to win over MODE, got %q", got.Status.EffectiveAuthMode)
+	}
+}
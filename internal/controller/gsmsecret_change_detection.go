@@ -0,0 +1,59 @@
+/*
+Copyright 2025 Zera Holladay.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	secretspizecomv1alpha1 "github.com/zeraholladay/gsm-operator/api/v1alpha1"
+)
+
+// annotationContentHash records on a target Secret the content hash of the
+// data the operator wrote, so edits made by anyone else can be detected
+// without reading GSM payloads.
+const annotationContentHash = "secrets.gsm-operator.io/content-hash"
+
+// setContentHash records the content hash of secret's data on the Secret.
+func setContentHash(secret *corev1.Secret) {
+	metav1.SetMetaDataAnnotation(&secret.ObjectMeta, annotationContentHash, secretContentHash(secret.Type, secret.Data))
+}
+
+// syncedSecretIntact reports whether the current generation was synced and
+// the Secret it wrote still holds what the operator wrote. Only then can a
+// resync whose GSM versions did not move skip reading payloads.
+func (r *GSMSecretReconciler) syncedSecretIntact(ctx context.Context, gsmSecret *secretspizecomv1alpha1.GSMSecret) bool {
+	ready := apimeta.FindStatusCondition(gsmSecret.Status.Conditions, conditionTypeReady)
+	if ready == nil || ready.Status != metav1.ConditionTrue || ready.ObservedGeneration != gsmSecret.Generation {
+		return false
+	}
+	if gsmSecret.Status.CurrentSecretName == "" || len(gsmSecret.Status.ResolvedVersions) == 0 {
+		return false
+	}
+
+	var secret corev1.Secret
+	key := types.NamespacedName{Namespace: targetNamespace(gsmSecret), Name: gsmSecret.Status.CurrentSecretName}
+	if err := r.Get(ctx, key, &secret); err != nil {
+		return false
+	}
+	hash, ok := secret.Annotations[annotationContentHash]
+	return ok && hash == secretContentHash(secret.Type, appliedData(&secret))
+}
@@ -0,0 +1,135 @@
+/*
+Copyright 2025 Zera Holladay.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	secretspizecomv1alpha1 "github.com/zeraholladay/gsm-operator/api/v1alpha1"
+)
+
+func newSyncedGSMSecret() *secretspizecomv1alpha1.GSMSecret {
+	return &secretspizecomv1alpha1.GSMSecret{
+		ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "default", UID: types.UID("app-uid"), Generation: 2},
+		Spec: secretspizecomv1alpha1.GSMSecretSpec{
+			TargetSecret: secretspizecomv1alpha1.GSMSecretTargetSecret{Name: "app-secret"},
+			Secrets:      []secretspizecomv1alpha1.GSMSecretEntry{{Key: "K", ProjectID: "p", SecretID: "s", Version: "latest"}},
+		},
+		Status: secretspizecomv1alpha1.GSMSecretStatus{
+			ObservedGeneration: 2,
+			CurrentSecretName:  "app-secret",
+			ResolvedVersions:   []secretspizecomv1alpha1.ResolvedSecretVersion{{Secret: "projects/p/secrets/s/versions/latest", Version: "4"}},
+			Conditions: []metav1.Condition{
+				{Type: conditionTypeReady, Status: metav1.ConditionTrue, Reason: "Synced", ObservedGeneration: 2},
+			},
+		},
+	}
+}
+
+func TestApplySecret_RecordsContentHash(t *testing.T) {
+	owner := newSyncedGSMSecret()
+	r := newTestReconciler(owner)
+	ctx := context.Background()
+
+	desired := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "app-secret", Namespace: "default"},
+		Type:       corev1.SecretTypeOpaque,
+		Data:       map[string][]byte{"K": []byte("v1")},
+	}
+	if err := r.applySecret(ctx, owner, desired.DeepCopy()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	desired.Data["K"] = []byte("v2")
+	if err := r.applySecret(ctx, owner, desired.DeepCopy()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var secret corev1.Secret
+	if err := r.Get(ctx, types.NamespacedName{Namespace: "default", Name: "app-secret"}, &secret); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got, want := secret.Annotations[annotationContentHash], secretContentHash(secret.Type, secret.Data); got != want {
+		t.Errorf("expected content hash %q of the updated data, got %q", want, got)
+	}
+}
+
+func TestSyncedSecretIntact(t *testing.T) {
+	data := map[string][]byte{"K": []byte("v1")}
+	written := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "app-secret", Namespace: "default",
+			Annotations: map[string]string{annotationContentHash: secretContentHash(corev1.SecretTypeOpaque, data)},
+		},
+		Type: corev1.SecretTypeOpaque,
+		Data: data,
+	}
+
+	tests := []struct {
+		name   string
+		mutate func(*secretspizecomv1alpha1.GSMSecret, *corev1.Secret)
+		want   bool
+	}{
+		{name: "intact", want: true},
+		{
+			name: "data edited by someone else",
+			mutate: func(_ *secretspizecomv1alpha1.GSMSecret, s *corev1.Secret) {
+				s.Data = map[string][]byte{"K": []byte("edited")}
+			},
+		},
+		{
+			name:   "written before content hashes",
+			mutate: func(_ *secretspizecomv1alpha1.GSMSecret, s *corev1.Secret) { s.Annotations = nil },
+		},
+		{
+			name:   "new generation",
+			mutate: func(g *secretspizecomv1alpha1.GSMSecret, _ *corev1.Secret) { g.Generation = 3 },
+		},
+		{
+			name: "last sync failed",
+			mutate: func(g *secretspizecomv1alpha1.GSMSecret, _ *corev1.Secret) {
+				g.Status.Conditions[0].Status = metav1.ConditionFalse
+			},
+		},
+		{
+			name:   "no recorded versions",
+			mutate: func(g *secretspizecomv1alpha1.GSMSecret, _ *corev1.Secret) { g.Status.ResolvedVersions = nil },
+		},
+		{
+			name:   "Secret deleted",
+			mutate: func(g *secretspizecomv1alpha1.GSMSecret, _ *corev1.Secret) { g.Status.CurrentSecretName = "gone" },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gsmSecret := newSyncedGSMSecret()
+			secret := written.DeepCopy()
+			if tt.mutate != nil {
+				tt.mutate(gsmSecret, secret)
+			}
+			r := newTestReconciler(gsmSecret, secret)
+			if got := r.syncedSecretIntact(context.Background(), gsmSecret); got != tt.want {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
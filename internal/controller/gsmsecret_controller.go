@@ -19,24 +19,35 @@ package controller
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	secretspizecomv1alpha1 "github.com/zeraholladay/gsm-operator/api/v1alpha1"
+	"github.com/zeraholladay/gsm-operator/internal/accesspolicy"
+	"github.com/zeraholladay/gsm-operator/internal/authmode"
+	"github.com/zeraholladay/gsm-operator/internal/ksaapproval"
 )
 
 const (
@@ -45,8 +56,16 @@ const (
 	// Can be overridden via RESYNC_INTERVAL_SECONDS environment variable.
 	defaultResyncInterval = 5 * time.Minute
 
+	// defaultResyncJitterPercent is how much, at most, a requeue is delayed
+	// beyond its interval, so GSMSecrets synced together (e.g. after a
+	// restart) spread out. Can be overridden via RESYNC_JITTER_PERCENT;
+	// 0 disables jitter.
+	defaultResyncJitterPercent = 10
+
 	// Condition types for GSMSecret status.
-	conditionTypeReady = "Ready"
+	conditionTypeReady       = "Ready"
+	conditionTypeProgressing = "Progressing"
+	conditionTypeDegraded    = "Degraded"
 )
 
 // getResyncInterval returns the resync interval from RESYNC_INTERVAL_SECONDS env var,
@@ -60,47 +79,239 @@ func getResyncInterval() time.Duration {
 	return defaultResyncInterval
 }
 
+// getResyncJitterPercent returns the jitter from RESYNC_JITTER_PERCENT, or
+// the default if not set or invalid.
+func getResyncJitterPercent() int {
+	if v := os.Getenv("RESYNC_JITTER_PERCENT"); v != "" {
+		if percent, err := strconv.Atoi(v); err == nil && percent >= 0 {
+			return percent
+		}
+	}
+	return defaultResyncJitterPercent
+}
+
+// jitter returns d lengthened by a random amount of up to
+// RESYNC_JITTER_PERCENT of d.
+func jitter(d time.Duration) time.Duration {
+	percent := getResyncJitterPercent()
+	if percent == 0 {
+		return d
+	}
+	return wait.Jitter(d, float64(percent)/100)
+}
+
+// resyncAfter returns the jittered resync interval to requeue with.
+func resyncAfter() time.Duration {
+	return jitter(getResyncInterval())
+}
+
 // GSMSecretReconciler reconciles a GSMSecret object.
 type GSMSecretReconciler struct {
 	client.Client
 	Scheme *runtime.Scheme
+
+	// APIReader reads objects that are not worth caching (e.g. pods) directly
+	// from the API server. Falls back to Client when nil.
+	APIReader client.Reader
+
+	// Recorder emits Kubernetes events for the GSMSecret. Optional.
+	Recorder record.EventRecorder
+
+	// recentEvents suppresses repeated identical events; set up by
+	// SetupWithManager.
+	recentEvents *eventDeduper
 }
 
 // +kubebuilder:rbac:groups=secrets.gsm-operator.io,resources=gsmsecrets,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=secrets.gsm-operator.io,resources=gsmsecrets/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups=secrets.gsm-operator.io,resources=gsmsecrets/finalizers,verbs=update
+// +kubebuilder:rbac:groups=secrets.gsm-operator.io,resources=gsmsecretgrants,verbs=get;list;watch
+// +kubebuilder:rbac:groups=secrets.gsm-operator.io,resources=gsmsecretstores,verbs=get;list;watch
+// +kubebuilder:rbac:groups=secrets.gsm-operator.io,resources=clustergsmsecretstores,verbs=get;list;watch
+// +kubebuilder:rbac:groups=secrets.gsm-operator.io,resources=gsmaccesspolicies,verbs=get;list;watch
 // +kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch;create;update;patch;delete
-func (r *GSMSecretReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+// +kubebuilder:rbac:groups="",resources=configmaps,verbs=get
+// +kubebuilder:rbac:groups="",resources=pods,verbs=get;list
+// +kubebuilder:rbac:groups=apps,resources=deployments;statefulsets;daemonsets,verbs=get;list
+// +kubebuilder:rbac:groups=batch,resources=jobs;cronjobs,verbs=get;list
+// +kubebuilder:rbac:groups="",resources=namespaces,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+func (r *GSMSecretReconciler) Reconcile(ctx context.Context, req ctrl.Request) (_ ctrl.Result, err error) {
 	log := logf.FromContext(ctx)
 
+	// Observe the duration and outcome; setStatusCondition records the reason.
+	start := time.Now()
+	outcome := &reconcileOutcome{}
+	ctx = withReconcileOutcome(ctx, outcome)
+	defer func() { observeReconcile(outcome, time.Since(start), err) }()
+
 	// 1. FETCH: Load the GSMSecret instance.
 	var gsmSecret secretspizecomv1alpha1.GSMSecret
 	if err := r.Get(ctx, req.NamespacedName, &gsmSecret); err != nil {
 		if apierrors.IsNotFound(err) {
 			// Resource deleted; nothing to do.
 			log.V(1).Info("GSMSecret resource not found; assuming it was deleted", "name", req.Name, "namespace", req.Namespace)
+			outcome.reason = reasonDeleted
+			lastSyncs.forget(req.NamespacedName)
+			if r.recentEvents != nil {
+				r.recentEvents.forget(req.NamespacedName)
+			}
 			return ctrl.Result{}, nil
 		}
 		log.Error(err, "failed to fetch GSMSecret from API server", "name", req.Name, "namespace", req.Namespace)
 		return ctrl.Result{}, err
 	}
 
+	// Release Secrets written into other namespaces before the GSMSecret goes away.
+	if !gsmSecret.DeletionTimestamp.IsZero() {
+		outcome.reason = reasonDeleted
+		lastSyncs.forget(req.NamespacedName)
+		if err := r.finalizeGSMSecret(ctx, &gsmSecret); err != nil {
+			log.Error(err, "failed to clean up cross-namespace Secrets")
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{}, nil
+	}
+
+	// A plan is only kept while dry-run is on; the next status update drops it.
+	if !gsmSecret.Spec.DryRun {
+		gsmSecret.Status.Plan = nil
+	}
+
+	// A new spec generation is in progress until it is synced or fails.
+	if gsmSecret.Status.ObservedGeneration != gsmSecret.Generation {
+		if err := r.markProgressing(ctx, &gsmSecret, "NewGeneration",
+			fmt.Sprintf("Applying generation %d", gsmSecret.Generation)); err != nil {
+			log.Error(err, "failed to update status before applying a new generation")
+			return ctrl.Result{}, err
+		}
+	}
+
 	log.Info("starting reconciliation",
 		"name", gsmSecret.Name,
 		"namespace", gsmSecret.Namespace,
 		"specTargetSecret", gsmSecret.Spec.TargetSecret.Name,
+		"targetNamespace", targetNamespace(&gsmSecret),
 	)
 
+	// Resolve the referenced store, if any; it supplies the auth configuration.
+	store, err := r.resolveStore(ctx, &gsmSecret)
+	if err != nil {
+		log.Error(err, "referenced store is not usable")
+		r.recordEvent(&gsmSecret, corev1.EventTypeWarning, "StoreNotReady", err.Error())
+		if statusErr := r.setStatusCondition(ctx, &gsmSecret, metav1.ConditionFalse, "StoreNotReady", err.Error()); statusErr != nil {
+			log.Error(statusErr, "failed to update status after store resolution error")
+		}
+		return ctrl.Result{}, err
+	}
+
+	// The auth mode may be chosen per GSMSecret or store; AUTH_MODE_POLICY
+	// decides which namespaces may use it. A denial is not retried: spec and
+	// store changes trigger a reconcile, and the policy only changes on restart.
+	mode := authmode.Resolve(&gsmSecret, store)
+	gsmSecret.Status.EffectiveAuthMode = mode
+	if err := authmode.Check(mode, gsmSecret.Namespace); err != nil {
+		log.Info("GSMSecret auth mode not allowed", "authMode", mode, "reason", err.Error())
+		r.recordEvent(&gsmSecret, corev1.EventTypeWarning, "AuthModeNotAllowed", err.Error())
+		if statusErr := r.setStatusCondition(ctx, &gsmSecret, metav1.ConditionFalse, "AuthModeNotAllowed", err.Error()); statusErr != nil {
+			log.Error(statusErr, "failed to update status after auth mode check")
+			return ctrl.Result{}, statusErr
+		}
+		return ctrl.Result{}, nil
+	}
+
+	// Only mint tokens for a KSA the GSMSecret's author was approved to use.
+	// Only status updates, which the webhook does not see, come before this
+	// check; the operator's own object updates, such as adding the finalizer,
+	// never pass through the webhook for an unapproved KSA.
+	if ksaapproval.Required() {
+		if err := checkApprovedKSA(&gsmSecret, store); err != nil {
+			log.Info("GSMSecret KSA not approved", "reason", err.Error())
+			r.recordEvent(&gsmSecret, corev1.EventTypeWarning, "KSANotApproved", err.Error())
+			if statusErr := r.setStatusCondition(ctx, &gsmSecret, metav1.ConditionFalse, "KSANotApproved", err.Error()); statusErr != nil {
+				log.Error(statusErr, "failed to update status after KSA approval check")
+				return ctrl.Result{}, statusErr
+			}
+			// The approved-ksa annotation is a relevant annotation, so a fresh
+			// approval triggers a reconcile.
+			return ctrl.Result{}, nil
+		}
+	}
+
+	// Writing into another namespace requires a grant there, and a finalizer here
+	// because OwnerReferences cannot cross namespaces.
+	if isCrossNamespaceTarget(&gsmSecret) {
+		if err := r.checkTargetGrant(ctx, &gsmSecret); err != nil {
+			log.Error(err, "cross-namespace target Secret not permitted")
+			r.recordEvent(&gsmSecret, corev1.EventTypeWarning, "GrantDenied", err.Error())
+			if statusErr := r.setStatusCondition(ctx, &gsmSecret, metav1.ConditionFalse, "GrantDenied", err.Error()); statusErr != nil {
+				log.Error(statusErr, "failed to update status after grant check")
+			}
+			return ctrl.Result{}, err
+		}
+		// A dry run writes no Secret, so there is nothing to clean up.
+		if !gsmSecret.Spec.DryRun {
+			if err := r.ensureCleanupFinalizer(ctx, &gsmSecret); err != nil {
+				log.Error(err, "failed to add cross-namespace cleanup finalizer")
+				return ctrl.Result{}, err
+			}
+		}
+	}
+
 	// 2. MATERIALIZE: Initialize the helper with one clean call.
 	m := r.newSecretMaterializer(&gsmSecret)
+	m.store = store
+	if accesspolicy.Enabled() {
+		m.policyReader = r.Client
+	}
+	// A resync of an intact sync only reads payloads if a GSM version moved.
+	// A dry run always reads them, so the plan compares real values.
+	if !gsmSecret.Spec.DryRun && r.syncedSecretIntact(ctx, &gsmSecret) {
+		m.knownVersions = gsmSecret.Status.ResolvedVersions
+	}
 
 	// Delegate the heavy lifting to the materializer.
 	if err := m.resolvePayloads(ctx); err != nil {
-		log.Error(err, "failed to fetch GSM payloads")
-		if statusErr := r.setStatusCondition(ctx, &gsmSecret, metav1.ConditionFalse, "FetchFailed", err.Error()); statusErr != nil {
+		// A policy denial is not retried with backoff. Policy and spec changes
+		// trigger a reconcile; namespace label changes are caught on resync.
+		var denied *accesspolicy.DeniedError
+		if errors.As(err, &denied) {
+			log.Info("GSMSecret denied by access policy", "reason", err.Error())
+			r.recordEvent(&gsmSecret, corev1.EventTypeWarning, "PolicyDenied", err.Error())
+			if statusErr := r.setStatusCondition(ctx, &gsmSecret, metav1.ConditionFalse, "PolicyDenied", err.Error()); statusErr != nil {
+				log.Error(statusErr, "failed to update status after policy denial")
+				return ctrl.Result{}, statusErr
+			}
+			return ctrl.Result{RequeueAfter: resyncAfter()}, nil
+		}
+		// Classified errors pick their own retry strategy; see fetchErrorResult.
+		reason, result, retErr := fetchErrorResult(err)
+		log.Error(err, "failed to fetch GSM payloads", "reason", reason, "requeueAfter", result.RequeueAfter)
+		r.recordEvent(&gsmSecret, corev1.EventTypeWarning, reason, err.Error())
+		if statusErr := r.setStatusCondition(ctx, &gsmSecret, metav1.ConditionFalse, reason, err.Error()); statusErr != nil {
 			log.Error(statusErr, "failed to update status after fetch error")
+			return ctrl.Result{}, statusErr
+		}
+		return result, retErr
+	}
+	if m.unchanged {
+		log.Info("GSM versions unchanged; keeping the current Secret",
+			"secret", gsmSecret.Status.CurrentSecretName)
+		lastSyncs.record(req.NamespacedName, time.Now())
+		if err := r.setStatusCondition(ctx, &gsmSecret, metav1.ConditionTrue, "Synced", "Secret up to date; GSM versions unchanged"); err != nil {
+			log.Error(err, "failed to update status after version check")
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{RequeueAfter: resyncAfter()}, nil
+	}
+
+	// So is a version alias, such as "latest", that moved since the last sync.
+	newVersions := newVersionsMessage(gsmSecret.Status.ResolvedVersions, m.resolvedVersions)
+	if newVersions != "" && !gsmSecret.Spec.DryRun {
+		if err := r.markProgressing(ctx, &gsmSecret, eventReasonNewVersion, newVersions); err != nil {
+			log.Error(err, "failed to update status before applying new versions")
+			return ctrl.Result{}, err
 		}
-		return ctrl.Result{}, err
 	}
 	log.Info("fetched GSM payloads for GSMSecret",
 		"name", gsmSecret.Name,
@@ -112,22 +323,61 @@ func (r *GSMSecretReconciler) Reconcile(ctx context.Context, req ctrl.Request) (
 	desiredSecret, err := m.buildOpaqueSecret(ctx)
 	if err != nil {
 		log.Error(err, "failed to build Secret object")
+		r.recordEvent(&gsmSecret, corev1.EventTypeWarning, "BuildFailed", err.Error())
 		if statusErr := r.setStatusCondition(ctx, &gsmSecret, metav1.ConditionFalse, "BuildFailed", err.Error()); statusErr != nil {
 			log.Error(statusErr, "failed to update status after build error")
 		}
 		return ctrl.Result{}, err
 	}
 
+	// A dry run records what applying would change instead.
+	if gsmSecret.Spec.DryRun {
+		return r.reportPlan(ctx, &gsmSecret, desiredSecret)
+	}
+
 	// 3. APPLY: Ensure the cluster state matches our desired state.
 	if err := r.applySecret(ctx, &gsmSecret, desiredSecret); err != nil {
+		if apierrors.IsConflict(err) {
+			return r.applyConflictResult(ctx, &gsmSecret, err)
+		}
 		log.Error(err, "failed to apply Kubernetes Secret")
+		r.recordEvent(&gsmSecret, corev1.EventTypeWarning, "ApplyFailed", err.Error())
 		if statusErr := r.setStatusCondition(ctx, &gsmSecret, metav1.ConditionFalse, "ApplyFailed", err.Error()); statusErr != nil {
 			log.Error(statusErr, "failed to update status after apply error")
 		}
 		return ctrl.Result{}, err
 	}
 
+	// Publish the Secret name workloads should use; in immutable mode it changes with the content.
+	gsmSecret.Status.CurrentSecretName = desiredSecret.Name
+
+	// Remove what the previous target namespace still holds. The namespace is
+	// only recorded once that succeeds, so a failed cleanup is retried.
+	if err := r.cleanupPreviousNamespace(ctx, &gsmSecret); err != nil {
+		log.Error(err, "failed to clean up the previous target namespace")
+	} else {
+		gsmSecret.Status.CurrentSecretNamespace = desiredSecret.Namespace
+	}
+
+	// Report aliases such as "latest" that moved since the last sync.
+	if newVersions != "" {
+		r.recordEvent(&gsmSecret, corev1.EventTypeNormal, eventReasonNewVersion, newVersions)
+	}
+	gsmSecret.Status.ResolvedVersions = m.resolvedVersions
+
+	// Prune old immutable generations once the new one is in place.
+	if gsmSecret.Spec.TargetSecret.Immutable {
+		if err := r.pruneSecretGenerations(ctx, &gsmSecret, desiredSecret.Name); err != nil {
+			// Pruning is best effort; the current Secret is already live.
+			log.Error(err, "failed to prune old immutable Secret generations")
+		}
+	}
+
 	// 4. STATUS: Mark reconciliation as successful.
+	lastSyncs.record(req.NamespacedName, time.Now())
+	if r.recentEvents != nil {
+		r.recentEvents.recovered(&gsmSecret)
+	}
 	if err := r.setStatusCondition(ctx, &gsmSecret, metav1.ConditionTrue, "Synced", "Secret successfully synced from GSM"); err != nil {
 		log.Error(err, "failed to update status after successful reconciliation")
 		return ctrl.Result{}, err
@@ -135,7 +385,7 @@ func (r *GSMSecretReconciler) Reconcile(ctx context.Context, req ctrl.Request) (
 
 	log.Info("reconciliation complete")
 	// Requeue after interval to pick up GSM secret changes.
-	return ctrl.Result{RequeueAfter: getResyncInterval()}, nil
+	return ctrl.Result{RequeueAfter: resyncAfter()}, nil
 }
 
 // newSecretMaterializer acts as a factory/constructor.
@@ -148,17 +398,44 @@ func (r *GSMSecretReconciler) newSecretMaterializer(gsm *secretspizecomv1alpha1.
 	}
 }
 
-// applySecret handles the generic K8s "Create or Update" logic.
-// This removes the boilerplate from Reconcile, making the flow linear and readable.
+// recordEvent emits an event on the GSMSecret when a recorder is configured,
+// unless it repeats the last event emitted for the GSMSecret.
+func (r *GSMSecretReconciler) recordEvent(gsmSecret *secretspizecomv1alpha1.GSMSecret, eventType, reason, message string) {
+	if r.Recorder == nil {
+		return
+	}
+	if r.recentEvents != nil && r.recentEvents.duplicate(gsmSecret, eventType, reason, message) {
+		return
+	}
+	r.Recorder.Event(gsmSecret, eventType, reason, message)
+}
+
+// prepareSecret sets the owner and content hash of desired. An OwnerReference
+// makes deleting the GSMSecret delete the generated Secret; Secrets in other
+// namespaces are tracked by label and cleaned up by the finalizer instead.
+func (r *GSMSecretReconciler) prepareSecret(owner *secretspizecomv1alpha1.GSMSecret, desired *corev1.Secret) error {
+	if desired.Namespace != owner.Namespace {
+		setCrossNamespaceOwner(owner, desired)
+	} else if err := ctrl.SetControllerReference(owner, desired, r.Scheme); err != nil {
+		return fmt.Errorf("failed to set controller reference: %w", err)
+	}
+	setContentHash(desired)
+	return nil
+}
+
+// applySecret server-side applies desired with the operator's field manager,
+// so fields other writers own are left alone. A field another manager set to
+// a different value is a conflict unless targetSecret.forceConflicts is set.
 func (r *GSMSecretReconciler) applySecret(ctx context.Context, owner *secretspizecomv1alpha1.GSMSecret, desired *corev1.Secret) error {
 	log := logf.FromContext(ctx)
 
-	// 1. Set OwnerReference so deleting the GSMSecret deletes the generated Secret.
-	if err := ctrl.SetControllerReference(owner, desired, r.Scheme); err != nil {
-		return fmt.Errorf("failed to set controller reference: %w", err)
+	// 1. Record the owner and content hash on the Secret.
+	if err := r.prepareSecret(owner, desired); err != nil {
+		return err
 	}
+	crossNamespace := desired.Namespace != owner.Namespace
 
-	// 2. Check if the secret already exists.
+	// 2. Read the current Secret to report what the apply changes.
 	var existing corev1.Secret
 	key := types.NamespacedName{
 		Name:      desired.Name,
@@ -169,67 +446,138 @@ func (r *GSMSecretReconciler) applySecret(ctx context.Context, owner *secretspiz
 	if err != nil && !apierrors.IsNotFound(err) {
 		return err // Actual API error.
 	}
-
-	// 3. Create if not found.
-	if apierrors.IsNotFound(err) {
-		log.Info("creating new Kubernetes Secret", "secret", key)
-		return r.Create(ctx, desired)
+	found := err == nil
+
+	var adopted bool
+	if found {
+		if crossNamespace {
+			adopted = existing.Labels[labelOwnerUID] != string(owner.UID)
+		} else {
+			adopted = !metav1.IsControlledBy(&existing, owner)
+			// Refuse Secrets another controller owns, as before server-side apply.
+			if err := ctrl.SetControllerReference(owner, existing.DeepCopy(), r.Scheme); err != nil {
+				return fmt.Errorf("failed to set controller reference on existing secret: %w", err)
+			}
+		}
+		if err := r.upgradeLegacyFieldManager(ctx, &existing); err != nil {
+			return err
+		}
 	}
 
-	// 4. Update if found.
-	// Set controller reference on the existing secret to ensure ownership is
-	// established even for pre-existing secrets (handles adoption scenario).
-	if err := ctrl.SetControllerReference(owner, &existing, r.Scheme); err != nil {
-		return fmt.Errorf("failed to set controller reference on existing secret: %w", err)
+	// 3. Skip the write when the Secret already holds what would be applied,
+	// so unchanged resyncs cause no API write, audit entry or watch event.
+	if found && secretUpToDate(&existing, desired) {
+		log.Info("Kubernetes Secret up to date; skipping apply", "secret", key)
+		secretWrites.WithLabelValues(secretWriteSkipped).Inc()
+		return nil
 	}
 
-	existing.Data = desired.Data
-	existing.Type = desired.Type
+	// 4. Apply the fields the operator owns.
+	opts := []client.ApplyOption{client.FieldOwner(fieldManager)}
+	if owner.Spec.TargetSecret.ForceConflicts {
+		opts = append(opts, client.ForceOwnership)
+	}
+	applied := secretApplyConfiguration(desired)
+	log.Info("applying Kubernetes Secret", "secret", key, "forceConflicts", owner.Spec.TargetSecret.ForceConflicts)
+	if err := r.Apply(ctx, applied, opts...); err != nil {
+		return err
+	}
+	secretWrites.WithLabelValues(secretWriteWritten).Inc()
 
-	log.Info("updating existing Kubernetes Secret", "secret", key)
-	return r.Update(ctx, &existing)
+	if !found {
+		r.recordEvent(owner, corev1.EventTypeNormal, eventReasonSecretCreated, fmt.Sprintf("Created Secret %s", key))
+		return nil
+	}
+	if adopted {
+		r.recordEvent(owner, corev1.EventTypeNormal, eventReasonSecretAdopted, fmt.Sprintf("Adopted pre-existing Secret %s", key))
+	}
+	if changed := changedKeys(existing.Data, applied.Data); len(changed) > 0 {
+		r.recordEvent(owner, corev1.EventTypeNormal, eventReasonSecretUpdated,
+			fmt.Sprintf("Updated Secret %s; changed keys: %s", key, strings.Join(changed, ", ")))
+	}
+	return nil
 }
 
-// setStatusCondition updates the GSMSecret's status with a Ready condition.
+// setStatusCondition records the outcome of a reconcile. Ready carries status,
+// reason and message; Progressing ends; and Degraded is True when a sync
+// failed while a Secret from an earlier sync is still in place.
 func (r *GSMSecretReconciler) setStatusCondition(
 	ctx context.Context,
 	gsmSecret *secretspizecomv1alpha1.GSMSecret,
 	status metav1.ConditionStatus,
 	reason, message string,
 ) error {
-	// Update observed generation to indicate we've processed this spec version.
-	gsmSecret.Status.ObservedGeneration = gsmSecret.Generation
+	recordReconcileReason(ctx, reason)
 
-	// Build the new condition.
-	newCondition := metav1.Condition{
-		Type:               conditionTypeReady,
-		Status:             status,
-		ObservedGeneration: gsmSecret.Generation,
-		LastTransitionTime: metav1.Now(),
-		Reason:             reason,
-		Message:            message,
-	}
-
-	// Find and update existing condition or append new one.
-	found := false
-	for i, c := range gsmSecret.Status.Conditions {
-		if c.Type == conditionTypeReady {
-			// Only update LastTransitionTime if status actually changed.
-			if c.Status == status {
-				newCondition.LastTransitionTime = c.LastTransitionTime
-			}
-			gsmSecret.Status.Conditions[i] = newCondition
-			found = true
-			break
+	degraded := metav1.Condition{
+		Type:    conditionTypeDegraded,
+		Status:  metav1.ConditionFalse,
+		Reason:  reason,
+		Message: message,
+	}
+	if status != metav1.ConditionTrue {
+		if name, ok := r.lastGoodSecretInPlace(ctx, gsmSecret); ok {
+			degraded.Status = metav1.ConditionTrue
+			degraded.Message = fmt.Sprintf("Secret %s from an earlier sync is still in place: %s", name, message)
+		} else {
+			degraded.Reason = "NoPreviousSecret"
+			degraded.Message = "No Secret from an earlier sync is in place"
 		}
 	}
-	if !found {
-		gsmSecret.Status.Conditions = append(gsmSecret.Status.Conditions, newCondition)
+
+	return r.updateConditions(ctx, gsmSecret,
+		metav1.Condition{Type: conditionTypeReady, Status: status, Reason: reason, Message: message},
+		metav1.Condition{Type: conditionTypeProgressing, Status: metav1.ConditionFalse, Reason: reason, Message: message},
+		degraded,
+	)
+}
+
+// markProgressing reports that a new spec generation or GSM version is being
+// applied: Progressing is True and Ready is False until the sync finishes.
+// Degraded is left as it was.
+func (r *GSMSecretReconciler) markProgressing(
+	ctx context.Context,
+	gsmSecret *secretspizecomv1alpha1.GSMSecret,
+	reason, message string,
+) error {
+	return r.updateConditions(ctx, gsmSecret,
+		metav1.Condition{Type: conditionTypeReady, Status: metav1.ConditionFalse, Reason: reason, Message: message},
+		metav1.Condition{Type: conditionTypeProgressing, Status: metav1.ConditionTrue, Reason: reason, Message: message},
+	)
+}
+
+// updateConditions sets conditions, in order, for the current generation and
+// writes the status. LastTransitionTime only moves when a status changes.
+func (r *GSMSecretReconciler) updateConditions(
+	ctx context.Context,
+	gsmSecret *secretspizecomv1alpha1.GSMSecret,
+	conditions ...metav1.Condition,
+) error {
+	// Update observed generation to indicate we've processed this spec version.
+	gsmSecret.Status.ObservedGeneration = gsmSecret.Generation
+
+	for _, c := range conditions {
+		c.ObservedGeneration = gsmSecret.Generation
+		apimeta.SetStatusCondition(&gsmSecret.Status.Conditions, c)
 	}
 
 	return r.Status().Update(ctx, gsmSecret)
 }
 
+// lastGoodSecretInPlace returns the name of the Secret written by the last
+// successful sync, if it still exists.
+func (r *GSMSecretReconciler) lastGoodSecretInPlace(ctx context.Context, gsmSecret *secretspizecomv1alpha1.GSMSecret) (string, bool) {
+	name := gsmSecret.Status.CurrentSecretName
+	if name == "" {
+		return "", false
+	}
+	key := types.NamespacedName{Namespace: targetNamespace(gsmSecret), Name: name}
+	if err := r.Get(ctx, key, &corev1.Secret{}); err != nil {
+		return "", false
+	}
+	return key.String(), true
+}
+
 // gsmSecretChangedPredicate triggers reconciliation when the GSMSecret's spec or
 // relevant annotations change. This ignores status-only updates (which don't increment
 // generation) while still reacting to annotation changes that affect behavior.
@@ -244,6 +592,9 @@ var relevantAnnotations = []string{
 	secretspizecomv1alpha1.AnnotationGSA,
 	secretspizecomv1alpha1.AnnotationWIFAudience,
 	secretspizecomv1alpha1.AnnotationRelease,
+	secretspizecomv1alpha1.AnnotationGSADelegates,
+	secretspizecomv1alpha1.AnnotationImpersonationLifetime,
+	secretspizecomv1alpha1.AnnotationApprovedKSA,
 }
 
 // Update returns true if the GSMSecret's generation or relevant annotations have changed.
@@ -312,7 +663,17 @@ func secretDataEqual(a, b map[string][]byte) bool {
 
 // SetupWithManager sets up the controller with the Manager.
 func (r *GSMSecretReconciler) SetupWithManager(mgr ctrl.Manager) error {
-	return ctrl.NewControllerManagedBy(mgr).
+	if err := setupCredentialsSecretIndexes(context.Background(), mgr); err != nil {
+		return err
+	}
+	if err := metrics.Registry.Register(newGSMSecretCollector(mgr.GetClient())); err != nil {
+		return fmt.Errorf("register GSMSecret metrics collector: %w", err)
+	}
+	if r.recentEvents == nil {
+		r.recentEvents = newEventDeduper(eventDedupWindow)
+	}
+
+	b := ctrl.NewControllerManagedBy(mgr).
 		// Watch GSMSecret with custom predicate to ignore status-only updates.
 		// Reconcile when: spec changes (generation bump) OR annotations change.
 		// Skip when: only status changes (e.g., after we update conditions).
@@ -323,6 +684,49 @@ func (r *GSMSecretReconciler) SetupWithManager(mgr ctrl.Manager) error {
 		// update event) but the data hasn't meaningfully changed.
 		Owns(&corev1.Secret{},
 			builder.WithPredicates(secretDataChangedPredicate{})).
-		Named("gsmsecret").
-		Complete(r)
+		// Secrets written into other namespaces carry no OwnerReference, so map
+		// them back to their GSMSecret through the owner annotations and label.
+		Watches(&corev1.Secret{},
+			handler.EnqueueRequestsFromMapFunc(r.mapCrossNamespaceSecret),
+			builder.WithPredicates(secretDataChangedPredicate{})).
+		// Re-reconcile GSMSecrets whose credentials Secret was rotated.
+		Watches(&corev1.Secret{},
+			handler.EnqueueRequestsFromMapFunc(r.mapCredentialsSecretToGSMSecrets),
+			builder.WithPredicates(secretDataChangedPredicate{})).
+		// Re-reconcile GSMSecrets when the store they reference changes.
+		Watches(&secretspizecomv1alpha1.GSMSecretStore{},
+			handler.EnqueueRequestsFromMapFunc(r.mapStoreToGSMSecrets(secretspizecomv1alpha1.GSMSecretStoreKind)),
+			builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Watches(&secretspizecomv1alpha1.ClusterGSMSecretStore{},
+			handler.EnqueueRequestsFromMapFunc(r.mapStoreToGSMSecrets(secretspizecomv1alpha1.ClusterGSMSecretStoreKind)),
+			builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		// A new grant lets a waiting cross-namespace GSMSecret sync without
+		// waiting for its next resync.
+		Watches(&secretspizecomv1alpha1.GSMSecretGrant{},
+			handler.EnqueueRequestsFromMapFunc(r.mapGrantToGSMSecrets),
+			builder.WithPredicates(predicate.GenerationChangedPredicate{}))
+	// Policy changes may allow previously denied GSMSecrets, or deny synced ones.
+	if accesspolicy.Enabled() {
+		b = b.Watches(&secretspizecomv1alpha1.GSMAccessPolicy{},
+			handler.EnqueueRequestsFromMapFunc(r.mapAccessPolicyToGSMSecrets),
+			builder.WithPredicates(predicate.GenerationChangedPredicate{}))
+	}
+	return b.Named("gsmsecret").Complete(r)
+}
+
+// mapAccessPolicyToGSMSecrets enqueues every GSMSecret, since a policy change
+// can affect any namespace its selector matches now or matched before.
+func (r *GSMSecretReconciler) mapAccessPolicyToGSMSecrets(ctx context.Context, obj client.Object) []reconcile.Request {
+	var list secretspizecomv1alpha1.GSMSecretList
+	if err := r.List(ctx, &list); err != nil {
+		logf.FromContext(ctx).Error(err, "failed to list GSMSecrets for access policy", "policy", obj.GetName())
+		return nil
+	}
+	requests := make([]reconcile.Request, 0, len(list.Items))
+	for _, gsm := range list.Items {
+		requests = append(requests, reconcile.Request{
+			NamespacedName: types.NamespacedName{Namespace: gsm.Namespace, Name: gsm.Name},
+		})
+	}
+	return requests
 }
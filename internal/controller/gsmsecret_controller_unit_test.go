@@ -18,10 +18,12 @@ package controller
 
 import (
 	"context"
+	"strings"
 	"testing"
 	"time"
 
 	corev1 "k8s.io/api/core/v1"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
@@ -46,6 +48,7 @@ func newTestReconciler(objs ...client.Object) *GSMSecretReconciler {
 		WithScheme(scheme).
 		WithObjects(objs...).
 		WithStatusSubresource(&secretspizecomv1alpha1.GSMSecret{}).
+		WithReturnManagedFields().
 		Build()
 	return &GSMSecretReconciler{
 		Client: fakeClient,
@@ -122,10 +125,12 @@ func TestApplySecret_UpdateExisting(t *testing.T) {
 		},
 	}
 
+	// Written by an earlier version of the operator, before server-side apply.
 	existingSecret := &corev1.Secret{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      "my-secret",
-			Namespace: "default",
+			Name:          "my-secret",
+			Namespace:     "default",
+			ManagedFields: legacyManagedFields("OLD_KEY"),
 		},
 		Type: corev1.SecretTypeOpaque,
 		Data: map[string][]byte{
@@ -175,7 +180,8 @@ func TestApplySecret_AdoptsExistingSecret(t *testing.T) {
 			UID:       types.UID("test-uid-123"),
 		},
 		Spec: secretspizecomv1alpha1.GSMSecretSpec{
-			TargetSecret: secretspizecomv1alpha1.GSMSecretTargetSecret{Name: "my-secret"},
+			// Another manager wrote KEY, so taking it over needs forceConflicts.
+			TargetSecret: secretspizecomv1alpha1.GSMSecretTargetSecret{Name: "my-secret", ForceConflicts: true},
 			Secrets:      []secretspizecomv1alpha1.GSMSecretEntry{{Key: "K", ProjectID: "p", SecretID: "s", Version: "1"}},
 		},
 	}
@@ -234,7 +240,8 @@ func TestApplySecret_PreservesExistingLabelsAndAnnotations(t *testing.T) {
 			UID:       types.UID("test-uid-123"),
 		},
 		Spec: secretspizecomv1alpha1.GSMSecretSpec{
-			TargetSecret: secretspizecomv1alpha1.GSMSecretTargetSecret{Name: "my-secret"},
+			// Another manager wrote KEY, so taking it over needs forceConflicts.
+			TargetSecret: secretspizecomv1alpha1.GSMSecretTargetSecret{Name: "my-secret", ForceConflicts: true},
 			Secrets:      []secretspizecomv1alpha1.GSMSecretEntry{{Key: "K", ProjectID: "p", SecretID: "s", Version: "1"}},
 		},
 	}
@@ -323,8 +330,8 @@ func TestSetStatusCondition_NewCondition(t *testing.T) {
 		t.Fatalf("expected resource to exist, got %v", err)
 	}
 
-	if len(updated.Status.Conditions) != 1 {
-		t.Fatalf("expected 1 condition, got %d", len(updated.Status.Conditions))
+	if len(updated.Status.Conditions) != 3 {
+		t.Fatalf("expected 3 conditions, got %d", len(updated.Status.Conditions))
 	}
 
 	cond := updated.Status.Conditions[0]
@@ -520,8 +527,8 @@ func TestSetStatusCondition_MultipleConditionReasons(t *testing.T) {
 				t.Fatalf("expected resource to exist, got %v", err)
 			}
 
-			if len(updated.Status.Conditions) != 1 {
-				t.Fatalf("expected 1 condition, got %d", len(updated.Status.Conditions))
+			if len(updated.Status.Conditions) != 3 {
+				t.Fatalf("expected 3 conditions, got %d", len(updated.Status.Conditions))
 			}
 
 			cond := updated.Status.Conditions[0]
@@ -538,6 +545,96 @@ func TestSetStatusCondition_MultipleConditionReasons(t *testing.T) {
 	}
 }
 
+func TestSetStatusCondition_DegradedWhilePreviousSecretInPlace(t *testing.T) {
+	gsmSecret := &secretspizecomv1alpha1.GSMSecret{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-gsmsecret", Namespace: "default", Generation: 2},
+		Spec: secretspizecomv1alpha1.GSMSecretSpec{
+			TargetSecret: secretspizecomv1alpha1.GSMSecretTargetSecret{Name: "my-secret"},
+		},
+		Status: secretspizecomv1alpha1.GSMSecretStatus{CurrentSecretName: "my-secret"},
+	}
+	previous := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "my-secret", Namespace: "default"}}
+	r := newTestReconciler(gsmSecret, previous)
+	ctx := context.Background()
+
+	if err := r.setStatusCondition(ctx, gsmSecret, metav1.ConditionFalse, "Unavailable", "Secret Manager is unavailable"); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	conds := gsmSecret.Status.Conditions
+	if c := apimeta.FindStatusCondition(conds, conditionTypeReady); c == nil || c.Status != metav1.ConditionFalse || c.Reason != "Unavailable" {
+		t.Errorf("expected Ready=False/Unavailable, got %+v", c)
+	}
+	if c := apimeta.FindStatusCondition(conds, conditionTypeProgressing); c == nil || c.Status != metav1.ConditionFalse {
+		t.Errorf("expected Progressing=False, got %+v", c)
+	}
+	c := apimeta.FindStatusCondition(conds, conditionTypeDegraded)
+	if c == nil || c.Status != metav1.ConditionTrue || c.Reason != "Unavailable" || !strings.Contains(c.Message, "default/my-secret") {
+		t.Errorf("expected Degraded=True naming the previous Secret, got %+v", c)
+	}
+
+	// Once the previous Secret is gone the failure is no longer a degradation.
+	if err := r.Delete(ctx, previous); err != nil {
+		t.Fatalf("failed to delete Secret: %v", err)
+	}
+	if err := r.setStatusCondition(ctx, gsmSecret, metav1.ConditionFalse, "Unavailable", "Secret Manager is unavailable"); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if c := apimeta.FindStatusCondition(gsmSecret.Status.Conditions, conditionTypeDegraded); c == nil || c.Status != metav1.ConditionFalse || c.Reason != "NoPreviousSecret" {
+		t.Errorf("expected Degraded=False/NoPreviousSecret, got %+v", c)
+	}
+}
+
+func TestMarkProgressing(t *testing.T) {
+	gsmSecret := &secretspizecomv1alpha1.GSMSecret{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-gsmsecret", Namespace: "default", Generation: 3},
+		Status: secretspizecomv1alpha1.GSMSecretStatus{
+			ObservedGeneration: 2,
+			Conditions: []metav1.Condition{
+				{Type: conditionTypeReady, Status: metav1.ConditionTrue, Reason: "Synced", LastTransitionTime: metav1.Now()},
+				{Type: conditionTypeProgressing, Status: metav1.ConditionFalse, Reason: "Synced", LastTransitionTime: metav1.Now()},
+				{Type: conditionTypeDegraded, Status: metav1.ConditionFalse, Reason: "Synced", LastTransitionTime: metav1.Now()},
+			},
+		},
+	}
+	r := newTestReconciler(gsmSecret)
+	ctx := context.Background()
+
+	if err := r.markProgressing(ctx, gsmSecret, "NewGeneration", "Applying generation 3"); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	conds := gsmSecret.Status.Conditions
+	if c := apimeta.FindStatusCondition(conds, conditionTypeReady); c == nil || c.Status != metav1.ConditionFalse || c.Reason != "NewGeneration" {
+		t.Errorf("expected Ready=False/NewGeneration, got %+v", c)
+	}
+	if c := apimeta.FindStatusCondition(conds, conditionTypeProgressing); c == nil || c.Status != metav1.ConditionTrue || c.ObservedGeneration != 3 {
+		t.Errorf("expected Progressing=True for generation 3, got %+v", c)
+	}
+	if c := apimeta.FindStatusCondition(conds, conditionTypeDegraded); c == nil || c.Reason != "Synced" {
+		t.Errorf("expected Degraded to be left alone, got %+v", c)
+	}
+	if gsmSecret.Status.ObservedGeneration != 3 {
+		t.Errorf("expected ObservedGeneration 3, got %d", gsmSecret.Status.ObservedGeneration)
+	}
+
+	// A successful sync ends the progression.
+	if err := r.setStatusCondition(ctx, gsmSecret, metav1.ConditionTrue, "Synced", "Secret successfully synced from GSM"); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	for _, want := range []struct {
+		condType string
+		status   metav1.ConditionStatus
+	}{
+		{conditionTypeReady, metav1.ConditionTrue},
+		{conditionTypeProgressing, metav1.ConditionFalse},
+		{conditionTypeDegraded, metav1.ConditionFalse},
+	} {
+		if c := apimeta.FindStatusCondition(gsmSecret.Status.Conditions, want.condType); c == nil || c.Status != want.status {
+			t.Errorf("expected %s=%s, got %+v", want.condType, want.status, c)
+		}
+	}
+}
+
 // ==================== Predicate tests ====================
 
 func TestSecretDataEqual(t *testing.T) {
@@ -1102,3 +1199,38 @@ func TestGetResyncInterval_NegativeValue(t *testing.T) {
 		t.Errorf("expected default 5 minutes for negative value, got %v", interval)
 	}
 }
+
+func TestResyncAfter_AddsJitter(t *testing.T) {
+	t.Setenv("RESYNC_INTERVAL_SECONDS", "100")
+	t.Setenv("RESYNC_JITTER_PERCENT", "20")
+
+	seen := map[time.Duration]bool{}
+	for range 50 {
+		d := resyncAfter()
+		if d < 100*time.Second || d > 120*time.Second {
+			t.Fatalf("expected requeue within 20%% above 100s, got %v", d)
+		}
+		seen[d] = true
+	}
+	if len(seen) < 2 {
+		t.Error("expected requeue times to be spread out")
+	}
+}
+
+func TestResyncAfter_JitterDisabled(t *testing.T) {
+	t.Setenv("RESYNC_INTERVAL_SECONDS", "100")
+	t.Setenv("RESYNC_JITTER_PERCENT", "0")
+
+	if d := resyncAfter(); d != 100*time.Second {
+		t.Errorf("expected exactly 100s without jitter, got %v", d)
+	}
+}
+
+func TestGetResyncJitterPercent(t *testing.T) {
+	for value, want := range map[string]int{"": 10, "25": 25, "0": 0, "-5": 10, "x": 10} {
+		t.Setenv("RESYNC_JITTER_PERCENT", value)
+		if got := getResyncJitterPercent(); got != want {
+			t.Errorf("RESYNC_JITTER_PERCENT=%q: expected %d, got %d", value, want, got)
+		}
+	}
+}
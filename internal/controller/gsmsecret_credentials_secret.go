@@ -0,0 +1,134 @@
+/*
+Copyright 2025 Zera Holladay.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	secretspizecomv1alpha1 "github.com/zeraholladay/gsm-operator/api/v1alpha1"
+)
+
+// credentialsSecretIndexField indexes GSMSecrets and stores by the name of
+// the credentials Secret they reference.
+const credentialsSecretIndexField = ".spec.credentialsSecretRef.name"
+
+func indexGSMSecretCredentialsSecret(obj client.Object) []string {
+	gsm, ok := obj.(*secretspizecomv1alpha1.GSMSecret)
+	if !ok || gsm.Spec.CredentialsSecretRef == nil {
+		return nil
+	}
+	return []string{gsm.Spec.CredentialsSecretRef.Name}
+}
+
+func indexStoreCredentialsSecret(obj client.Object) []string {
+	var spec *secretspizecomv1alpha1.GSMSecretStoreSpec
+	switch store := obj.(type) {
+	case *secretspizecomv1alpha1.GSMSecretStore:
+		spec = &store.Spec
+	case *secretspizecomv1alpha1.ClusterGSMSecretStore:
+		spec = &store.Spec
+	default:
+		return nil
+	}
+	if spec.CredentialsSecretRef == nil {
+		return nil
+	}
+	return []string{spec.CredentialsSecretRef.Name}
+}
+
+// setupCredentialsSecretIndexes registers the field indexes used to map a
+// credentials Secret back to the GSMSecrets that use it.
+func setupCredentialsSecretIndexes(ctx context.Context, mgr ctrl.Manager) error {
+	indexer := mgr.GetFieldIndexer()
+	if err := indexer.IndexField(ctx, &secretspizecomv1alpha1.GSMSecret{},
+		credentialsSecretIndexField, indexGSMSecretCredentialsSecret); err != nil {
+		return err
+	}
+	if err := indexer.IndexField(ctx, &secretspizecomv1alpha1.GSMSecretStore{},
+		credentialsSecretIndexField, indexStoreCredentialsSecret); err != nil {
+		return err
+	}
+	return indexer.IndexField(ctx, &secretspizecomv1alpha1.ClusterGSMSecretStore{},
+		credentialsSecretIndexField, indexStoreCredentialsSecret)
+}
+
+// mapCredentialsSecretToGSMSecrets enqueues the GSMSecrets in the Secret's
+// namespace that read credentials from it, directly or through a store, so a
+// rotated key is picked up without waiting for the resync.
+func (r *GSMSecretReconciler) mapCredentialsSecretToGSMSecrets(ctx context.Context, obj client.Object) []reconcile.Request {
+	log := logf.FromContext(ctx).WithValues("secret", obj.GetName(), "namespace", obj.GetNamespace())
+	byName := client.MatchingFields{credentialsSecretIndexField: obj.GetName()}
+
+	var direct secretspizecomv1alpha1.GSMSecretList
+	if err := r.List(ctx, &direct, client.InNamespace(obj.GetNamespace()), byName); err != nil {
+		log.Error(err, "failed to list GSMSecrets for credentials Secret")
+		return nil
+	}
+	var requests []reconcile.Request
+	for _, gsm := range direct.Items {
+		requests = append(requests, reconcile.Request{
+			NamespacedName: types.NamespacedName{Namespace: gsm.Namespace, Name: gsm.Name},
+		})
+	}
+
+	// Stores resolve their credentialsSecretRef in each GSMSecret's namespace.
+	stores := map[string]map[string]bool{
+		secretspizecomv1alpha1.GSMSecretStoreKind:        {},
+		secretspizecomv1alpha1.ClusterGSMSecretStoreKind: {},
+	}
+	var nsStores secretspizecomv1alpha1.GSMSecretStoreList
+	if err := r.List(ctx, &nsStores, client.InNamespace(obj.GetNamespace()), byName); err != nil {
+		log.Error(err, "failed to list GSMSecretStores for credentials Secret")
+		return requests
+	}
+	for _, s := range nsStores.Items {
+		stores[secretspizecomv1alpha1.GSMSecretStoreKind][s.Name] = true
+	}
+	var clusterStores secretspizecomv1alpha1.ClusterGSMSecretStoreList
+	if err := r.List(ctx, &clusterStores, byName); err != nil {
+		log.Error(err, "failed to list ClusterGSMSecretStores for credentials Secret")
+		return requests
+	}
+	for _, s := range clusterStores.Items {
+		stores[secretspizecomv1alpha1.ClusterGSMSecretStoreKind][s.Name] = true
+	}
+	if len(nsStores.Items) == 0 && len(clusterStores.Items) == 0 {
+		return requests
+	}
+
+	var viaStore secretspizecomv1alpha1.GSMSecretList
+	if err := r.List(ctx, &viaStore, client.InNamespace(obj.GetNamespace())); err != nil {
+		log.Error(err, "failed to list GSMSecrets for credentials Secret")
+		return requests
+	}
+	for _, gsm := range viaStore.Items {
+		ref := gsm.Spec.StoreRef
+		if ref == nil || !stores[storeRefKind(ref)][ref.Name] {
+			continue
+		}
+		requests = append(requests, reconcile.Request{
+			NamespacedName: types.NamespacedName{Namespace: gsm.Namespace, Name: gsm.Name},
+		})
+	}
+	return requests
+}
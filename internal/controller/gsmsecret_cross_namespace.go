@@ -0,0 +1,249 @@
+/*
+Copyright 2025 Zera Holladay.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	secretspizecomv1alpha1 "github.com/zeraholladay/gsm-operator/api/v1alpha1"
+)
+
+const (
+	// crossNamespaceFinalizer is added to GSMSecrets whose target Secret lives in
+	// another namespace. OwnerReferences cannot cross namespaces, so the
+	// finalizer is what removes those Secrets when the GSMSecret is deleted.
+	crossNamespaceFinalizer = "secrets.gsm-operator.io/cross-namespace-cleanup"
+
+	// labelOwnerUID marks Secrets written into another namespace with the UID of
+	// the GSMSecret that owns them so they can be found during cleanup.
+	labelOwnerUID = "secrets.gsm-operator.io/owner-uid"
+
+	// annotationOwnerNamespace and annotationOwnerName record the owning
+	// GSMSecret on cross-namespace Secrets so their events can be mapped back.
+	annotationOwnerNamespace = "secrets.gsm-operator.io/owner-namespace"
+	annotationOwnerName      = "secrets.gsm-operator.io/owner-name"
+)
+
+// targetNamespace returns the namespace the target Secret should be written to.
+func targetNamespace(gsmSecret *secretspizecomv1alpha1.GSMSecret) string {
+	if ns := gsmSecret.Spec.TargetSecret.Namespace; ns != "" {
+		return ns
+	}
+	return gsmSecret.Namespace
+}
+
+// isCrossNamespaceTarget returns true if the target Secret lives outside the
+// GSMSecret's own namespace.
+func isCrossNamespaceTarget(gsmSecret *secretspizecomv1alpha1.GSMSecret) bool {
+	return targetNamespace(gsmSecret) != gsmSecret.Namespace
+}
+
+// checkTargetGrant verifies that a GSMSecretGrant in the destination namespace
+// allows the GSMSecret's namespace to write the requested Secret.
+func (r *GSMSecretReconciler) checkTargetGrant(ctx context.Context, gsmSecret *secretspizecomv1alpha1.GSMSecret) error {
+	dest := targetNamespace(gsmSecret)
+
+	var grants secretspizecomv1alpha1.GSMSecretGrantList
+	if err := r.List(ctx, &grants, client.InNamespace(dest)); err != nil {
+		return fmt.Errorf("list GSMSecretGrants in namespace %q: %w", dest, err)
+	}
+
+	for i := range grants.Items {
+		if grantAllows(&grants.Items[i], gsmSecret.Namespace, gsmSecret.Spec.TargetSecret.Name) {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("no GSMSecretGrant in namespace %q allows namespace %q to write Secret %q",
+		dest, gsmSecret.Namespace, gsmSecret.Spec.TargetSecret.Name)
+}
+
+// grantAllows reports whether the grant trusts fromNamespace to write secretName.
+func grantAllows(grant *secretspizecomv1alpha1.GSMSecretGrant, fromNamespace, secretName string) bool {
+	fromAllowed := false
+	for _, from := range grant.Spec.From {
+		if from.Namespace == fromNamespace {
+			fromAllowed = true
+			break
+		}
+	}
+	if !fromAllowed {
+		return false
+	}
+
+	// An empty To list covers every Secret in the namespace.
+	if len(grant.Spec.To) == 0 {
+		return true
+	}
+	for _, to := range grant.Spec.To {
+		if to.Name == secretName {
+			return true
+		}
+	}
+	return false
+}
+
+// ensureCleanupFinalizer adds the cross-namespace cleanup finalizer if missing.
+// Update replaces gsmSecret with the stored object, whose status lacks what
+// this reconcile has set so far, such as the effective auth mode, so the
+// in-memory status is put back for the status update that follows.
+func (r *GSMSecretReconciler) ensureCleanupFinalizer(ctx context.Context, gsmSecret *secretspizecomv1alpha1.GSMSecret) error {
+	if controllerutil.ContainsFinalizer(gsmSecret, crossNamespaceFinalizer) {
+		return nil
+	}
+	status := gsmSecret.Status.DeepCopy()
+	controllerutil.AddFinalizer(gsmSecret, crossNamespaceFinalizer)
+	if err := r.Update(ctx, gsmSecret); err != nil {
+		return err
+	}
+	gsmSecret.Status = *status
+	return nil
+}
+
+// finalizeGSMSecret deletes any Secrets the GSMSecret wrote into other
+// namespaces and then releases the cleanup finalizer.
+func (r *GSMSecretReconciler) finalizeGSMSecret(ctx context.Context, gsmSecret *secretspizecomv1alpha1.GSMSecret) error {
+	log := logf.FromContext(ctx)
+
+	if !controllerutil.ContainsFinalizer(gsmSecret, crossNamespaceFinalizer) {
+		return nil
+	}
+
+	var secrets corev1.SecretList
+	if err := r.List(ctx, &secrets, client.MatchingLabels{labelOwnerUID: string(gsmSecret.UID)}); err != nil {
+		return fmt.Errorf("list cross-namespace Secrets: %w", err)
+	}
+
+	for i := range secrets.Items {
+		s := &secrets.Items[i]
+		log.Info("deleting cross-namespace Secret", "secret", types.NamespacedName{Namespace: s.Namespace, Name: s.Name})
+		if err := r.Delete(ctx, s); err != nil && !apierrors.IsNotFound(err) {
+			return fmt.Errorf("delete Secret %s/%s: %w", s.Namespace, s.Name, err)
+		}
+	}
+
+	controllerutil.RemoveFinalizer(gsmSecret, crossNamespaceFinalizer)
+	return r.Update(ctx, gsmSecret)
+}
+
+// setCrossNamespaceOwner records the owning GSMSecret on a Secret that lives
+// in another namespace, where an OwnerReference is not allowed.
+func setCrossNamespaceOwner(owner *secretspizecomv1alpha1.GSMSecret, secret *corev1.Secret) {
+	if secret.Labels == nil {
+		secret.Labels = map[string]string{}
+	}
+	secret.Labels[labelOwnerUID] = string(owner.UID)
+
+	if secret.Annotations == nil {
+		secret.Annotations = map[string]string{}
+	}
+	secret.Annotations[annotationOwnerNamespace] = owner.Namespace
+	secret.Annotations[annotationOwnerName] = owner.Name
+}
+
+// mapCrossNamespaceSecret maps events on cross-namespace Secrets back to the
+// GSMSecret recorded in their annotations. Anyone who can write Secrets can
+// set those annotations, so the Secret's owner-uid label must match the
+// GSMSecret too.
+func (r *GSMSecretReconciler) mapCrossNamespaceSecret(ctx context.Context, obj client.Object) []reconcile.Request {
+	ann := obj.GetAnnotations()
+	ns, name := ann[annotationOwnerNamespace], ann[annotationOwnerName]
+	if ns == "" || name == "" || ns == obj.GetNamespace() {
+		return nil
+	}
+	key := types.NamespacedName{Namespace: ns, Name: name}
+	var gsmSecret secretspizecomv1alpha1.GSMSecret
+	if err := r.Get(ctx, key, &gsmSecret); err != nil {
+		return nil
+	}
+	if uid := obj.GetLabels()[labelOwnerUID]; uid == "" || uid != string(gsmSecret.UID) {
+		return nil
+	}
+	return []reconcile.Request{{NamespacedName: key}}
+}
+
+// mapGrantToGSMSecrets enqueues the GSMSecrets a grant covers: those in its
+// From namespaces that target a Secret in the grant's namespace, limited to
+// the To names when set. Updates map both the old and the new grant, so
+// GSMSecrets that lose their grant are reconciled too.
+func (r *GSMSecretReconciler) mapGrantToGSMSecrets(ctx context.Context, obj client.Object) []reconcile.Request {
+	grant, ok := obj.(*secretspizecomv1alpha1.GSMSecretGrant)
+	if !ok {
+		return nil
+	}
+	var requests []reconcile.Request
+	seen := map[string]bool{}
+	for _, from := range grant.Spec.From {
+		if seen[from.Namespace] || from.Namespace == grant.Namespace {
+			continue
+		}
+		seen[from.Namespace] = true
+
+		var list secretspizecomv1alpha1.GSMSecretList
+		if err := r.List(ctx, &list, client.InNamespace(from.Namespace)); err != nil {
+			logf.FromContext(ctx).Error(err, "failed to list GSMSecrets for grant",
+				"grant", types.NamespacedName{Namespace: grant.Namespace, Name: grant.Name}, "namespace", from.Namespace)
+			continue
+		}
+		for _, gsm := range list.Items {
+			if targetNamespace(&gsm) != grant.Namespace || !grantAllows(grant, gsm.Namespace, gsm.Spec.TargetSecret.Name) {
+				continue
+			}
+			requests = append(requests, reconcile.Request{
+				NamespacedName: types.NamespacedName{Namespace: gsm.Namespace, Name: gsm.Name},
+			})
+		}
+	}
+	return requests
+}
+
+// cleanupPreviousNamespace deletes the Secrets the GSMSecret wrote into the
+// namespace recorded in status.currentSecretNamespace when
+// targetSecret.namespace has since moved elsewhere.
+func (r *GSMSecretReconciler) cleanupPreviousNamespace(ctx context.Context, gsmSecret *secretspizecomv1alpha1.GSMSecret) error {
+	log := logf.FromContext(ctx)
+	previous := gsmSecret.Status.CurrentSecretNamespace
+	if previous == "" || previous == targetNamespace(gsmSecret) {
+		return nil
+	}
+
+	var secrets corev1.SecretList
+	if err := r.List(ctx, &secrets, client.InNamespace(previous)); err != nil {
+		return fmt.Errorf("list Secrets in previous target namespace %q: %w", previous, err)
+	}
+	for i := range secrets.Items {
+		s := &secrets.Items[i]
+		if s.Labels[labelOwnerUID] != string(gsmSecret.UID) && !metav1.IsControlledBy(s, gsmSecret) {
+			continue
+		}
+		log.Info("deleting Secret left in previous target namespace", "secret", types.NamespacedName{Namespace: s.Namespace, Name: s.Name})
+		if err := r.Delete(ctx, s); err != nil && !apierrors.IsNotFound(err) {
+			return fmt.Errorf("delete Secret %s/%s: %w", s.Namespace, s.Name, err)
+		}
+	}
+	return nil
+}
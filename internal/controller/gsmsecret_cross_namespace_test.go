@@ -0,0 +1,376 @@
+/*
+Copyright 2025 Zera Holladay.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	secretspizecomv1alpha1 "github.com/zeraholladay/gsm-operator/api/v1alpha1"
+)
+
+func newCrossNamespaceGSMSecret() *secretspizecomv1alpha1.GSMSecret {
+	return &secretspizecomv1alpha1.GSMSecret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "wildcard",
+			Namespace: "platform",
+			UID:       types.UID("wildcard-uid"),
+		},
+		Spec: secretspizecomv1alpha1.GSMSecretSpec{
+			TargetSecret: secretspizecomv1alpha1.GSMSecretTargetSecret{Name: "wildcard-tls", Namespace: "app"},
+			Secrets:      []secretspizecomv1alpha1.GSMSecretEntry{{Key: "K", ProjectID: "p", SecretID: "s", Version: "1"}},
+		},
+	}
+}
+
+func newGrant(namespace string, from []string, to ...string) *secretspizecomv1alpha1.GSMSecretGrant {
+	grant := &secretspizecomv1alpha1.GSMSecretGrant{
+		ObjectMeta: metav1.ObjectMeta{Name: "grant", Namespace: namespace},
+	}
+	for _, ns := range from {
+		grant.Spec.From = append(grant.Spec.From, secretspizecomv1alpha1.GSMSecretGrantFrom{Namespace: ns})
+	}
+	for _, name := range to {
+		grant.Spec.To = append(grant.Spec.To, secretspizecomv1alpha1.GSMSecretGrantTo{Name: name})
+	}
+	return grant
+}
+
+func TestTargetNamespace_DefaultsToOwnNamespace(t *testing.T) {
+	gsmSecret := newCrossNamespaceGSMSecret()
+	gsmSecret.Spec.TargetSecret.Namespace = ""
+
+	if got := targetNamespace(gsmSecret); got != "platform" {
+		t.Errorf("targetNamespace = %q, want %q", got, "platform")
+	}
+	if isCrossNamespaceTarget(gsmSecret) {
+		t.Error("expected same-namespace target")
+	}
+}
+
+func TestTargetNamespace_ExplicitNamespace(t *testing.T) {
+	gsmSecret := newCrossNamespaceGSMSecret()
+
+	if got := targetNamespace(gsmSecret); got != "app" {
+		t.Errorf("targetNamespace = %q, want %q", got, "app")
+	}
+	if !isCrossNamespaceTarget(gsmSecret) {
+		t.Error("expected cross-namespace target")
+	}
+}
+
+func TestGrantAllows(t *testing.T) {
+	tests := []struct {
+		name   string
+		grant  *secretspizecomv1alpha1.GSMSecretGrant
+		from   string
+		secret string
+		want   bool
+	}{
+		{"matching namespace, any name", newGrant("app", []string{"platform"}), "platform", "wildcard-tls", true},
+		{"matching namespace and name", newGrant("app", []string{"platform"}, "wildcard-tls"), "platform", "wildcard-tls", true},
+		{"matching namespace, other name", newGrant("app", []string{"platform"}, "db-creds"), "platform", "wildcard-tls", false},
+		{"other namespace", newGrant("app", []string{"tenant"}), "platform", "wildcard-tls", false},
+		{"one of several namespaces", newGrant("app", []string{"tenant", "platform"}), "platform", "wildcard-tls", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := grantAllows(tt.grant, tt.from, tt.secret); got != tt.want {
+				t.Errorf("grantAllows = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckTargetGrant_NoGrant(t *testing.T) {
+	gsmSecret := newCrossNamespaceGSMSecret()
+	r := newTestReconciler(gsmSecret)
+
+	err := r.checkTargetGrant(context.Background(), gsmSecret)
+	if err == nil {
+		t.Fatal("expected error when no grant exists")
+	}
+}
+
+func TestCheckTargetGrant_GrantInWrongNamespace(t *testing.T) {
+	gsmSecret := newCrossNamespaceGSMSecret()
+	r := newTestReconciler(gsmSecret, newGrant("platform", []string{"platform"}))
+
+	if err := r.checkTargetGrant(context.Background(), gsmSecret); err == nil {
+		t.Fatal("expected error when grant is not in the destination namespace")
+	}
+}
+
+func TestCheckTargetGrant_Allowed(t *testing.T) {
+	gsmSecret := newCrossNamespaceGSMSecret()
+	r := newTestReconciler(gsmSecret, newGrant("app", []string{"platform"}, "wildcard-tls"))
+
+	if err := r.checkTargetGrant(context.Background(), gsmSecret); err != nil {
+		t.Fatalf("expected grant to allow write, got %v", err)
+	}
+}
+
+func TestReconcile_CrossNamespaceWithoutGrant(t *testing.T) {
+	t.Setenv("REQUIRE_KSA_APPROVAL", "false")
+	gsmSecret := newCrossNamespaceGSMSecret()
+	r := newTestReconciler(gsmSecret)
+	ctx := context.Background()
+
+	_, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: types.NamespacedName{Name: "wildcard", Namespace: "platform"}})
+	if err == nil {
+		t.Fatal("expected error when grant is missing")
+	}
+
+	var updated secretspizecomv1alpha1.GSMSecret
+	if err := r.Get(ctx, types.NamespacedName{Name: "wildcard", Namespace: "platform"}, &updated); err != nil {
+		t.Fatalf("failed to get GSMSecret: %v", err)
+	}
+	if len(updated.Status.Conditions) != 3 || updated.Status.Conditions[0].Reason != "GrantDenied" {
+		t.Errorf("expected GrantDenied condition, got %+v", updated.Status.Conditions)
+	}
+	if controllerutil.ContainsFinalizer(&updated, crossNamespaceFinalizer) {
+		t.Error("finalizer should not be added before the grant check passes")
+	}
+}
+
+func TestApplySecret_CrossNamespaceUsesLabelsNotOwnerReference(t *testing.T) {
+	owner := newCrossNamespaceGSMSecret()
+	r := newTestReconciler(owner)
+	ctx := context.Background()
+
+	desired := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "wildcard-tls", Namespace: "app"},
+		Type:       corev1.SecretTypeOpaque,
+		Data:       map[string][]byte{"tls.crt": []byte("cert")},
+	}
+
+	if err := r.applySecret(ctx, owner, desired); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	var created corev1.Secret
+	if err := r.Get(ctx, types.NamespacedName{Name: "wildcard-tls", Namespace: "app"}, &created); err != nil {
+		t.Fatalf("expected secret to exist, got %v", err)
+	}
+	if len(created.OwnerReferences) != 0 {
+		t.Errorf("expected no owner references on cross-namespace Secret, got %d", len(created.OwnerReferences))
+	}
+	if created.Labels[labelOwnerUID] != string(owner.UID) {
+		t.Errorf("expected owner UID label %q, got %q", owner.UID, created.Labels[labelOwnerUID])
+	}
+	if created.Annotations[annotationOwnerNamespace] != "platform" || created.Annotations[annotationOwnerName] != "wildcard" {
+		t.Errorf("unexpected owner annotations: %v", created.Annotations)
+	}
+}
+
+func TestFinalizeGSMSecret_DeletesCrossNamespaceSecrets(t *testing.T) {
+	owner := newCrossNamespaceGSMSecret()
+	owner.Finalizers = []string{crossNamespaceFinalizer}
+	now := metav1.Now()
+	owner.DeletionTimestamp = &now
+
+	owned := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "wildcard-tls",
+			Namespace: "app",
+			Labels:    map[string]string{labelOwnerUID: string(owner.UID)},
+		},
+	}
+	unrelated := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "other",
+			Namespace: "app",
+			Labels:    map[string]string{labelOwnerUID: "someone-else"},
+		},
+	}
+
+	r := newTestReconciler(owner, owned, unrelated)
+	ctx := context.Background()
+
+	_, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: types.NamespacedName{Name: "wildcard", Namespace: "platform"}})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	var s corev1.Secret
+	if err := r.Get(ctx, types.NamespacedName{Name: "wildcard-tls", Namespace: "app"}, &s); !apierrors.IsNotFound(err) {
+		t.Errorf("expected owned Secret to be deleted, got %v", err)
+	}
+	if err := r.Get(ctx, types.NamespacedName{Name: "other", Namespace: "app"}, &s); err != nil {
+		t.Errorf("expected unrelated Secret to remain, got %v", err)
+	}
+
+	// Removing the last finalizer lets the fake client complete the deletion.
+	var gone secretspizecomv1alpha1.GSMSecret
+	if err := r.Get(ctx, types.NamespacedName{Name: "wildcard", Namespace: "platform"}, &gone); !apierrors.IsNotFound(err) {
+		t.Errorf("expected GSMSecret to be deleted after finalizer removal, got %v", err)
+	}
+}
+
+func TestMapCrossNamespaceSecret(t *testing.T) {
+	owner := newCrossNamespaceGSMSecret()
+	r := newTestReconciler(owner)
+	ctx := context.Background()
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "wildcard-tls",
+			Namespace: "app",
+			Labels:    map[string]string{labelOwnerUID: string(owner.UID)},
+			Annotations: map[string]string{
+				annotationOwnerNamespace: "platform",
+				annotationOwnerName:      "wildcard",
+			},
+		},
+	}
+
+	reqs := r.mapCrossNamespaceSecret(ctx, secret)
+	if len(reqs) != 1 {
+		t.Fatalf("expected 1 request, got %d", len(reqs))
+	}
+	if reqs[0].Namespace != "platform" || reqs[0].Name != "wildcard" {
+		t.Errorf("unexpected request %v", reqs[0].NamespacedName)
+	}
+
+	// Annotations anyone can write must not point at another tenant's GSMSecret.
+	forged := secret.DeepCopy()
+	forged.Labels[labelOwnerUID] = "other-uid"
+	if reqs := r.mapCrossNamespaceSecret(ctx, forged); len(reqs) != 0 {
+		t.Errorf("expected no requests for a Secret with a foreign owner UID, got %v", reqs)
+	}
+	delete(forged.Labels, labelOwnerUID)
+	if reqs := r.mapCrossNamespaceSecret(ctx, forged); len(reqs) != 0 {
+		t.Errorf("expected no requests for a Secret without an owner UID, got %v", reqs)
+	}
+
+	// Same-namespace Secrets are handled by Owns and must not be enqueued twice.
+	secret.Annotations[annotationOwnerNamespace] = "app"
+	if reqs := r.mapCrossNamespaceSecret(ctx, secret); len(reqs) != 0 {
+		t.Errorf("expected no requests for same-namespace owner, got %v", reqs)
+	}
+
+	if reqs := r.mapCrossNamespaceSecret(ctx, &corev1.Secret{}); len(reqs) != 0 {
+		t.Errorf("expected no requests for unannotated Secret, got %v", reqs)
+	}
+}
+
+func TestEnsureCleanupFinalizer_KeepsInMemoryStatus(t *testing.T) {
+	owner := newCrossNamespaceGSMSecret()
+	owner.Status.Plan = &secretspizecomv1alpha1.SecretPlan{Secret: "app/wildcard-tls"}
+	r := newTestReconciler(owner)
+	ctx := context.Background()
+
+	var gsmSecret secretspizecomv1alpha1.GSMSecret
+	if err := r.Get(ctx, client.ObjectKeyFromObject(owner), &gsmSecret); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	gsmSecret.Status.EffectiveAuthMode = secretspizecomv1alpha1.AuthModeWorkloadIdentityFederation
+	gsmSecret.Status.Plan = nil
+
+	if err := r.ensureCleanupFinalizer(ctx, &gsmSecret); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !controllerutil.ContainsFinalizer(&gsmSecret, crossNamespaceFinalizer) {
+		t.Error("expected the cleanup finalizer to be added")
+	}
+	if gsmSecret.Status.EffectiveAuthMode != secretspizecomv1alpha1.AuthModeWorkloadIdentityFederation || gsmSecret.Status.Plan != nil {
+		t.Errorf("expected the in-memory status to survive the update, got %+v", gsmSecret.Status)
+	}
+}
+
+func TestMapGrantToGSMSecrets(t *testing.T) {
+	covered := newCrossNamespaceGSMSecret()
+	otherName := newCrossNamespaceGSMSecret()
+	otherName.Name, otherName.UID = "other-name", "other-name-uid"
+	otherName.Spec.TargetSecret.Name = "other-tls"
+	otherTarget := newCrossNamespaceGSMSecret()
+	otherTarget.Name, otherTarget.UID = "other-target", "other-target-uid"
+	otherTarget.Spec.TargetSecret.Namespace = "web"
+	otherSource := newCrossNamespaceGSMSecret()
+	otherSource.Namespace = "team"
+	r := newTestReconciler(covered, otherName, otherTarget, otherSource)
+	ctx := context.Background()
+
+	reqs := r.mapGrantToGSMSecrets(ctx, newGrant("app", []string{"platform", "platform"}, "wildcard-tls"))
+	if len(reqs) != 1 || reqs[0].NamespacedName != (types.NamespacedName{Namespace: "platform", Name: "wildcard"}) {
+		t.Errorf("expected only platform/wildcard, got %v", reqs)
+	}
+
+	// Without To, every GSMSecret of a trusted namespace targeting app is covered.
+	if reqs := r.mapGrantToGSMSecrets(ctx, newGrant("app", []string{"platform"})); len(reqs) != 2 {
+		t.Errorf("expected 2 requests, got %v", reqs)
+	}
+	if reqs := r.mapGrantToGSMSecrets(ctx, newGrant("app", []string{"elsewhere"})); len(reqs) != 0 {
+		t.Errorf("expected no requests for an untrusted namespace, got %v", reqs)
+	}
+}
+
+func TestCleanupPreviousNamespace(t *testing.T) {
+	owner := newCrossNamespaceGSMSecret()
+	owner.Spec.TargetSecret.Namespace = "web"
+	owner.Status.CurrentSecretNamespace = "app"
+
+	owned := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{
+		Name:      "wildcard-tls",
+		Namespace: "app",
+		Labels:    map[string]string{labelOwnerUID: string(owner.UID)},
+	}}
+	unrelated := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "app"}}
+	r := newTestReconciler(owner, owned, unrelated)
+	ctx := context.Background()
+
+	if err := r.cleanupPreviousNamespace(ctx, owner); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var s corev1.Secret
+	if err := r.Get(ctx, types.NamespacedName{Name: "wildcard-tls", Namespace: "app"}, &s); !apierrors.IsNotFound(err) {
+		t.Errorf("expected the Secret in the previous namespace to be deleted, got %v", err)
+	}
+	if err := r.Get(ctx, types.NamespacedName{Name: "other", Namespace: "app"}, &s); err != nil {
+		t.Errorf("expected unrelated Secret to be kept, got %v", err)
+	}
+
+	// Back in its own namespace, the Secret there is found by OwnerReference.
+	owner.Status.CurrentSecretNamespace = "platform"
+	ownNamespace := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "wildcard-tls", Namespace: "platform"}}
+	if err := ctrl.SetControllerReference(owner, ownNamespace, r.Scheme); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := r.Create(ctx, ownNamespace); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := r.cleanupPreviousNamespace(ctx, owner); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := r.Get(ctx, types.NamespacedName{Name: "wildcard-tls", Namespace: "platform"}, &s); !apierrors.IsNotFound(err) {
+		t.Errorf("expected the owned Secret in the previous namespace to be deleted, got %v", err)
+	}
+
+	// Nothing is deleted while the target namespace is unchanged.
+	owner.Status.CurrentSecretNamespace = "web"
+	if err := r.cleanupPreviousNamespace(ctx, owner); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
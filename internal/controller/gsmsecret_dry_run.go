@@ -0,0 +1,196 @@
+/*
+Copyright 2025 Zera Holladay.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	secretspizecomv1alpha1 "github.com/zeraholladay/gsm-operator/api/v1alpha1"
+)
+
+// reasonDryRun is the status reason and event reason of a dry-run reconcile.
+const reasonDryRun = "DryRun"
+
+// reportPlan records in status what a sync of desired would change, without
+// writing the Secret. Ready stays False with reason DryRun, since the Secret
+// for the current generation is not live.
+func (r *GSMSecretReconciler) reportPlan(ctx context.Context, gsmSecret *secretspizecomv1alpha1.GSMSecret, desired *corev1.Secret) (ctrl.Result, error) {
+	log := logf.FromContext(ctx)
+
+	plan, err := r.planSecret(ctx, gsmSecret, desired)
+	if err != nil {
+		log.Error(err, "failed to plan Kubernetes Secret")
+		r.recordEvent(gsmSecret, corev1.EventTypeWarning, "PlanFailed", err.Error())
+		if statusErr := r.setStatusCondition(ctx, gsmSecret, metav1.ConditionFalse, "PlanFailed", err.Error()); statusErr != nil {
+			log.Error(statusErr, "failed to update status after plan error")
+		}
+		return ctrl.Result{}, err
+	}
+
+	message := planSummary(plan)
+	log.Info("dry run; Kubernetes Secret not written", "plan", message)
+	r.recordEvent(gsmSecret, corev1.EventTypeNormal, reasonDryRun, message)
+	recordReconcileReason(ctx, reasonDryRun)
+	gsmSecret.Status.Plan = plan
+	if err := r.updateConditions(ctx, gsmSecret,
+		metav1.Condition{Type: conditionTypeReady, Status: metav1.ConditionFalse, Reason: reasonDryRun, Message: message},
+		metav1.Condition{Type: conditionTypeProgressing, Status: metav1.ConditionFalse, Reason: reasonDryRun, Message: message},
+		metav1.Condition{Type: conditionTypeDegraded, Status: metav1.ConditionFalse, Reason: reasonDryRun, Message: message},
+	); err != nil {
+		log.Error(err, "failed to update status after dry run")
+		return ctrl.Result{}, err
+	}
+	return ctrl.Result{RequeueAfter: resyncAfter()}, nil
+}
+
+// planSecret compares desired with the Secret in the cluster. Changes that
+// would be applied are confirmed with a server-side dry-run apply, which
+// reports conflicts with other field managers without writing anything.
+func (r *GSMSecretReconciler) planSecret(ctx context.Context, owner *secretspizecomv1alpha1.GSMSecret, desired *corev1.Secret) (*secretspizecomv1alpha1.SecretPlan, error) {
+	if err := r.prepareSecret(owner, desired); err != nil {
+		return nil, err
+	}
+	key := types.NamespacedName{Namespace: desired.Namespace, Name: desired.Name}
+	plan := &secretspizecomv1alpha1.SecretPlan{Secret: key.String()}
+
+	var existing corev1.Secret
+	err := r.Get(ctx, key, &existing)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return nil, err
+	}
+	if apierrors.IsNotFound(err) {
+		plan.Action = secretspizecomv1alpha1.SecretPlanCreate
+		for _, k := range slices.Sorted(maps.Keys(desired.Data)) {
+			plan.Added = append(plan.Added, k)
+		}
+	} else {
+		diffSecret(plan, &existing, desired)
+		if desired.Namespace == owner.Namespace {
+			// Secrets another controller owns are refused, as on apply.
+			if err := ctrl.SetControllerReference(owner, existing.DeepCopy(), r.Scheme); err != nil {
+				plan.Conflict = err.Error()
+				return plan, nil
+			}
+		}
+	}
+	if plan.Action == secretspizecomv1alpha1.SecretPlanNone {
+		return plan, nil
+	}
+
+	opts := []client.ApplyOption{client.FieldOwner(fieldManager), client.DryRunAll}
+	if owner.Spec.TargetSecret.ForceConflicts {
+		opts = append(opts, client.ForceOwnership)
+	}
+	if err := r.Apply(ctx, secretApplyConfiguration(desired), opts...); err != nil {
+		if !apierrors.IsConflict(err) {
+			return nil, err
+		}
+		plan.Conflict = err.Error()
+	}
+	return plan, nil
+}
+
+// diffSecret fills plan with the differences between existing and desired.
+// Only keys the operator owns are planned for removal; keys of other field
+// managers are left alone by an apply.
+func diffSecret(plan *secretspizecomv1alpha1.SecretPlan, existing, desired *corev1.Secret) {
+	for _, k := range slices.Sorted(maps.Keys(desired.Data)) {
+		current, ok := existing.Data[k]
+		switch {
+		case !ok:
+			plan.Added = append(plan.Added, k)
+		case string(current) != string(desired.Data[k]):
+			plan.Changed = append(plan.Changed, k)
+		}
+	}
+	if owned, ok := ownedDataKeys(existing); ok {
+		for _, k := range slices.Sorted(maps.Keys(existing.Data)) {
+			if _, kept := desired.Data[k]; owned[k] && !kept {
+				plan.Removed = append(plan.Removed, k)
+			}
+		}
+	}
+
+	if existing.Type != desired.Type {
+		plan.TypeChange = fmt.Sprintf("%s -> %s", existing.Type, desired.Type)
+	}
+	plan.Metadata = append(plan.Metadata, mapChanges("label", existing.Labels, desired.Labels)...)
+	plan.Metadata = append(plan.Metadata, mapChanges("annotation", existing.Annotations, desired.Annotations)...)
+	for _, ref := range desired.OwnerReferences {
+		if !hasOwnerReference(existing, ref) {
+			plan.Metadata = append(plan.Metadata, fmt.Sprintf("set owner reference to %s %s", ref.Kind, ref.Name))
+		}
+	}
+	if desired.Immutable != nil && (existing.Immutable == nil || *existing.Immutable != *desired.Immutable) {
+		plan.Metadata = append(plan.Metadata, fmt.Sprintf("set immutable to %t", *desired.Immutable))
+	}
+
+	plan.Action = secretspizecomv1alpha1.SecretPlanNone
+	if len(plan.Added)+len(plan.Changed)+len(plan.Removed)+len(plan.Metadata) > 0 || plan.TypeChange != "" {
+		plan.Action = secretspizecomv1alpha1.SecretPlanUpdate
+	}
+}
+
+// mapChanges describes the entries of want that got lacks or holds with a
+// different value, without the values.
+func mapChanges(kind string, got, want map[string]string) []string {
+	var changes []string
+	for _, k := range slices.Sorted(maps.Keys(want)) {
+		current, ok := got[k]
+		switch {
+		case !ok:
+			changes = append(changes, fmt.Sprintf("add %s %q", kind, k))
+		case current != want[k]:
+			changes = append(changes, fmt.Sprintf("change %s %q", kind, k))
+		}
+	}
+	return changes
+}
+
+// planSummary is the one-line description of plan used in the Ready
+// condition and the DryRun event.
+func planSummary(plan *secretspizecomv1alpha1.SecretPlan) string {
+	var b strings.Builder
+	switch plan.Action {
+	case secretspizecomv1alpha1.SecretPlanCreate:
+		fmt.Fprintf(&b, "Dry run: would create Secret %s; keys added: %d", plan.Secret, len(plan.Added))
+	case secretspizecomv1alpha1.SecretPlanUpdate:
+		fmt.Fprintf(&b, "Dry run: would update Secret %s; keys added: %d, changed: %d, removed: %d",
+			plan.Secret, len(plan.Added), len(plan.Changed), len(plan.Removed))
+		if plan.TypeChange != "" || len(plan.Metadata) > 0 {
+			b.WriteString("; type or metadata changed")
+		}
+	default:
+		fmt.Fprintf(&b, "Dry run: Secret %s is up to date", plan.Secret)
+	}
+	if plan.Conflict != "" {
+		fmt.Fprintf(&b, "; a sync would fail: %s", plan.Conflict)
+	}
+	return b.String()
+}
@@ -0,0 +1,225 @@
+/*
+Copyright 2025 Zera Holladay.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	secretspizecomv1alpha1 "github.com/zeraholladay/gsm-operator/api/v1alpha1"
+)
+
+func dryRunOwner() *secretspizecomv1alpha1.GSMSecret {
+	owner := ssaOwner(false)
+	owner.Spec.DryRun = true
+	return owner
+}
+
+// withServerDryRun makes r's client check that applies are server-side dry
+// runs. The fake client ignores DryRunAll on Apply and would persist the
+// Secret, so successful dry runs are answered without calling it. With
+// detectConflicts set the apply is passed through, for tests where the fake
+// fails it with a conflict before writing anything.
+func withServerDryRun(t *testing.T, r *GSMSecretReconciler, detectConflicts bool) {
+	t.Helper()
+	r.Client = interceptor.NewClient(r.Client.(client.WithWatch), interceptor.Funcs{
+		Apply: func(ctx context.Context, c client.WithWatch, obj runtime.ApplyConfiguration, opts ...client.ApplyOption) error {
+			applyOpts := &client.ApplyOptions{}
+			applyOpts.ApplyOptions(opts)
+			if len(applyOpts.DryRun) != 1 || applyOpts.DryRun[0] != metav1.DryRunAll {
+				t.Errorf("expected a server-side dry run, got DryRun=%v", applyOpts.DryRun)
+				return nil
+			}
+			if detectConflicts {
+				return c.Apply(ctx, obj, opts...)
+			}
+			return nil
+		},
+	})
+}
+
+func TestPlanSecret_Create(t *testing.T) {
+	owner := dryRunOwner()
+	r := newTestReconciler(owner)
+	withServerDryRun(t, r, false)
+	ctx := context.Background()
+
+	plan, err := r.planSecret(ctx, owner, ssaDesired(map[string][]byte{"DB": []byte("hunter2")}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if plan.Action != secretspizecomv1alpha1.SecretPlanCreate || plan.Secret != "default/app" || plan.Conflict != "" {
+		t.Errorf("unexpected plan %+v", plan)
+	}
+	if !slices.Equal(plan.Added, []string{"DB"}) {
+		t.Errorf("expected DB to be added, got %+v", plan.Added)
+	}
+	if data, _ := json.Marshal(plan); strings.Contains(string(data), "sha256") || strings.Contains(string(data), "hunter2") {
+		t.Errorf("expected the plan to record no value or digest, got %s", data)
+	}
+
+	err = r.Get(ctx, types.NamespacedName{Namespace: "default", Name: "app"}, &corev1.Secret{})
+	if !apierrors.IsNotFound(err) {
+		t.Errorf("expected no Secret to be written, got %v", err)
+	}
+}
+
+func TestPlanSecret_Update(t *testing.T) {
+	owner := ssaOwner(false)
+	r := newTestReconciler(owner)
+	ctx := context.Background()
+	key := types.NamespacedName{Namespace: "default", Name: "app"}
+
+	if err := r.applySecret(ctx, owner, ssaDesired(map[string][]byte{"DB": []byte("one"), "GONE": []byte("x")})); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var before corev1.Secret
+	if err := r.Get(ctx, key, &before); err != nil {
+		t.Fatalf("get secret: %v", err)
+	}
+	// Keys of other field managers are not planned for removal.
+	before.Data["EXTRA"] = []byte("theirs")
+	if err := r.Update(ctx, &before); err != nil {
+		t.Fatalf("update secret: %v", err)
+	}
+
+	withServerDryRun(t, r, false)
+	desired := ssaDesired(map[string][]byte{"DB": []byte("two"), "NEW": []byte("n")})
+	desired.Labels = map[string]string{"app": "web"}
+	plan, err := r.planSecret(ctx, dryRunOwner(), desired)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := &secretspizecomv1alpha1.SecretPlan{
+		Secret:  "default/app",
+		Action:  secretspizecomv1alpha1.SecretPlanUpdate,
+		Added:   []string{"NEW"},
+		Changed: []string{"DB"},
+		Removed: []string{"GONE"},
+		Metadata: []string{
+			`add label "app"`,
+			`change annotation "` + annotationContentHash + `"`,
+		},
+	}
+	gotJSON, _ := json.Marshal(plan)
+	wantJSON, _ := json.Marshal(want)
+	if string(gotJSON) != string(wantJSON) {
+		t.Errorf("expected plan\n%s\ngot\n%s", wantJSON, gotJSON)
+	}
+	for _, value := range []string{"one", "two", "theirs"} {
+		if strings.Contains(string(gotJSON), `"`+value+`"`) {
+			t.Errorf("plan must not contain Secret values, found %q", value)
+		}
+	}
+
+	var after corev1.Secret
+	if err := r.Get(ctx, key, &after); err != nil {
+		t.Fatalf("get secret: %v", err)
+	}
+	if after.ResourceVersion == "" || string(after.Data["DB"]) != "one" {
+		t.Errorf("expected the Secret to be left alone, got DB=%q", after.Data["DB"])
+	}
+}
+
+func TestPlanSecret_UpToDate(t *testing.T) {
+	owner := ssaOwner(false)
+	r := newTestReconciler(owner)
+	ctx := context.Background()
+	data := map[string][]byte{"DB": []byte("one")}
+
+	if err := r.applySecret(ctx, owner, ssaDesired(data)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	plan, err := r.planSecret(ctx, dryRunOwner(), ssaDesired(data))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if plan.Action != secretspizecomv1alpha1.SecretPlanNone || len(plan.Metadata) != 0 {
+		t.Errorf("expected no changes, got %+v", plan)
+	}
+}
+
+func TestPlanSecret_Conflict(t *testing.T) {
+	// Another writer already set DB to a different value.
+	existing := ssaDesired(map[string][]byte{"DB": []byte("theirs")})
+	r := newTestReconciler(dryRunOwner(), existing)
+	withServerDryRun(t, r, true)
+	ctx := context.Background()
+
+	plan, err := r.planSecret(ctx, dryRunOwner(), ssaDesired(map[string][]byte{"DB": []byte("ours")}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(plan.Conflict, "conflict") {
+		t.Errorf("expected the apply conflict in the plan, got %q", plan.Conflict)
+	}
+
+	var secret corev1.Secret
+	if err := r.Get(ctx, types.NamespacedName{Namespace: "default", Name: "app"}, &secret); err != nil {
+		t.Fatalf("get secret: %v", err)
+	}
+	if string(secret.Data["DB"]) != "theirs" {
+		t.Errorf("expected the Secret to be left alone, got DB=%q", secret.Data["DB"])
+	}
+}
+
+func TestReportPlan(t *testing.T) {
+	t.Setenv("RESYNC_JITTER_PERCENT", "0")
+	owner := dryRunOwner()
+	r := newTestReconciler(owner)
+	withServerDryRun(t, r, false)
+	recorder := record.NewFakeRecorder(10)
+	r.Recorder = recorder
+	ctx := context.Background()
+
+	result, err := r.reportPlan(ctx, owner, ssaDesired(map[string][]byte{"DB": []byte("one")}))
+	if err != nil || result.RequeueAfter != getResyncInterval() {
+		t.Errorf("expected a requeue at the resync interval, got result=%+v err=%v", result, err)
+	}
+
+	var got secretspizecomv1alpha1.GSMSecret
+	if err := r.Get(ctx, types.NamespacedName{Namespace: "default", Name: "app"}, &got); err != nil {
+		t.Fatalf("get GSMSecret: %v", err)
+	}
+	if got.Status.Plan == nil || got.Status.Plan.Action != secretspizecomv1alpha1.SecretPlanCreate {
+		t.Errorf("expected a Create plan in status, got %+v", got.Status.Plan)
+	}
+	for _, condType := range []string{conditionTypeReady, conditionTypeProgressing, conditionTypeDegraded} {
+		cond := apimeta.FindStatusCondition(got.Status.Conditions, condType)
+		if cond == nil || cond.Status != metav1.ConditionFalse || cond.Reason != reasonDryRun {
+			t.Errorf("expected %s=False with reason %s, got %+v", condType, reasonDryRun, cond)
+		}
+	}
+	want := "Normal DryRun Dry run: would create Secret default/app; keys added: 1"
+	if events := drainEvents(recorder); len(events) != 1 || events[0] != want {
+		t.Errorf("expected event %q, got %v", want, events)
+	}
+}
@@ -0,0 +1,140 @@
+/*
+Copyright 2025 Zera Holladay.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	secretspizecomv1alpha1 "github.com/zeraholladay/gsm-operator/api/v1alpha1"
+)
+
+// Reasons of the Normal events emitted on a GSMSecret. Failures are reported
+// with the reason of the Ready condition instead.
+const (
+	eventReasonSecretCreated = "SecretCreated"
+	eventReasonSecretUpdated = "SecretUpdated"
+	eventReasonSecretAdopted = "SecretAdopted"
+	eventReasonNewVersion    = "NewVersion"
+)
+
+// eventDedupWindow is how long an event identical to the last one emitted for
+// the same GSMSecret is suppressed. A failure that persists is reported again
+// once the window has passed.
+const eventDedupWindow = 30 * time.Minute
+
+// eventDeduper suppresses repeats of the last event emitted for each
+// GSMSecret, so a failure retried with backoff is reported once rather than
+// on every attempt. It holds one entry per GSMSecret.
+type eventDeduper struct {
+	window time.Duration
+	now    func() time.Time
+
+	mu   sync.Mutex
+	last map[types.NamespacedName]emittedEvent
+}
+
+// emittedEvent is the last event emitted for a GSMSecret.
+type emittedEvent struct {
+	uid                        types.UID
+	eventType, reason, message string
+	at                         time.Time
+}
+
+func newEventDeduper(window time.Duration) *eventDeduper {
+	return &eventDeduper{window: window, now: time.Now, last: map[types.NamespacedName]emittedEvent{}}
+}
+
+// duplicate reports whether the event repeats the last one emitted for
+// gsmSecret within the window, and otherwise records it as the last one.
+func (d *eventDeduper) duplicate(gsmSecret *secretspizecomv1alpha1.GSMSecret, eventType, reason, message string) bool {
+	key := types.NamespacedName{Namespace: gsmSecret.Namespace, Name: gsmSecret.Name}
+	e := emittedEvent{uid: gsmSecret.UID, eventType: eventType, reason: reason, message: message, at: d.now()}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if prev, ok := d.last[key]; ok && prev.uid == e.uid && prev.eventType == e.eventType &&
+		prev.reason == e.reason && prev.message == e.message && e.at.Sub(prev.at) < d.window {
+		return true
+	}
+	d.last[key] = e
+	return false
+}
+
+// recovered drops the last event of gsmSecret if it was a Warning, after a
+// sync that emitted no event succeeded. A later failure is then reported
+// again even if it repeats the one before the recovery.
+func (d *eventDeduper) recovered(gsmSecret *secretspizecomv1alpha1.GSMSecret) {
+	key := types.NamespacedName{Namespace: gsmSecret.Namespace, Name: gsmSecret.Name}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if prev, ok := d.last[key]; ok && prev.eventType == corev1.EventTypeWarning {
+		delete(d.last, key)
+	}
+}
+
+// forget drops the last event of a deleted GSMSecret.
+func (d *eventDeduper) forget(key types.NamespacedName) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	delete(d.last, key)
+}
+
+// changedKeys returns the sorted keys added, removed or changed between two
+// Secret data maps. Values are compared but never returned.
+func changedKeys(before, after map[string][]byte) []string {
+	var keys []string
+	for k, v := range after {
+		if old, ok := before[k]; !ok || !bytes.Equal(old, v) {
+			keys = append(keys, k)
+		}
+	}
+	for k := range before {
+		if _, ok := after[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// newVersionsMessage describes the requested versions that resolved to a
+// different version than on the last sync, or returns "" if none did.
+// Versions seen for the first time are not reported.
+func newVersionsMessage(before, after []secretspizecomv1alpha1.ResolvedSecretVersion) string {
+	previous := make(map[string]string, len(before))
+	for _, v := range before {
+		previous[v.Secret] = v.Version
+	}
+	var changes []string
+	for _, v := range after {
+		if old, ok := previous[v.Secret]; ok && old != v.Version {
+			changes = append(changes, fmt.Sprintf("%s resolved to version %s (was %s)", v.Secret, v.Version, old))
+		}
+	}
+	if len(changes) == 0 {
+		return ""
+	}
+	return "Picked up new Secret Manager versions: " + strings.Join(changes, "; ")
+}
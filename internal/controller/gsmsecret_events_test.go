@@ -0,0 +1,208 @@
+/*
+Copyright 2025 Zera Holladay.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	secretspizecomv1alpha1 "github.com/zeraholladay/gsm-operator/api/v1alpha1"
+)
+
+// drainEvents returns the events recorded so far.
+func drainEvents(recorder *record.FakeRecorder) []string {
+	var events []string
+	for {
+		select {
+		case e := <-recorder.Events:
+			events = append(events, e)
+		default:
+			return events
+		}
+	}
+}
+
+func TestEventDeduper(t *testing.T) {
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	d := newEventDeduper(time.Minute)
+	d.now = func() time.Time { return now }
+	gsm := &secretspizecomv1alpha1.GSMSecret{ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "team", UID: "uid-1"}}
+
+	if d.duplicate(gsm, corev1.EventTypeWarning, "AuthFailed", "denied") {
+		t.Fatal("expected the first event to be emitted")
+	}
+	if !d.duplicate(gsm, corev1.EventTypeWarning, "AuthFailed", "denied") {
+		t.Error("expected an identical event to be suppressed")
+	}
+	if d.duplicate(gsm, corev1.EventTypeWarning, "AuthFailed", "denied again") {
+		t.Error("expected an event with a new message to be emitted")
+	}
+	if d.duplicate(gsm, corev1.EventTypeWarning, "AuthFailed", "denied") {
+		t.Error("expected an event that is not the last one to be emitted")
+	}
+
+	now = now.Add(time.Minute)
+	if d.duplicate(gsm, corev1.EventTypeWarning, "AuthFailed", "denied") {
+		t.Error("expected an identical event to be emitted again after the window")
+	}
+
+	recreated := gsm.DeepCopy()
+	recreated.UID = "uid-2"
+	if d.duplicate(recreated, corev1.EventTypeWarning, "AuthFailed", "denied") {
+		t.Error("expected a recreated GSMSecret to get its own events")
+	}
+
+	d.forget(types.NamespacedName{Namespace: "team", Name: "app"})
+	if d.duplicate(recreated, corev1.EventTypeWarning, "AuthFailed", "denied") {
+		t.Error("expected a forgotten GSMSecret to get its events again")
+	}
+}
+
+func TestEventDeduper_FailRecoverFail(t *testing.T) {
+	d := newEventDeduper(time.Hour)
+	gsm := &secretspizecomv1alpha1.GSMSecret{ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "team", UID: "uid-1"}}
+
+	if d.duplicate(gsm, corev1.EventTypeWarning, "AuthFailed", "denied") {
+		t.Fatal("expected the first failure to be emitted")
+	}
+	d.recovered(gsm)
+	if d.duplicate(gsm, corev1.EventTypeWarning, "AuthFailed", "denied") {
+		t.Error("expected the same failure to be emitted again after a recovery")
+	}
+
+	// A recovery keeps the last Normal event, so unchanged Normal events stay
+	// deduplicated.
+	if d.duplicate(gsm, corev1.EventTypeNormal, reasonDryRun, "no changes") {
+		t.Fatal("expected the first Normal event to be emitted")
+	}
+	d.recovered(gsm)
+	if !d.duplicate(gsm, corev1.EventTypeNormal, reasonDryRun, "no changes") {
+		t.Error("expected a repeated Normal event to be suppressed")
+	}
+}
+
+func TestChangedKeys(t *testing.T) {
+	before := map[string][]byte{"A": []byte("1"), "B": []byte("2"), "C": []byte("3")}
+	after := map[string][]byte{"A": []byte("1"), "B": []byte("two"), "D": []byte("4")}
+	if got := strings.Join(changedKeys(before, after), ","); got != "B,C,D" {
+		t.Errorf("expected B,C,D, got %q", got)
+	}
+	if got := changedKeys(before, before); len(got) != 0 {
+		t.Errorf("expected no changed keys, got %v", got)
+	}
+}
+
+func TestNewVersionsMessage(t *testing.T) {
+	const latest = "projects/p/secrets/db/versions/latest"
+	before := []secretspizecomv1alpha1.ResolvedSecretVersion{{Secret: latest, Version: "4"}}
+
+	if got := newVersionsMessage(before, before); got != "" {
+		t.Errorf("expected no message for unchanged versions, got %q", got)
+	}
+	if got := newVersionsMessage(nil, before); got != "" {
+		t.Errorf("expected no message on the first sync, got %q", got)
+	}
+	after := []secretspizecomv1alpha1.ResolvedSecretVersion{
+		{Secret: latest, Version: "5"},
+		{Secret: "projects/p/secrets/api/versions/2", Version: "2"},
+	}
+	want := "Picked up new Secret Manager versions: " + latest + " resolved to version 5 (was 4)"
+	if got := newVersionsMessage(before, after); got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+}
+
+func TestApplySecret_Events(t *testing.T) {
+	owner := &secretspizecomv1alpha1.GSMSecret{
+		ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "default", UID: "owner-uid"},
+		Spec: secretspizecomv1alpha1.GSMSecretSpec{
+			TargetSecret: secretspizecomv1alpha1.GSMSecretTargetSecret{ForceConflicts: true},
+		},
+	}
+	preexisting := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "adopted", Namespace: "default"},
+		Data:       map[string][]byte{"KEEP": []byte("same"), "TOKEN": []byte("old-value")},
+	}
+	r := newTestReconciler(owner, preexisting)
+	recorder := record.NewFakeRecorder(10)
+	r.Recorder = recorder
+	ctx := context.Background()
+
+	desired := func(name string, data map[string][]byte) *corev1.Secret {
+		return &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+			Type:       corev1.SecretTypeOpaque,
+			Data:       data,
+		}
+	}
+
+	if err := r.applySecret(ctx, owner, desired("created", map[string][]byte{"A": []byte("1")})); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := r.applySecret(ctx, owner, desired("created", map[string][]byte{"A": []byte("1")})); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := r.applySecret(ctx, owner, desired("adopted", map[string][]byte{"KEEP": []byte("same"), "TOKEN": []byte("new-value")})); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []string{
+		"Normal SecretCreated Created Secret default/created",
+		"Normal SecretAdopted Adopted pre-existing Secret default/adopted",
+		"Normal SecretUpdated Updated Secret default/adopted; changed keys: TOKEN",
+	}
+	got := drainEvents(recorder)
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("expected events\n%s\ngot\n%s", strings.Join(want, "\n"), strings.Join(got, "\n"))
+	}
+	for _, e := range got {
+		if strings.Contains(e, "value") {
+			t.Errorf("event must not contain Secret values: %q", e)
+		}
+	}
+}
+
+func TestReconcile_RepeatedFailureEventsAreDeduplicated(t *testing.T) {
+	t.Setenv("REQUIRE_KSA_APPROVAL", "false")
+	t.Setenv("MODE", "")
+	t.Setenv("WIFAUDIENCE", "")
+
+	gsm := newStoreGSMSecret("team", "app", nil)
+	c := newStoreTestClient(gsm)
+	recorder := record.NewFakeRecorder(10)
+	r := &GSMSecretReconciler{Client: c, Scheme: c.Scheme(), Recorder: recorder, recentEvents: newEventDeduper(eventDedupWindow)}
+
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "team", Name: "app"}}
+	for range 3 {
+		if _, err := r.Reconcile(context.Background(), req); err == nil {
+			t.Fatal("expected credential error")
+		}
+	}
+
+	events := drainEvents(recorder)
+	if len(events) != 1 || !strings.HasPrefix(events[0], "Warning AuthFailed") {
+		t.Errorf("expected a single AuthFailed warning, got %q", events)
+	}
+}
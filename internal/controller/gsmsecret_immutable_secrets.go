@@ -0,0 +1,246 @@
+/*
+Copyright 2025 Zera Holladay.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	secretspizecomv1alpha1 "github.com/zeraholladay/gsm-operator/api/v1alpha1"
+)
+
+const (
+	// immutableHashLength is the number of hex characters of the content hash
+	// appended to immutable Secret names.
+	immutableHashLength = 10
+
+	// defaultRetainGenerations is used when targetSecret.retainGenerations is unset.
+	defaultRetainGenerations int32 = 3
+)
+
+// immutableSecretName returns "<base>-<hash>" where hash is a prefix of the
+// Secret's content hash.
+func immutableSecretName(base string, secretType corev1.SecretType, data map[string][]byte) string {
+	return fmt.Sprintf("%s-%s", base, secretContentHash(secretType, data)[:immutableHashLength])
+}
+
+// secretContentHash returns the hex SHA-256 of the Secret type and every
+// key/value pair in a deterministic order.
+func secretContentHash(secretType corev1.SecretType, data map[string][]byte) string {
+	keys := make([]string, 0, len(data))
+	for k := range data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	h := sha256.New()
+	writeField := func(b []byte) {
+		// Length-prefix each field so ("ab","c") and ("a","bc") hash differently.
+		var n [8]byte
+		binary.BigEndian.PutUint64(n[:], uint64(len(b)))
+		h.Write(n[:])
+		h.Write(b)
+	}
+	writeField([]byte(secretType))
+	for _, k := range keys {
+		writeField([]byte(k))
+		writeField(data[k])
+	}
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// retainGenerations returns how many previous immutable Secrets to keep.
+func retainGenerations(gsmSecret *secretspizecomv1alpha1.GSMSecret) int {
+	if n := gsmSecret.Spec.TargetSecret.RetainGenerations; n != nil && *n >= 0 {
+		return int(*n)
+	}
+	return int(defaultRetainGenerations)
+}
+
+// pruneSecretGenerations deletes immutable Secrets written by the GSMSecret
+// that are older than the retention count. Secrets still referenced by
+// running pods or workload pod templates are kept regardless of age.
+func (r *GSMSecretReconciler) pruneSecretGenerations(ctx context.Context, gsmSecret *secretspizecomv1alpha1.GSMSecret, current string) error {
+	log := logf.FromContext(ctx)
+	namespace := targetNamespace(gsmSecret)
+	prefix := gsmSecret.Spec.TargetSecret.Name + "-"
+
+	var secrets corev1.SecretList
+	if err := r.List(ctx, &secrets,
+		client.InNamespace(namespace),
+		client.MatchingLabels{labelOwnerUID: string(gsmSecret.UID)},
+	); err != nil {
+		return fmt.Errorf("list immutable Secret generations: %w", err)
+	}
+
+	var previous []corev1.Secret
+	for _, s := range secrets.Items {
+		if s.Name == current || !strings.HasPrefix(s.Name, prefix) || s.Immutable == nil || !*s.Immutable {
+			continue
+		}
+		previous = append(previous, s)
+	}
+
+	keep := retainGenerations(gsmSecret)
+	if len(previous) <= keep {
+		return nil
+	}
+
+	// Newest first, so everything past the retention count is a candidate.
+	sort.Slice(previous, func(i, j int) bool {
+		return previous[j].CreationTimestamp.Before(&previous[i].CreationTimestamp)
+	})
+
+	inUse, err := r.secretsInUse(ctx, namespace)
+	if err != nil {
+		return err
+	}
+
+	for i := keep; i < len(previous); i++ {
+		s := &previous[i]
+		if inUse[s.Name] {
+			log.Info("keeping old immutable Secret still referenced by pods or workloads", "secret", s.Name)
+			continue
+		}
+		log.Info("pruning old immutable Secret generation", "secret", s.Name)
+		if err := r.Delete(ctx, s); err != nil && !apierrors.IsNotFound(err) {
+			return fmt.Errorf("delete Secret %s/%s: %w", s.Namespace, s.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// secretsInUse returns the names of Secrets referenced by pods in the
+// namespace that have not yet terminated, and by the pod templates of its
+// Deployments, StatefulSets, DaemonSets, Jobs and CronJobs, so a generation
+// the next rollout or scheduled run would use is kept too. Workloads of other
+// kinds, and ReplicaSets kept for rollback, are not checked.
+func (r *GSMSecretReconciler) secretsInUse(ctx context.Context, namespace string) (map[string]bool, error) {
+	inUse := map[string]bool{}
+	markSpec := func(spec *corev1.PodSpec) {
+		for _, name := range podSecretNames(spec) {
+			inUse[name] = true
+		}
+	}
+
+	var pods corev1.PodList
+	if err := r.podReader().List(ctx, &pods, client.InNamespace(namespace)); err != nil {
+		return nil, fmt.Errorf("list pods in namespace %q: %w", namespace, err)
+	}
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		if pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+			continue
+		}
+		markSpec(&pod.Spec)
+	}
+
+	var deployments appsv1.DeploymentList
+	var statefulSets appsv1.StatefulSetList
+	var daemonSets appsv1.DaemonSetList
+	var jobs batchv1.JobList
+	var cronJobs batchv1.CronJobList
+	for _, list := range []client.ObjectList{&deployments, &statefulSets, &daemonSets, &jobs, &cronJobs} {
+		if err := r.podReader().List(ctx, list, client.InNamespace(namespace)); err != nil {
+			return nil, fmt.Errorf("list workloads in namespace %q: %w", namespace, err)
+		}
+	}
+	for i := range deployments.Items {
+		markSpec(&deployments.Items[i].Spec.Template.Spec)
+	}
+	for i := range statefulSets.Items {
+		markSpec(&statefulSets.Items[i].Spec.Template.Spec)
+	}
+	for i := range daemonSets.Items {
+		markSpec(&daemonSets.Items[i].Spec.Template.Spec)
+	}
+	for i := range jobs.Items {
+		if job := &jobs.Items[i]; job.Status.CompletionTime == nil {
+			markSpec(&job.Spec.Template.Spec)
+		}
+	}
+	for i := range cronJobs.Items {
+		markSpec(&cronJobs.Items[i].Spec.JobTemplate.Spec.Template.Spec)
+	}
+	return inUse, nil
+}
+
+// podSecretNames lists the Secrets a pod mounts or reads from the environment.
+func podSecretNames(spec *corev1.PodSpec) []string {
+	var names []string
+
+	for _, v := range spec.Volumes {
+		if v.Secret != nil {
+			names = append(names, v.Secret.SecretName)
+		}
+		if v.Projected != nil {
+			for _, src := range v.Projected.Sources {
+				if src.Secret != nil {
+					names = append(names, src.Secret.Name)
+				}
+			}
+		}
+	}
+
+	containerSecrets := func(envFrom []corev1.EnvFromSource, env []corev1.EnvVar) {
+		for _, ef := range envFrom {
+			if ef.SecretRef != nil {
+				names = append(names, ef.SecretRef.Name)
+			}
+		}
+		for _, e := range env {
+			if e.ValueFrom != nil && e.ValueFrom.SecretKeyRef != nil {
+				names = append(names, e.ValueFrom.SecretKeyRef.Name)
+			}
+		}
+	}
+	for _, c := range spec.InitContainers {
+		containerSecrets(c.EnvFrom, c.Env)
+	}
+	for _, c := range spec.Containers {
+		containerSecrets(c.EnvFrom, c.Env)
+	}
+	for _, c := range spec.EphemeralContainers {
+		containerSecrets(c.EnvFrom, c.Env)
+	}
+
+	return names
+}
+
+// podReader returns the reader used to list pods and workloads. They are read
+// straight from the API server so the operator does not cache every pod in
+// the cluster.
+func (r *GSMSecretReconciler) podReader() client.Reader {
+	if r.APIReader != nil {
+		return r.APIReader
+	}
+	return r.Client
+}
@@ -0,0 +1,299 @@
+/*
+Copyright 2025 Zera Holladay.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+
+	secretspizecomv1alpha1 "github.com/zeraholladay/gsm-operator/api/v1alpha1"
+)
+
+func newImmutableGSMSecret(retain *int32) *secretspizecomv1alpha1.GSMSecret {
+	return &secretspizecomv1alpha1.GSMSecret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "app",
+			Namespace: "default",
+			UID:       types.UID("app-uid"),
+		},
+		Spec: secretspizecomv1alpha1.GSMSecretSpec{
+			TargetSecret: secretspizecomv1alpha1.GSMSecretTargetSecret{
+				Name:              "app-config",
+				Immutable:         true,
+				RetainGenerations: retain,
+			},
+			Secrets: []secretspizecomv1alpha1.GSMSecretEntry{{Key: "K", ProjectID: "p", SecretID: "s", Version: "1"}},
+		},
+	}
+}
+
+func newGenerationSecret(name string, age time.Duration) *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              name,
+			Namespace:         "default",
+			Labels:            map[string]string{labelOwnerUID: "app-uid"},
+			CreationTimestamp: metav1.NewTime(time.Now().Add(-age)),
+		},
+		Immutable: ptr.To(true),
+	}
+}
+
+func TestImmutableSecretName_Deterministic(t *testing.T) {
+	a := immutableSecretName("app", corev1.SecretTypeOpaque, map[string][]byte{"A": []byte("1"), "B": []byte("2")})
+	b := immutableSecretName("app", corev1.SecretTypeOpaque, map[string][]byte{"B": []byte("2"), "A": []byte("1")})
+
+	if a != b {
+		t.Errorf("expected identical names for identical data, got %q and %q", a, b)
+	}
+	if !strings.HasPrefix(a, "app-") || len(a) != len("app-")+immutableHashLength {
+		t.Errorf("unexpected name format %q", a)
+	}
+}
+
+func TestImmutableSecretName_ChangesWithContent(t *testing.T) {
+	base := immutableSecretName("app", corev1.SecretTypeOpaque, map[string][]byte{"A": []byte("1")})
+
+	if got := immutableSecretName("app", corev1.SecretTypeOpaque, map[string][]byte{"A": []byte("2")}); got == base {
+		t.Error("expected name to change when a value changes")
+	}
+	if got := immutableSecretName("app", corev1.SecretTypeOpaque, map[string][]byte{"B": []byte("1")}); got == base {
+		t.Error("expected name to change when a key changes")
+	}
+	if got := immutableSecretName("app", corev1.SecretTypeTLS, map[string][]byte{"A": []byte("1")}); got == base {
+		t.Error("expected name to change when the type changes")
+	}
+	// Field boundaries must be part of the hash.
+	if immutableSecretName("app", corev1.SecretTypeOpaque, map[string][]byte{"ab": []byte("c")}) ==
+		immutableSecretName("app", corev1.SecretTypeOpaque, map[string][]byte{"a": []byte("bc")}) {
+		t.Error("expected different names for different key/value boundaries")
+	}
+}
+
+func TestBuildOpaqueSecret_Immutable(t *testing.T) {
+	gsmSecret := newImmutableGSMSecret(nil)
+	m := &secretMaterializer{
+		gsmSecret: gsmSecret,
+		payloads:  []keyedSecretPayload{newTestPayload(t, "K", []byte("v"))},
+	}
+
+	secret, err := m.buildOpaqueSecret(context.Background())
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if secret.Name == "app-config" || !strings.HasPrefix(secret.Name, "app-config-") {
+		t.Errorf("expected hashed name, got %q", secret.Name)
+	}
+	if secret.Immutable == nil || !*secret.Immutable {
+		t.Error("expected Secret to be immutable")
+	}
+	if secret.Labels[labelOwnerUID] != "app-uid" {
+		t.Errorf("expected owner UID label, got %v", secret.Labels)
+	}
+}
+
+func TestPruneSecretGenerations_KeepsRetentionCount(t *testing.T) {
+	gsmSecret := newImmutableGSMSecret(ptr.To[int32](1))
+	current := newGenerationSecret("app-config-current", 0)
+	newer := newGenerationSecret("app-config-newer", time.Hour)
+	older := newGenerationSecret("app-config-older", 2*time.Hour)
+	oldest := newGenerationSecret("app-config-oldest", 3*time.Hour)
+
+	r := newTestReconciler(gsmSecret, current, newer, older, oldest)
+	ctx := context.Background()
+
+	if err := r.pruneSecretGenerations(ctx, gsmSecret, current.Name); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	for name, wantExists := range map[string]bool{
+		"app-config-current": true,
+		"app-config-newer":   true,
+		"app-config-older":   false,
+		"app-config-oldest":  false,
+	} {
+		var s corev1.Secret
+		err := r.Get(ctx, types.NamespacedName{Name: name, Namespace: "default"}, &s)
+		if wantExists && err != nil {
+			t.Errorf("expected %s to be kept, got %v", name, err)
+		}
+		if !wantExists && !apierrors.IsNotFound(err) {
+			t.Errorf("expected %s to be pruned, got %v", name, err)
+		}
+	}
+}
+
+func TestPruneSecretGenerations_SkipsSecretsInUse(t *testing.T) {
+	gsmSecret := newImmutableGSMSecret(ptr.To[int32](0))
+	current := newGenerationSecret("app-config-current", 0)
+	mounted := newGenerationSecret("app-config-mounted", time.Hour)
+	envRef := newGenerationSecret("app-config-env", 2*time.Hour)
+	finished := newGenerationSecret("app-config-finished", 3*time.Hour)
+
+	runningPod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"},
+		Spec: corev1.PodSpec{
+			Volumes: []corev1.Volume{{
+				Name:         "cfg",
+				VolumeSource: corev1.VolumeSource{Secret: &corev1.SecretVolumeSource{SecretName: "app-config-mounted"}},
+			}},
+			Containers: []corev1.Container{{
+				Name:    "web",
+				EnvFrom: []corev1.EnvFromSource{{SecretRef: &corev1.SecretEnvSource{LocalObjectReference: corev1.LocalObjectReference{Name: "app-config-env"}}}},
+			}},
+		},
+		Status: corev1.PodStatus{Phase: corev1.PodRunning},
+	}
+	completedPod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "job", Namespace: "default"},
+		Spec: corev1.PodSpec{
+			Volumes: []corev1.Volume{{
+				Name:         "cfg",
+				VolumeSource: corev1.VolumeSource{Secret: &corev1.SecretVolumeSource{SecretName: "app-config-finished"}},
+			}},
+		},
+		Status: corev1.PodStatus{Phase: corev1.PodSucceeded},
+	}
+
+	r := newTestReconciler(gsmSecret, current, mounted, envRef, finished, runningPod, completedPod)
+	ctx := context.Background()
+
+	if err := r.pruneSecretGenerations(ctx, gsmSecret, current.Name); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	var s corev1.Secret
+	for _, name := range []string{"app-config-current", "app-config-mounted", "app-config-env"} {
+		if err := r.Get(ctx, types.NamespacedName{Name: name, Namespace: "default"}, &s); err != nil {
+			t.Errorf("expected %s to be kept, got %v", name, err)
+		}
+	}
+	if err := r.Get(ctx, types.NamespacedName{Name: "app-config-finished", Namespace: "default"}, &s); !apierrors.IsNotFound(err) {
+		t.Errorf("expected Secret used only by a completed pod to be pruned, got %v", err)
+	}
+}
+
+func TestPruneSecretGenerations_SkipsSecretsInPodTemplates(t *testing.T) {
+	gsmSecret := newImmutableGSMSecret(ptr.To[int32](0))
+	current := newGenerationSecret("app-config-current", 0)
+	deployed := newGenerationSecret("app-config-deployed", time.Hour)
+	scheduled := newGenerationSecret("app-config-scheduled", 2*time.Hour)
+	unused := newGenerationSecret("app-config-unused", 3*time.Hour)
+
+	volume := func(name string) corev1.PodSpec {
+		return corev1.PodSpec{Volumes: []corev1.Volume{{
+			Name:         "cfg",
+			VolumeSource: corev1.VolumeSource{Secret: &corev1.SecretVolumeSource{SecretName: name}},
+		}}}
+	}
+	// Scaled to zero, so no pod references the Secret yet.
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: ptr.To[int32](0),
+			Template: corev1.PodTemplateSpec{Spec: volume("app-config-deployed")},
+		},
+	}
+	cronJob := &batchv1.CronJob{
+		ObjectMeta: metav1.ObjectMeta{Name: "nightly", Namespace: "default"},
+		Spec: batchv1.CronJobSpec{
+			Schedule: "0 0 * * *",
+			JobTemplate: batchv1.JobTemplateSpec{Spec: batchv1.JobSpec{
+				Template: corev1.PodTemplateSpec{Spec: volume("app-config-scheduled")},
+			}},
+		},
+	}
+
+	r := newTestReconciler(gsmSecret, current, deployed, scheduled, unused, deployment, cronJob)
+	ctx := context.Background()
+
+	if err := r.pruneSecretGenerations(ctx, gsmSecret, current.Name); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	var s corev1.Secret
+	for _, name := range []string{"app-config-current", "app-config-deployed", "app-config-scheduled"} {
+		if err := r.Get(ctx, types.NamespacedName{Name: name, Namespace: "default"}, &s); err != nil {
+			t.Errorf("expected %s to be kept, got %v", name, err)
+		}
+	}
+	if err := r.Get(ctx, types.NamespacedName{Name: "app-config-unused", Namespace: "default"}, &s); !apierrors.IsNotFound(err) {
+		t.Errorf("expected unreferenced Secret to be pruned, got %v", err)
+	}
+}
+
+func TestPruneSecretGenerations_IgnoresMutableAndForeignSecrets(t *testing.T) {
+	gsmSecret := newImmutableGSMSecret(ptr.To[int32](0))
+	current := newGenerationSecret("app-config-current", 0)
+	mutable := newGenerationSecret("app-config-mutable", time.Hour)
+	mutable.Immutable = nil
+	foreign := newGenerationSecret("other-abc", time.Hour)
+
+	r := newTestReconciler(gsmSecret, current, mutable, foreign)
+	ctx := context.Background()
+
+	if err := r.pruneSecretGenerations(ctx, gsmSecret, current.Name); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	var s corev1.Secret
+	for _, name := range []string{"app-config-mutable", "other-abc"} {
+		if err := r.Get(ctx, types.NamespacedName{Name: name, Namespace: "default"}, &s); err != nil {
+			t.Errorf("expected %s to be kept, got %v", name, err)
+		}
+	}
+}
+
+func TestPodSecretNames(t *testing.T) {
+	spec := &corev1.PodSpec{
+		Volumes: []corev1.Volume{
+			{VolumeSource: corev1.VolumeSource{Secret: &corev1.SecretVolumeSource{SecretName: "vol"}}},
+			{VolumeSource: corev1.VolumeSource{Projected: &corev1.ProjectedVolumeSource{
+				Sources: []corev1.VolumeProjection{{Secret: &corev1.SecretProjection{LocalObjectReference: corev1.LocalObjectReference{Name: "projected"}}}},
+			}}},
+		},
+		InitContainers: []corev1.Container{{
+			Env: []corev1.EnvVar{{Name: "X", ValueFrom: &corev1.EnvVarSource{
+				SecretKeyRef: &corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "init-env"}, Key: "k"},
+			}}},
+		}},
+		Containers: []corev1.Container{{
+			EnvFrom: []corev1.EnvFromSource{{SecretRef: &corev1.SecretEnvSource{LocalObjectReference: corev1.LocalObjectReference{Name: "env-from"}}}},
+		}},
+	}
+
+	got := map[string]bool{}
+	for _, n := range podSecretNames(spec) {
+		got[n] = true
+	}
+	for _, want := range []string{"vol", "projected", "init-env", "env-from"} {
+		if !got[want] {
+			t.Errorf("expected %q in referenced Secrets, got %v", want, got)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+/*
+Copyright 2025 Zera Holladay.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+
+	secretspizecomv1alpha1 "github.com/zeraholladay/gsm-operator/api/v1alpha1"
+)
+
+// checkApprovedKSA returns an error unless the KSA the controller would
+// request tokens for matches the one recorded by the admission webhook.
+// The KSA can change without the GSMSecret changing (for example, a store's
+// serviceAccountName is edited), so the approval is compared on every
+// reconcile rather than trusted once.
+func checkApprovedKSA(
+	gsmSecret *secretspizecomv1alpha1.GSMSecret,
+	store *secretspizecomv1alpha1.GSMSecretStoreSpec,
+) error {
+	m := &secretMaterializer{gsmSecret: gsmSecret, store: store}
+	if m.authMode() != secretspizecomv1alpha1.AuthModeWorkloadIdentityFederation {
+		// Only Workload Identity Federation mints tenant KSA tokens.
+		return nil
+	}
+
+	ksa := m.getKSA()
+	approved := gsmSecret.GetAnnotations()[secretspizecomv1alpha1.AnnotationApprovedKSA]
+	switch approved {
+	case "":
+		return fmt.Errorf("ServiceAccount %q has not been approved for this GSMSecret; apply it through the admission webhook", ksa)
+	case ksa:
+		return nil
+	default:
+		return fmt.Errorf("ServiceAccount %q does not match approved ServiceAccount %q; re-apply the GSMSecret to approve it", ksa, approved)
+	}
+}
@@ -0,0 +1,119 @@
+/*
+Copyright 2025 Zera Holladay.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	secretspizecomv1alpha1 "github.com/zeraholladay/gsm-operator/api/v1alpha1"
+)
+
+func TestCheckApprovedKSA(t *testing.T) {
+	t.Setenv("MODE", "")
+	t.Setenv("KSA", "")
+
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		store       *secretspizecomv1alpha1.GSMSecretStoreSpec
+		wantErr     string
+	}{
+		{
+			name:        "approved annotation KSA",
+			annotations: map[string]string{secretspizecomv1alpha1.AnnotationKSA: "reader", secretspizecomv1alpha1.AnnotationApprovedKSA: "reader"},
+		},
+		{
+			name:        "approved default KSA",
+			annotations: map[string]string{secretspizecomv1alpha1.AnnotationApprovedKSA: "default"},
+		},
+		{
+			name:        "not approved",
+			annotations: map[string]string{secretspizecomv1alpha1.AnnotationKSA: "reader"},
+			wantErr:     `ServiceAccount "reader" has not been approved`,
+		},
+		{
+			name:        "KSA changed after approval",
+			annotations: map[string]string{secretspizecomv1alpha1.AnnotationKSA: "admin", secretspizecomv1alpha1.AnnotationApprovedKSA: "reader"},
+			wantErr:     `ServiceAccount "admin" does not match approved ServiceAccount "reader"`,
+		},
+		{
+			name:        "store KSA changed after approval",
+			annotations: map[string]string{secretspizecomv1alpha1.AnnotationApprovedKSA: "reader"},
+			store:       &secretspizecomv1alpha1.GSMSecretStoreSpec{ServiceAccountName: "admin"},
+			wantErr:     `does not match approved ServiceAccount "reader"`,
+		},
+		{
+			name:  "trusted subsystem store needs no approval",
+			store: &secretspizecomv1alpha1.GSMSecretStoreSpec{AuthMode: secretspizecomv1alpha1.AuthModeTrustedSubsystem},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gsm := newStoreGSMSecret("team", "app", nil)
+			gsm.Annotations = tt.annotations
+			err := checkApprovedKSA(gsm, tt.store)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestReconcile_KSANotApproved(t *testing.T) {
+	t.Setenv("REQUIRE_KSA_APPROVAL", "true")
+	t.Setenv("MODE", "")
+	t.Setenv("KSA", "")
+
+	gsm := newStoreGSMSecret("team", "app", nil)
+	gsm.Annotations = map[string]string{secretspizecomv1alpha1.AnnotationKSA: "admin"}
+	c := newStoreTestClient(gsm)
+	recorder := record.NewFakeRecorder(10)
+	r := &GSMSecretReconciler{Client: c, Scheme: c.Scheme(), Recorder: recorder}
+
+	if _, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "team", Name: "app"}}); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	var got secretspizecomv1alpha1.GSMSecret
+	if err := c.Get(context.Background(), types.NamespacedName{Namespace: "team", Name: "app"}, &got); err != nil {
+		t.Fatalf("failed to get GSMSecret: %v", err)
+	}
+	if len(got.Status.Conditions) != 3 || got.Status.Conditions[0].Reason != "KSANotApproved" {
+		t.Fatalf("expected KSANotApproved condition, got %+v", got.Status.Conditions)
+	}
+	select {
+	case e := <-recorder.Events:
+		if !strings.HasPrefix(e, "Warning KSANotApproved") {
+			t.Errorf("expected KSANotApproved warning event, got %q", e)
+		}
+	default:
+		t.Error("expected a KSANotApproved event")
+	}
+}
@@ -0,0 +1,159 @@
+/*
+Copyright 2025 Zera Holladay.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	corev1ac "k8s.io/client-go/applyconfigurations/core/v1"
+	metav1ac "k8s.io/client-go/applyconfigurations/meta/v1"
+	"k8s.io/client-go/util/csaupgrade"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	secretspizecomv1alpha1 "github.com/zeraholladay/gsm-operator/api/v1alpha1"
+)
+
+const (
+	// fieldManager is the field manager target Secrets are applied with. The
+	// operator owns only the fields it applies: its data keys, its labels and
+	// annotations, and its owner reference.
+	fieldManager = "gsm-operator"
+
+	// legacyFieldManager is the field manager the API server recorded for the
+	// Updates of earlier versions, taken from the "manager" binary's user agent.
+	legacyFieldManager = "manager"
+
+	// reasonApplyConflict is the status reason of a sync that failed because
+	// another field manager owns a field the operator applies.
+	reasonApplyConflict = "ApplyConflict"
+)
+
+// secretApplyConfiguration returns the apply configuration of desired,
+// carrying only the fields the operator owns.
+func secretApplyConfiguration(desired *corev1.Secret) *corev1ac.SecretApplyConfiguration {
+	ac := corev1ac.Secret(desired.Name, desired.Namespace).
+		WithType(desired.Type).
+		WithData(desired.Data)
+	if len(desired.Labels) > 0 {
+		ac.WithLabels(desired.Labels)
+	}
+	if len(desired.Annotations) > 0 {
+		ac.WithAnnotations(desired.Annotations)
+	}
+	if desired.Immutable != nil {
+		ac.WithImmutable(*desired.Immutable)
+	}
+	for _, ref := range desired.OwnerReferences {
+		refAC := metav1ac.OwnerReference().
+			WithAPIVersion(ref.APIVersion).
+			WithKind(ref.Kind).
+			WithName(ref.Name).
+			WithUID(ref.UID)
+		if ref.Controller != nil {
+			refAC.WithController(*ref.Controller)
+		}
+		if ref.BlockOwnerDeletion != nil {
+			refAC.WithBlockOwnerDeletion(*ref.BlockOwnerDeletion)
+		}
+		ac.WithOwnerReferences(refAC)
+	}
+	return ac
+}
+
+// upgradeLegacyFieldManager hands the fields an earlier version wrote with
+// Update over to fieldManager, so the first apply neither conflicts with the
+// operator's own old writes nor leaves keys it no longer sets behind.
+func (r *GSMSecretReconciler) upgradeLegacyFieldManager(ctx context.Context, existing *corev1.Secret) error {
+	patch, err := csaupgrade.UpgradeManagedFieldsPatch(existing, sets.New(legacyFieldManager), fieldManager)
+	if err != nil {
+		return fmt.Errorf("upgrade managed fields of Secret %s/%s: %w", existing.Namespace, existing.Name, err)
+	}
+	if patch == nil {
+		return nil
+	}
+	return r.Patch(ctx, existing, client.RawPatch(types.JSONPatchType, patch))
+}
+
+// ownedDataKeys returns the data keys of secret the operator owns: those
+// fieldManager applied, or those an earlier version wrote with Update as
+// legacyFieldManager. ok is false when neither manager owns any field.
+func ownedDataKeys(secret *corev1.Secret) (owned map[string]bool, ok bool) {
+	for _, entry := range secret.ManagedFields {
+		applied := entry.Manager == fieldManager && entry.Operation == metav1.ManagedFieldsOperationApply
+		legacy := entry.Manager == legacyFieldManager && entry.Operation == metav1.ManagedFieldsOperationUpdate
+		if !applied && !legacy || entry.FieldsV1 == nil {
+			continue
+		}
+		var fields struct {
+			Data map[string]json.RawMessage `json:"f:data"`
+		}
+		if err := json.Unmarshal(entry.FieldsV1.Raw, &fields); err != nil {
+			return nil, false
+		}
+		if owned == nil {
+			owned = map[string]bool{}
+		}
+		for k := range fields.Data {
+			if key, found := strings.CutPrefix(k, "f:"); found {
+				owned[key] = true
+			}
+		}
+	}
+	return owned, owned != nil
+}
+
+// appliedData returns the data of secret the operator applied: the keys it
+// owns. Keys other managers added are left out, so they do not make the
+// content hash look tampered with. A Secret without managed fields of the
+// operator is returned whole.
+func appliedData(secret *corev1.Secret) map[string][]byte {
+	owned, ok := ownedDataKeys(secret)
+	if !ok {
+		return secret.Data
+	}
+	data := make(map[string][]byte, len(owned))
+	for k, v := range secret.Data {
+		if owned[k] {
+			data[k] = v
+		}
+	}
+	return data
+}
+
+// applyConflictResult reports an apply that conflicted with another field
+// manager. Backing off does not help until the other manager lets go or
+// targetSecret.forceConflicts is set, so the apply is retried at the resync
+// interval instead.
+func (r *GSMSecretReconciler) applyConflictResult(ctx context.Context, gsmSecret *secretspizecomv1alpha1.GSMSecret, err error) (ctrl.Result, error) {
+	log := logf.FromContext(ctx)
+	log.Info("another field manager owns fields of the target Secret", "reason", err.Error())
+	r.recordEvent(gsmSecret, corev1.EventTypeWarning, reasonApplyConflict, err.Error())
+	if statusErr := r.setStatusCondition(ctx, gsmSecret, metav1.ConditionFalse, reasonApplyConflict, err.Error()); statusErr != nil {
+		log.Error(statusErr, "failed to update status after apply conflict")
+		return ctrl.Result{}, statusErr
+	}
+	return ctrl.Result{RequeueAfter: resyncAfter()}, nil
+}
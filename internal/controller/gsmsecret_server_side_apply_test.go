@@ -0,0 +1,233 @@
+/*
+Copyright 2025 Zera Holladay.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+
+	secretspizecomv1alpha1 "github.com/zeraholladay/gsm-operator/api/v1alpha1"
+)
+
+// legacyManagedFields returns the managed fields an Update by an earlier
+// version of the operator recorded for a Secret holding keys.
+func legacyManagedFields(keys ...string) []metav1.ManagedFieldsEntry {
+	var data []string
+	for _, k := range keys {
+		data = append(data, fmt.Sprintf(`"f:%s":{}`, k))
+	}
+	return []metav1.ManagedFieldsEntry{{
+		Manager:    legacyFieldManager,
+		Operation:  metav1.ManagedFieldsOperationUpdate,
+		APIVersion: "v1",
+		FieldsType: "FieldsV1",
+		FieldsV1:   &metav1.FieldsV1{Raw: []byte(`{"f:data":{".":{},` + strings.Join(data, ",") + `},"f:type":{}}`)},
+	}}
+}
+
+// ssaOwner returns a GSMSecret syncing into the Secret "app" in "default".
+func ssaOwner(force bool) *secretspizecomv1alpha1.GSMSecret {
+	return &secretspizecomv1alpha1.GSMSecret{
+		ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "default", UID: "owner-uid"},
+		Spec: secretspizecomv1alpha1.GSMSecretSpec{
+			TargetSecret: secretspizecomv1alpha1.GSMSecretTargetSecret{Name: "app", ForceConflicts: force},
+		},
+	}
+}
+
+func ssaDesired(data map[string][]byte) *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "default"},
+		Type:       corev1.SecretTypeOpaque,
+		Data:       data,
+	}
+}
+
+// fieldOwners returns the managers recorded in s's managed fields.
+func fieldOwners(s *corev1.Secret) []string {
+	var managers []string
+	for _, f := range s.ManagedFields {
+		managers = append(managers, f.Manager)
+	}
+	return managers
+}
+
+func TestApplySecret_LeavesOtherManagersFieldsAlone(t *testing.T) {
+	owner := ssaOwner(false)
+	r := newTestReconciler(owner)
+	ctx := context.Background()
+	key := types.NamespacedName{Namespace: "default", Name: "app"}
+
+	if err := r.applySecret(ctx, owner, ssaDesired(map[string][]byte{"DB": []byte("one")})); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Another writer adds its own key and label.
+	var secret corev1.Secret
+	if err := r.Get(ctx, key, &secret); err != nil {
+		t.Fatalf("get secret: %v", err)
+	}
+	secret.Data["EXTRA"] = []byte("theirs")
+	secret.Labels = map[string]string{"team": "payments"}
+	if err := r.Update(ctx, &secret); err != nil {
+		t.Fatalf("update secret: %v", err)
+	}
+
+	if err := r.applySecret(ctx, owner, ssaDesired(map[string][]byte{"DB": []byte("two")})); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := r.Get(ctx, key, &secret); err != nil {
+		t.Fatalf("get secret: %v", err)
+	}
+	if string(secret.Data["DB"]) != "two" {
+		t.Errorf("expected DB to be updated, got %q", secret.Data["DB"])
+	}
+	if string(secret.Data["EXTRA"]) != "theirs" {
+		t.Errorf("expected EXTRA to be left alone, got %q", secret.Data["EXTRA"])
+	}
+	if secret.Labels["team"] != "payments" {
+		t.Errorf("expected team label to be left alone, got %v", secret.Labels)
+	}
+	if !strings.Contains(strings.Join(fieldOwners(&secret), ","), fieldManager) {
+		t.Errorf("expected %q among field managers, got %v", fieldManager, fieldOwners(&secret))
+	}
+}
+
+func TestApplySecret_ConflictAndForce(t *testing.T) {
+	// Another writer already set DB to a different value.
+	existing := ssaDesired(map[string][]byte{"DB": []byte("theirs")})
+	r := newTestReconciler(ssaOwner(false), existing)
+	ctx := context.Background()
+	desired := map[string][]byte{"DB": []byte("ours")}
+
+	err := r.applySecret(ctx, ssaOwner(false), ssaDesired(desired))
+	if !apierrors.IsConflict(err) {
+		t.Fatalf("expected a conflict, got %v", err)
+	}
+
+	if err := r.applySecret(ctx, ssaOwner(true), ssaDesired(desired)); err != nil {
+		t.Fatalf("expected forceConflicts to take the field over, got %v", err)
+	}
+	var secret corev1.Secret
+	if err := r.Get(ctx, types.NamespacedName{Namespace: "default", Name: "app"}, &secret); err != nil {
+		t.Fatalf("get secret: %v", err)
+	}
+	if string(secret.Data["DB"]) != "ours" {
+		t.Errorf("expected DB to be taken over, got %q", secret.Data["DB"])
+	}
+}
+
+func TestApplySecret_UpgradesLegacyFieldManager(t *testing.T) {
+	existing := ssaDesired(map[string][]byte{"DB": []byte("old"), "GONE": []byte("old")})
+	existing.ManagedFields = legacyManagedFields("DB", "GONE")
+	r := newTestReconciler(ssaOwner(false), existing)
+	ctx := context.Background()
+
+	// No conflict with the operator's own earlier writes, and keys it no
+	// longer sets are removed.
+	if err := r.applySecret(ctx, ssaOwner(false), ssaDesired(map[string][]byte{"DB": []byte("new")})); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var secret corev1.Secret
+	if err := r.Get(ctx, types.NamespacedName{Namespace: "default", Name: "app"}, &secret); err != nil {
+		t.Fatalf("get secret: %v", err)
+	}
+	if string(secret.Data["DB"]) != "new" {
+		t.Errorf("expected DB to be updated, got %q", secret.Data["DB"])
+	}
+	if _, ok := secret.Data["GONE"]; ok {
+		t.Error("expected GONE to be removed")
+	}
+	for _, m := range fieldOwners(&secret) {
+		if m == legacyFieldManager {
+			t.Errorf("expected %q to own no fields, got %v", legacyFieldManager, fieldOwners(&secret))
+		}
+	}
+}
+
+func TestApplyConflictResult(t *testing.T) {
+	t.Setenv("RESYNC_JITTER_PERCENT", "0")
+	gsm := ssaOwner(false)
+	r := newTestReconciler(gsm, ssaDesired(map[string][]byte{"DB": []byte("theirs")}))
+	recorder := record.NewFakeRecorder(10)
+	r.Recorder = recorder
+	ctx := context.Background()
+
+	applyErr := r.applySecret(ctx, gsm, ssaDesired(map[string][]byte{"DB": []byte("ours")}))
+	result, err := r.applyConflictResult(ctx, gsm, applyErr)
+	if err != nil || result.RequeueAfter != getResyncInterval() {
+		t.Errorf("expected a requeue at the resync interval, got result=%+v err=%v", result, err)
+	}
+
+	var got secretspizecomv1alpha1.GSMSecret
+	if err := r.Get(ctx, types.NamespacedName{Namespace: "default", Name: "app"}, &got); err != nil {
+		t.Fatalf("get GSMSecret: %v", err)
+	}
+	cond := apimeta.FindStatusCondition(got.Status.Conditions, conditionTypeReady)
+	if cond == nil || cond.Status != metav1.ConditionFalse || cond.Reason != reasonApplyConflict {
+		t.Errorf("expected Ready=False with reason %s, got %+v", reasonApplyConflict, cond)
+	}
+	if events := drainEvents(recorder); len(events) != 1 || !strings.HasPrefix(events[0], "Warning ApplyConflict") {
+		t.Errorf("expected one ApplyConflict warning, got %v", events)
+	}
+}
+
+func TestAppliedData(t *testing.T) {
+	owner := ssaOwner(false)
+	r := newTestReconciler(owner)
+	ctx := context.Background()
+	key := types.NamespacedName{Namespace: "default", Name: "app"}
+
+	if err := r.applySecret(ctx, owner, ssaDesired(map[string][]byte{"DB": []byte("ours")})); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var secret corev1.Secret
+	if err := r.Get(ctx, key, &secret); err != nil {
+		t.Fatalf("get secret: %v", err)
+	}
+	secret.Data["EXTRA"] = []byte("theirs")
+	if err := r.Update(ctx, &secret); err != nil {
+		t.Fatalf("update secret: %v", err)
+	}
+	if err := r.Get(ctx, key, &secret); err != nil {
+		t.Fatalf("get secret: %v", err)
+	}
+
+	// A key added by another writer does not count as tampering.
+	got := appliedData(&secret)
+	if len(got) != 1 || string(got["DB"]) != "ours" {
+		t.Errorf("expected only DB, got %v", got)
+	}
+	if secret.Annotations[annotationContentHash] != secretContentHash(secret.Type, got) {
+		t.Error("expected the content hash to match the applied data")
+	}
+
+	// Without managed fields for the operator, the whole Secret counts.
+	secret.ManagedFields = nil
+	if got := appliedData(&secret); len(got) != 2 {
+		t.Errorf("expected all keys, got %v", got)
+	}
+}
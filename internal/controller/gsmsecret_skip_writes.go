@@ -0,0 +1,85 @@
+/*
+Copyright 2025 Zera Holladay.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"bytes"
+	"maps"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Results of a target Secret apply, the values of the result label of
+// gsm_operator_secret_writes_total.
+const (
+	secretWriteWritten = "written"
+	secretWriteSkipped = "skipped"
+)
+
+// secretUpToDate reports whether existing already holds everything desired
+// applies: its type, the data keys the operator owns, its labels and
+// annotations, immutability and owner references. Applying such a Secret
+// would not change it, so the write is skipped.
+func secretUpToDate(existing, desired *corev1.Secret) bool {
+	if existing.Type != desired.Type {
+		return false
+	}
+	if !maps.EqualFunc(appliedData(existing), desired.Data, bytes.Equal) {
+		return false
+	}
+	if !hasEntries(existing.Labels, desired.Labels) || !hasEntries(existing.Annotations, desired.Annotations) {
+		return false
+	}
+	if desired.Immutable != nil && (existing.Immutable == nil || *existing.Immutable != *desired.Immutable) {
+		return false
+	}
+	for _, ref := range desired.OwnerReferences {
+		if !hasOwnerReference(existing, ref) {
+			return false
+		}
+	}
+	return true
+}
+
+// hasEntries reports whether every entry of want is in got.
+func hasEntries(got, want map[string]string) bool {
+	for k, v := range want {
+		if current, ok := got[k]; !ok || current != v {
+			return false
+		}
+	}
+	return true
+}
+
+// hasOwnerReference reports whether secret carries ref with the same
+// controller and blockOwnerDeletion settings.
+func hasOwnerReference(secret *corev1.Secret, ref metav1.OwnerReference) bool {
+	for _, existing := range secret.OwnerReferences {
+		if existing.UID == ref.UID {
+			return existing.APIVersion == ref.APIVersion && existing.Kind == ref.Kind && existing.Name == ref.Name &&
+				boolPtrEqual(existing.Controller, ref.Controller) &&
+				boolPtrEqual(existing.BlockOwnerDeletion, ref.BlockOwnerDeletion)
+		}
+	}
+	return false
+}
+
+// boolPtrEqual compares two optional bools, treating nil as false.
+func boolPtrEqual(a, b *bool) bool {
+	return (a != nil && *a) == (b != nil && *b)
+}
@@ -0,0 +1,139 @@
+/*
+Copyright 2025 Zera Holladay.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+)
+
+func TestApplySecret_SkipsUnchangedSecret(t *testing.T) {
+	owner := ssaOwner(false)
+	r := newTestReconciler(owner)
+	ctx := context.Background()
+	key := types.NamespacedName{Namespace: "default", Name: "app"}
+	data := map[string][]byte{"DB": []byte("one")}
+
+	written := testutil.ToFloat64(secretWrites.WithLabelValues(secretWriteWritten))
+	skipped := testutil.ToFloat64(secretWrites.WithLabelValues(secretWriteSkipped))
+
+	if err := r.applySecret(ctx, owner, ssaDesired(data)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var before corev1.Secret
+	if err := r.Get(ctx, key, &before); err != nil {
+		t.Fatalf("get secret: %v", err)
+	}
+
+	if err := r.applySecret(ctx, owner, ssaDesired(data)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var after corev1.Secret
+	if err := r.Get(ctx, key, &after); err != nil {
+		t.Fatalf("get secret: %v", err)
+	}
+	if after.ResourceVersion != before.ResourceVersion {
+		t.Errorf("expected no write, resourceVersion went from %s to %s", before.ResourceVersion, after.ResourceVersion)
+	}
+
+	// A changed value is written again.
+	if err := r.applySecret(ctx, owner, ssaDesired(map[string][]byte{"DB": []byte("two")})); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got := testutil.ToFloat64(secretWrites.WithLabelValues(secretWriteWritten)) - written; got != 2 {
+		t.Errorf("expected 2 written applies, got %v", got)
+	}
+	if got := testutil.ToFloat64(secretWrites.WithLabelValues(secretWriteSkipped)) - skipped; got != 1 {
+		t.Errorf("expected 1 skipped apply, got %v", got)
+	}
+}
+
+func TestSecretUpToDate(t *testing.T) {
+	owner := ssaOwner(false)
+	r := newTestReconciler(owner)
+	ctx := context.Background()
+	desired := func() *corev1.Secret {
+		d := ssaDesired(map[string][]byte{"DB": []byte("one")})
+		d.Labels = map[string]string{"app": "web"}
+		return d
+	}
+	if err := r.applySecret(ctx, owner, desired()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var existing corev1.Secret
+	if err := r.Get(ctx, types.NamespacedName{Namespace: "default", Name: "app"}, &existing); err != nil {
+		t.Fatalf("get secret: %v", err)
+	}
+	// applySecret sets the owner reference and content hash on desired first.
+	want := desired()
+	want.OwnerReferences = existing.OwnerReferences
+	want.Annotations = existing.Annotations
+
+	tests := []struct {
+		name   string
+		mutate func(existing, desired *corev1.Secret)
+		want   bool
+	}{
+		{name: "identical", want: true},
+		{
+			name:   "keys and labels of other managers",
+			mutate: func(e, _ *corev1.Secret) { e.Data["EXTRA"] = []byte("theirs"); e.Labels["team"] = "payments" },
+			want:   true,
+		},
+		{
+			name:   "value changed",
+			mutate: func(_, d *corev1.Secret) { d.Data["DB"] = []byte("two") },
+		},
+		{
+			name:   "key removed",
+			mutate: func(_, d *corev1.Secret) { delete(d.Data, "DB") },
+		},
+		{
+			name:   "type changed",
+			mutate: func(_, d *corev1.Secret) { d.Type = corev1.SecretTypeDockerConfigJson },
+		},
+		{
+			name:   "label changed",
+			mutate: func(_, d *corev1.Secret) { d.Labels["app"] = "api" },
+		},
+		{
+			name:   "owner reference missing",
+			mutate: func(e, _ *corev1.Secret) { e.OwnerReferences = nil },
+		},
+		{
+			name:   "made immutable",
+			mutate: func(_, d *corev1.Secret) { d.Immutable = ptr.To(true) },
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, d := existing.DeepCopy(), want.DeepCopy()
+			if tt.mutate != nil {
+				tt.mutate(e, d)
+			}
+			if got := secretUpToDate(e, d); got != tt.want {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,104 @@
+/*
+Copyright 2025 Zera Holladay.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	secretspizecomv1alpha1 "github.com/zeraholladay/gsm-operator/api/v1alpha1"
+)
+
+// storeRefKind returns the kind of store ref points at, defaulting to the
+// namespaced GSMSecretStore.
+func storeRefKind(ref *secretspizecomv1alpha1.GSMSecretStoreRef) string {
+	if ref.Kind == "" {
+		return secretspizecomv1alpha1.GSMSecretStoreKind
+	}
+	return ref.Kind
+}
+
+// resolveStore returns the validated spec of the store referenced by the
+// GSMSecret, or nil when it has no storeRef.
+func (r *GSMSecretReconciler) resolveStore(
+	ctx context.Context,
+	gsmSecret *secretspizecomv1alpha1.GSMSecret,
+) (*secretspizecomv1alpha1.GSMSecretStoreSpec, error) {
+	ref := gsmSecret.Spec.StoreRef
+	if ref == nil {
+		return nil, nil
+	}
+
+	kind := storeRefKind(ref)
+	var spec *secretspizecomv1alpha1.GSMSecretStoreSpec
+	switch kind {
+	case secretspizecomv1alpha1.ClusterGSMSecretStoreKind:
+		var store secretspizecomv1alpha1.ClusterGSMSecretStore
+		if err := r.Get(ctx, types.NamespacedName{Name: ref.Name}, &store); err != nil {
+			return nil, fmt.Errorf("get %s %q: %w", kind, ref.Name, err)
+		}
+		spec = &store.Spec
+	case secretspizecomv1alpha1.GSMSecretStoreKind:
+		var store secretspizecomv1alpha1.GSMSecretStore
+		if err := r.Get(ctx, types.NamespacedName{Namespace: gsmSecret.Namespace, Name: ref.Name}, &store); err != nil {
+			return nil, fmt.Errorf("get %s %q: %w", kind, ref.Name, err)
+		}
+		spec = &store.Spec
+	default:
+		return nil, fmt.Errorf("unsupported store kind %q", kind)
+	}
+
+	if err := validateStoreSpec(spec, kind == secretspizecomv1alpha1.GSMSecretStoreKind); err != nil {
+		return nil, fmt.Errorf("%s %q is not ready: %w", kind, ref.Name, err)
+	}
+	return spec, nil
+}
+
+// mapStoreToGSMSecrets returns a map func that enqueues the GSMSecrets
+// referencing a store of the given kind, so store changes are picked up.
+func (r *GSMSecretReconciler) mapStoreToGSMSecrets(kind string) handler.MapFunc {
+	return func(ctx context.Context, obj client.Object) []reconcile.Request {
+		var opts []client.ListOption
+		if kind == secretspizecomv1alpha1.GSMSecretStoreKind {
+			opts = append(opts, client.InNamespace(obj.GetNamespace()))
+		}
+
+		var list secretspizecomv1alpha1.GSMSecretList
+		if err := r.List(ctx, &list, opts...); err != nil {
+			logf.FromContext(ctx).Error(err, "failed to list GSMSecrets for store", "kind", kind, "store", obj.GetName())
+			return nil
+		}
+
+		var requests []reconcile.Request
+		for _, gsm := range list.Items {
+			ref := gsm.Spec.StoreRef
+			if ref == nil || ref.Name != obj.GetName() || storeRefKind(ref) != kind {
+				continue
+			}
+			requests = append(requests, reconcile.Request{
+				NamespacedName: types.NamespacedName{Namespace: gsm.Namespace, Name: gsm.Name},
+			})
+		}
+		return requests
+	}
+}
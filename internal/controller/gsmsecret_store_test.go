@@ -0,0 +1,577 @@
+/*
+Copyright 2025 Zera Holladay.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	secretspizecomv1alpha1 "github.com/zeraholladay/gsm-operator/api/v1alpha1"
+	"github.com/zeraholladay/gsm-operator/internal/ksaapproval"
+)
+
+const testStoreAudience = "//iam.googleapis.com/projects/123/locations/global/workloadIdentityPools/pool/providers/provider"
+
+func newStoreTestClient(objs ...client.Object) client.Client {
+	return fake.NewClientBuilder().
+		WithScheme(newTestScheme()).
+		WithObjects(objs...).
+		WithStatusSubresource(
+			&secretspizecomv1alpha1.GSMSecret{},
+			&secretspizecomv1alpha1.GSMSecretStore{},
+			&secretspizecomv1alpha1.ClusterGSMSecretStore{},
+		).
+		Build()
+}
+
+func newStoreGSMSecret(namespace, name string, ref *secretspizecomv1alpha1.GSMSecretStoreRef) *secretspizecomv1alpha1.GSMSecret {
+	return &secretspizecomv1alpha1.GSMSecret{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Spec: secretspizecomv1alpha1.GSMSecretSpec{
+			TargetSecret: secretspizecomv1alpha1.GSMSecretTargetSecret{Name: name},
+			Secrets:      []secretspizecomv1alpha1.GSMSecretEntry{{Key: "K", SecretID: "s", Version: "1"}},
+			StoreRef:     ref,
+		},
+	}
+}
+
+// ==================== validateStoreSpec tests ====================
+
+func TestValidateStoreSpec(t *testing.T) {
+	tests := []struct {
+		name    string
+		spec    secretspizecomv1alpha1.GSMSecretStoreSpec
+		wantErr string
+	}{
+		{
+			name: "valid WIF store",
+			spec: secretspizecomv1alpha1.GSMSecretStoreSpec{
+				AuthMode: secretspizecomv1alpha1.AuthModeWorkloadIdentityFederation,
+				Audience: testStoreAudience,
+				Impersonation: &secretspizecomv1alpha1.GSMSecretStoreImpersonation{
+					ServiceAccount: "reader@p-123456.iam.gserviceaccount.com",
+					Delegates:      []string{"broker@p-123456.iam.gserviceaccount.com"},
+					Lifetime:       &metav1.Duration{Duration: time.Hour},
+				},
+			},
+		},
+		{
+			name: "valid trusted subsystem store",
+			spec: secretspizecomv1alpha1.GSMSecretStoreSpec{AuthMode: secretspizecomv1alpha1.AuthModeTrustedSubsystem},
+		},
+		{
+			name:    "WIF without audience",
+			spec:    secretspizecomv1alpha1.GSMSecretStoreSpec{AuthMode: secretspizecomv1alpha1.AuthModeWorkloadIdentityFederation},
+			wantErr: "audience is required",
+		},
+		{
+			name: "trusted subsystem with identity",
+			spec: secretspizecomv1alpha1.GSMSecretStoreSpec{
+				AuthMode:           secretspizecomv1alpha1.AuthModeTrustedSubsystem,
+				ServiceAccountName: "reader",
+			},
+			wantErr: "not used in TrustedSubsystem mode",
+		},
+		{
+			name: "valid credentials secret store",
+			spec: secretspizecomv1alpha1.GSMSecretStoreSpec{
+				AuthMode:             secretspizecomv1alpha1.AuthModeCredentialsSecret,
+				CredentialsSecretRef: &secretspizecomv1alpha1.CredentialsSecretRef{Name: "gcp-key"},
+			},
+		},
+		{
+			name:    "credentials secret mode without ref",
+			spec:    secretspizecomv1alpha1.GSMSecretStoreSpec{AuthMode: secretspizecomv1alpha1.AuthModeCredentialsSecret},
+			wantErr: "credentialsSecretRef is required",
+		},
+		{
+			name: "credentials secret mode with KSA",
+			spec: secretspizecomv1alpha1.GSMSecretStoreSpec{
+				AuthMode:             secretspizecomv1alpha1.AuthModeCredentialsSecret,
+				CredentialsSecretRef: &secretspizecomv1alpha1.CredentialsSecretRef{Name: "gcp-key"},
+				ServiceAccountName:   "reader",
+			},
+			wantErr: "not used in CredentialsSecret mode",
+		},
+		{
+			name: "credentialsSecretRef in WIF mode",
+			spec: secretspizecomv1alpha1.GSMSecretStoreSpec{
+				Audience:             testStoreAudience,
+				CredentialsSecretRef: &secretspizecomv1alpha1.CredentialsSecretRef{Name: "gcp-key"},
+			},
+			wantErr: "only used in CredentialsSecret mode",
+		},
+		{
+			name:    "external account store without operator config",
+			spec:    secretspizecomv1alpha1.GSMSecretStoreSpec{AuthMode: secretspizecomv1alpha1.AuthModeExternalAccount},
+			wantErr: "requires externalAccountConfigRef or the EXTERNAL_ACCOUNT_CONFIG env var",
+		},
+		{
+			name: "externalAccountConfigRef in WIF mode",
+			spec: secretspizecomv1alpha1.GSMSecretStoreSpec{
+				Audience:                 testStoreAudience,
+				ExternalAccountConfigRef: &secretspizecomv1alpha1.ExternalAccountConfigRef{Name: "ext", Namespace: "ops"},
+			},
+			wantErr: "only used in ExternalAccount mode",
+		},
+		{
+			name: "external account store with KSA",
+			spec: secretspizecomv1alpha1.GSMSecretStoreSpec{
+				AuthMode:           secretspizecomv1alpha1.AuthModeExternalAccount,
+				ServiceAccountName: "reader",
+			},
+			wantErr: "not used in ExternalAccount mode",
+		},
+		{
+			name: "invalid delegate",
+			spec: secretspizecomv1alpha1.GSMSecretStoreSpec{
+				Audience: testStoreAudience,
+				Impersonation: &secretspizecomv1alpha1.GSMSecretStoreImpersonation{
+					ServiceAccount: "reader@p-123456.iam.gserviceaccount.com",
+					Delegates:      []string{"broker"},
+				},
+			},
+			wantErr: "impersonation hop 1 of 2",
+		},
+		{
+			name: "lifetime too long",
+			spec: secretspizecomv1alpha1.GSMSecretStoreSpec{
+				Audience: testStoreAudience,
+				Impersonation: &secretspizecomv1alpha1.GSMSecretStoreImpersonation{
+					ServiceAccount: "reader@p-123456.iam.gserviceaccount.com",
+					Lifetime:       &metav1.Duration{Duration: 24 * time.Hour},
+				},
+			},
+			wantErr: "at most 12h",
+		},
+		{
+			name: "token expiration below minimum",
+			spec: secretspizecomv1alpha1.GSMSecretStoreSpec{
+				Audience: testStoreAudience,
+				Timeouts: &secretspizecomv1alpha1.GSMSecretStoreTimeouts{
+					TokenExpiration: &metav1.Duration{Duration: time.Minute},
+				},
+			},
+			wantErr: "tokenExpiration must be at least 10m0s",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateStoreSpec(&tt.spec, false)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestValidateStoreSpec_NamespacedRejectsOperatorIdentity(t *testing.T) {
+	t.Setenv("EXTERNAL_ACCOUNT_CONFIG", "/etc/gsm-operator/external-account.json")
+	for _, mode := range []secretspizecomv1alpha1.GSMSecretStoreAuthMode{
+		secretspizecomv1alpha1.AuthModeTrustedSubsystem,
+		secretspizecomv1alpha1.AuthModeExternalAccount,
+	} {
+		spec := &secretspizecomv1alpha1.GSMSecretStoreSpec{AuthMode: mode}
+		if err := validateStoreSpec(spec, false); err != nil {
+			t.Fatalf("cluster store with %s: unexpected error: %v", mode, err)
+		}
+		err := validateStoreSpec(spec, true)
+		if err == nil || !strings.Contains(err.Error(), "only allowed on a ClusterGSMSecretStore") {
+			t.Fatalf("namespaced store with %s: expected rejection, got %v", mode, err)
+		}
+	}
+}
+
+func TestValidateStoreSpec_Endpoints(t *testing.T) {
+	t.Setenv("WIFAUDIENCE", testStoreAudience)
+	spec := &secretspizecomv1alpha1.GSMSecretStoreSpec{STSEndpoint: "http://sts.example.com"}
+	if err := validateStoreSpec(spec, false); err == nil || !strings.Contains(err.Error(), "stsEndpoint must be an https:// URL") {
+		t.Fatalf("expected plaintext stsEndpoint to be rejected, got %v", err)
+	}
+	spec = &secretspizecomv1alpha1.GSMSecretStoreSpec{IAMCredentialsEndpoint: "http://iam.example.com"}
+	if err := validateStoreSpec(spec, false); err == nil || !strings.Contains(err.Error(), "iamCredentialsEndpoint must be an https:// URL") {
+		t.Fatalf("expected plaintext iamCredentialsEndpoint to be rejected, got %v", err)
+	}
+
+	spec = &secretspizecomv1alpha1.GSMSecretStoreSpec{
+		STSEndpoint:    "https://sts.example.com",
+		UniverseDomain: "example-universe.goog",
+	}
+	if err := validateStoreSpec(spec, false); err != nil {
+		t.Fatalf("cluster store: unexpected error: %v", err)
+	}
+	if err := validateStoreSpec(spec, true); err == nil || !strings.Contains(err.Error(), "only allowed on a ClusterGSMSecretStore") {
+		t.Fatalf("namespaced store: expected endpoint overrides to be rejected, got %v", err)
+	}
+}
+
+func TestValidateStoreSpec_ExternalAccountConfigFromEnv(t *testing.T) {
+	t.Setenv("EXTERNAL_ACCOUNT_CONFIG", "/etc/gsm-operator/external-account.json")
+	spec := &secretspizecomv1alpha1.GSMSecretStoreSpec{AuthMode: secretspizecomv1alpha1.AuthModeExternalAccount}
+	if err := validateStoreSpec(spec, false); err != nil {
+		t.Fatalf("expected EXTERNAL_ACCOUNT_CONFIG env var to satisfy ExternalAccount mode, got %v", err)
+	}
+}
+
+func TestValidateStoreSpec_ExternalAccountConfigRef(t *testing.T) {
+	t.Setenv("EXTERNAL_ACCOUNT_CONFIG", "")
+	spec := &secretspizecomv1alpha1.GSMSecretStoreSpec{
+		AuthMode:                 secretspizecomv1alpha1.AuthModeExternalAccount,
+		ExternalAccountConfigRef: &secretspizecomv1alpha1.ExternalAccountConfigRef{Name: "ext", Namespace: "ops"},
+	}
+	if err := validateStoreSpec(spec, false); err != nil {
+		t.Fatalf("expected externalAccountConfigRef to satisfy ExternalAccount mode, got %v", err)
+	}
+	if err := validateStoreSpec(spec, true); err == nil {
+		t.Fatal("expected a namespaced store to be refused ExternalAccount mode")
+	}
+}
+
+func TestValidateStoreSpec_AudienceFromEnv(t *testing.T) {
+	t.Setenv("WIFAUDIENCE", testStoreAudience)
+	if err := validateStoreSpec(&secretspizecomv1alpha1.GSMSecretStoreSpec{}, false); err != nil {
+		t.Fatalf("expected WIFAUDIENCE env var to satisfy audience, got %v", err)
+	}
+}
+
+// ==================== store reconciler tests ====================
+
+func TestSetStoreReadyCondition_LastTransitionTime(t *testing.T) {
+	past := metav1.NewTime(time.Now().Add(-time.Hour).Truncate(time.Second))
+	status := &secretspizecomv1alpha1.GSMSecretStoreStatus{Conditions: []metav1.Condition{{
+		Type:               conditionTypeReady,
+		Status:             metav1.ConditionFalse,
+		Reason:             "InvalidConfiguration",
+		Message:            "old error",
+		LastTransitionTime: past,
+	}}}
+
+	setStoreReadyCondition(status, 2, errors.New("new error"))
+	ready := apimeta.FindStatusCondition(status.Conditions, conditionTypeReady)
+	if !ready.LastTransitionTime.Equal(&past) || ready.Message != "new error" || ready.ObservedGeneration != 2 {
+		t.Fatalf("expected an unchanged status to keep its transition time, got %+v", ready)
+	}
+
+	setStoreReadyCondition(status, 3, nil)
+	ready = apimeta.FindStatusCondition(status.Conditions, conditionTypeReady)
+	if ready.Status != metav1.ConditionTrue || ready.LastTransitionTime.Equal(&past) {
+		t.Fatalf("expected a status change to move the transition time, got %+v", ready)
+	}
+	if len(status.Conditions) != 1 || status.ObservedGeneration != 3 {
+		t.Fatalf("expected one Ready condition for generation 3, got %+v", status)
+	}
+}
+
+func TestGSMSecretStoreReconcile_SetsReady(t *testing.T) {
+	store := &secretspizecomv1alpha1.GSMSecretStore{
+		ObjectMeta: metav1.ObjectMeta{Name: "store", Namespace: "team", Generation: 2},
+		Spec:       secretspizecomv1alpha1.GSMSecretStoreSpec{Audience: testStoreAudience},
+	}
+	c := newStoreTestClient(store)
+	r := &GSMSecretStoreReconciler{Client: c, Scheme: c.Scheme()}
+
+	if _, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "team", Name: "store"}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var got secretspizecomv1alpha1.GSMSecretStore
+	if err := c.Get(context.Background(), types.NamespacedName{Namespace: "team", Name: "store"}, &got); err != nil {
+		t.Fatalf("failed to get store: %v", err)
+	}
+	if len(got.Status.Conditions) != 1 || got.Status.Conditions[0].Status != metav1.ConditionTrue || got.Status.Conditions[0].Reason != "Valid" {
+		t.Errorf("expected Ready=True/Valid, got %+v", got.Status.Conditions)
+	}
+}
+
+func TestClusterGSMSecretStoreReconcile_SetsNotReady(t *testing.T) {
+	store := &secretspizecomv1alpha1.ClusterGSMSecretStore{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster-store"},
+		Spec:       secretspizecomv1alpha1.GSMSecretStoreSpec{AuthMode: secretspizecomv1alpha1.AuthModeWorkloadIdentityFederation},
+	}
+	c := newStoreTestClient(store)
+	r := &ClusterGSMSecretStoreReconciler{Client: c, Scheme: c.Scheme()}
+
+	if _, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: types.NamespacedName{Name: "cluster-store"}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var got secretspizecomv1alpha1.ClusterGSMSecretStore
+	if err := c.Get(context.Background(), types.NamespacedName{Name: "cluster-store"}, &got); err != nil {
+		t.Fatalf("failed to get store: %v", err)
+	}
+	if len(got.Status.Conditions) != 1 || got.Status.Conditions[0].Status != metav1.ConditionFalse ||
+		got.Status.Conditions[0].Reason != "InvalidConfiguration" ||
+		!strings.Contains(got.Status.Conditions[0].Message, "audience is required") {
+		t.Errorf("expected Ready=False/InvalidConfiguration, got %+v", got.Status.Conditions)
+	}
+}
+
+// ==================== resolveStore tests ====================
+
+func TestResolveStore_NoRef(t *testing.T) {
+	r := &GSMSecretReconciler{Client: newStoreTestClient()}
+	spec, err := r.resolveStore(context.Background(), newStoreGSMSecret("team", "app", nil))
+	if err != nil || spec != nil {
+		t.Fatalf("expected nil store and no error, got %v, %v", spec, err)
+	}
+}
+
+func TestResolveStore_NamespacedAndCluster(t *testing.T) {
+	nsStore := &secretspizecomv1alpha1.GSMSecretStore{
+		ObjectMeta: metav1.ObjectMeta{Name: "shared", Namespace: "team"},
+		Spec:       secretspizecomv1alpha1.GSMSecretStoreSpec{Audience: testStoreAudience, ServiceAccountName: "team-reader"},
+	}
+	clusterStore := &secretspizecomv1alpha1.ClusterGSMSecretStore{
+		ObjectMeta: metav1.ObjectMeta{Name: "shared"},
+		Spec:       secretspizecomv1alpha1.GSMSecretStoreSpec{Audience: testStoreAudience, ServiceAccountName: "cluster-reader"},
+	}
+	r := &GSMSecretReconciler{Client: newStoreTestClient(nsStore, clusterStore)}
+
+	spec, err := r.resolveStore(context.Background(),
+		newStoreGSMSecret("team", "app", &secretspizecomv1alpha1.GSMSecretStoreRef{Name: "shared"}))
+	if err != nil || spec.ServiceAccountName != "team-reader" {
+		t.Fatalf("expected namespaced store by default, got %+v, %v", spec, err)
+	}
+
+	spec, err = r.resolveStore(context.Background(), newStoreGSMSecret("team", "app", &secretspizecomv1alpha1.GSMSecretStoreRef{
+		Kind: secretspizecomv1alpha1.ClusterGSMSecretStoreKind,
+		Name: "shared",
+	}))
+	if err != nil || spec.ServiceAccountName != "cluster-reader" {
+		t.Fatalf("expected cluster store, got %+v, %v", spec, err)
+	}
+}
+
+func TestResolveStore_OtherNamespaceNotVisible(t *testing.T) {
+	store := &secretspizecomv1alpha1.GSMSecretStore{
+		ObjectMeta: metav1.ObjectMeta{Name: "shared", Namespace: "other"},
+		Spec:       secretspizecomv1alpha1.GSMSecretStoreSpec{Audience: testStoreAudience},
+	}
+	r := &GSMSecretReconciler{Client: newStoreTestClient(store)}
+
+	_, err := r.resolveStore(context.Background(),
+		newStoreGSMSecret("team", "app", &secretspizecomv1alpha1.GSMSecretStoreRef{Name: "shared"}))
+	if err == nil {
+		t.Fatal("expected error for a GSMSecretStore in another namespace")
+	}
+}
+
+func TestResolveStore_Invalid(t *testing.T) {
+	store := &secretspizecomv1alpha1.GSMSecretStore{
+		ObjectMeta: metav1.ObjectMeta{Name: "broken", Namespace: "team"},
+	}
+	r := &GSMSecretReconciler{Client: newStoreTestClient(store)}
+
+	_, err := r.resolveStore(context.Background(),
+		newStoreGSMSecret("team", "app", &secretspizecomv1alpha1.GSMSecretStoreRef{Name: "broken"}))
+	if err == nil || !strings.Contains(err.Error(), `GSMSecretStore "broken" is not ready`) {
+		t.Fatalf("expected not-ready error, got %v", err)
+	}
+}
+
+func TestReconcile_StoreNotReadySetsStatus(t *testing.T) {
+	gsm := newStoreGSMSecret("team", "app", &secretspizecomv1alpha1.GSMSecretStoreRef{Name: "missing"})
+	c := newStoreTestClient(gsm)
+	r := &GSMSecretReconciler{Client: c, Scheme: c.Scheme()}
+
+	if _, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "team", Name: "app"}}); err == nil {
+		t.Fatal("expected error for missing store")
+	}
+
+	var got secretspizecomv1alpha1.GSMSecret
+	if err := c.Get(context.Background(), types.NamespacedName{Namespace: "team", Name: "app"}, &got); err != nil {
+		t.Fatalf("failed to get GSMSecret: %v", err)
+	}
+	if len(got.Status.Conditions) != 3 || got.Status.Conditions[0].Reason != "StoreNotReady" {
+		t.Errorf("expected StoreNotReady condition, got %+v", got.Status.Conditions)
+	}
+}
+
+// ==================== mapStoreToGSMSecrets tests ====================
+
+func TestMapStoreToGSMSecrets(t *testing.T) {
+	objs := []client.Object{
+		newStoreGSMSecret("team", "uses-ns-store", &secretspizecomv1alpha1.GSMSecretStoreRef{Name: "shared"}),
+		newStoreGSMSecret("team", "uses-cluster-store", &secretspizecomv1alpha1.GSMSecretStoreRef{
+			Kind: secretspizecomv1alpha1.ClusterGSMSecretStoreKind, Name: "shared",
+		}),
+		newStoreGSMSecret("other", "uses-other-ns-store", &secretspizecomv1alpha1.GSMSecretStoreRef{Name: "shared"}),
+		newStoreGSMSecret("other", "uses-cluster-store", &secretspizecomv1alpha1.GSMSecretStoreRef{
+			Kind: secretspizecomv1alpha1.ClusterGSMSecretStoreKind, Name: "shared",
+		}),
+		newStoreGSMSecret("team", "no-store", nil),
+	}
+	r := &GSMSecretReconciler{Client: newStoreTestClient(objs...)}
+
+	nsRequests := r.mapStoreToGSMSecrets(secretspizecomv1alpha1.GSMSecretStoreKind)(context.Background(),
+		&secretspizecomv1alpha1.GSMSecretStore{ObjectMeta: metav1.ObjectMeta{Name: "shared", Namespace: "team"}})
+	if len(nsRequests) != 1 || nsRequests[0].Name != "uses-ns-store" {
+		t.Errorf("expected only team/uses-ns-store, got %v", nsRequests)
+	}
+
+	clusterRequests := r.mapStoreToGSMSecrets(secretspizecomv1alpha1.ClusterGSMSecretStoreKind)(context.Background(),
+		&secretspizecomv1alpha1.ClusterGSMSecretStore{ObjectMeta: metav1.ObjectMeta{Name: "shared"}})
+	if len(clusterRequests) != 2 {
+		t.Errorf("expected both cluster store references, got %v", clusterRequests)
+	}
+}
+
+// ==================== materializer store tests ====================
+
+func TestMaterializer_StoreOverridesAnnotationsAndEnv(t *testing.T) {
+	t.Setenv("MODE", "TRUSTED_SUBSYSTEM")
+	t.Setenv("WIFAUDIENCE", "//iam.googleapis.com/env-audience")
+	t.Setenv("HTTP_TIMEOUT_SECONDS", "99")
+
+	m := &secretMaterializer{
+		gsmSecret: &secretspizecomv1alpha1.GSMSecret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "app",
+				Namespace: "team",
+				Annotations: map[string]string{
+					secretspizecomv1alpha1.AnnotationKSA: "annotation-ksa",
+					secretspizecomv1alpha1.AnnotationGSA: "annotation@p-123456.iam.gserviceaccount.com",
+				},
+			},
+		},
+		store: &secretspizecomv1alpha1.GSMSecretStoreSpec{
+			AuthMode:           secretspizecomv1alpha1.AuthModeWorkloadIdentityFederation,
+			Audience:           testStoreAudience,
+			ServiceAccountName: "store-ksa",
+			Impersonation: &secretspizecomv1alpha1.GSMSecretStoreImpersonation{
+				ServiceAccount: "reader@p-123456.iam.gserviceaccount.com",
+				Delegates:      []string{"broker@p-123456.iam.gserviceaccount.com"},
+				Lifetime:       &metav1.Duration{Duration: 20 * time.Minute},
+			},
+			Endpoint: "secretmanager.us-central1.rep.googleapis.com:443",
+			Timeouts: &secretspizecomv1alpha1.GSMSecretStoreTimeouts{
+				Request:         &metav1.Duration{Duration: 5 * time.Second},
+				TokenExpiration: &metav1.Duration{Duration: 15 * time.Minute},
+			},
+		},
+	}
+
+	if m.isTrustedSubsystem() {
+		t.Error("expected store authMode to override MODE env var")
+	}
+	if got := m.getKSA(); got != "store-ksa" {
+		t.Errorf("getKSA() = %q, want store-ksa", got)
+	}
+	if aud, err := m.getWIFAudience(); err != nil || aud != testStoreAudience {
+		t.Errorf("getWIFAudience() = %q, %v; want store audience", aud, err)
+	}
+	chain, err := m.getImpersonationChain()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if chain.Target != "reader@p-123456.iam.gserviceaccount.com" ||
+		len(chain.Delegates) != 1 || chain.Lifetime != 20*time.Minute {
+		t.Errorf("unexpected chain %+v", chain)
+	}
+	if got := m.getSecretManagerEndpoint(); got != "secretmanager.us-central1.rep.googleapis.com:443" {
+		t.Errorf("getSecretManagerEndpoint() = %q", got)
+	}
+	if got := m.getHTTPRequestTimeoutSeconds(); got != 5 {
+		t.Errorf("getHTTPRequestTimeoutSeconds() = %d, want 5", got)
+	}
+	if got := m.getTokenExpSeconds(); got != 900 {
+		t.Errorf("getTokenExpSeconds() = %d, want 900", got)
+	}
+}
+
+func TestMaterializer_StoreFallsBackToEnvDefaults(t *testing.T) {
+	t.Setenv("WIFAUDIENCE", testStoreAudience)
+	t.Setenv("HTTP_TIMEOUT_SECONDS", "12")
+
+	m := &secretMaterializer{
+		gsmSecret: &secretspizecomv1alpha1.GSMSecret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "app",
+				Namespace:   "team",
+				Annotations: map[string]string{secretspizecomv1alpha1.AnnotationGSA: "annotation@p-123456.iam.gserviceaccount.com"},
+			},
+		},
+		store: &secretspizecomv1alpha1.GSMSecretStoreSpec{},
+	}
+
+	if got := m.getKSA(); got != ksaapproval.DefaultServiceAccount {
+		t.Errorf("getKSA() = %q, want %q", got, ksaapproval.DefaultServiceAccount)
+	}
+	if aud, err := m.getWIFAudience(); err != nil || aud != testStoreAudience {
+		t.Errorf("getWIFAudience() = %q, %v; want env audience", aud, err)
+	}
+	if chain, err := m.getImpersonationChain(); err != nil || chain != nil {
+		t.Errorf("expected GSA annotation to be ignored with a store, got %+v, %v", chain, err)
+	}
+	if got := m.getHTTPRequestTimeoutSeconds(); got != 12 {
+		t.Errorf("getHTTPRequestTimeoutSeconds() = %d, want 12", got)
+	}
+}
+
+func TestGetProjectID_DefaultFromStore(t *testing.T) {
+	m := &secretMaterializer{store: &secretspizecomv1alpha1.GSMSecretStoreSpec{DefaultProjectID: "store-project"}}
+
+	if got, err := m.getProjectID(secretspizecomv1alpha1.GSMSecretEntry{SecretID: "s"}); err != nil || got != "store-project" {
+		t.Errorf("expected store default project, got %q, %v", got, err)
+	}
+	if got, err := m.getProjectID(secretspizecomv1alpha1.GSMSecretEntry{ProjectID: "entry-project", SecretID: "s"}); err != nil || got != "entry-project" {
+		t.Errorf("expected entry project to win, got %q, %v", got, err)
+	}
+
+	m.store = nil
+	if _, err := m.getProjectID(secretspizecomv1alpha1.GSMSecretEntry{SecretID: "s"}); err == nil {
+		t.Error("expected error without projectId or store default")
+	}
+}
+
+func TestGetQuotaProject(t *testing.T) {
+	t.Setenv("QUOTA_PROJECT_ID", "")
+	m := &secretMaterializer{}
+	if got := m.getQuotaProject(secretspizecomv1alpha1.GSMSecretEntry{SecretID: "s"}); got != "" {
+		t.Errorf("expected no quota project by default, got %q", got)
+	}
+
+	t.Setenv("QUOTA_PROJECT_ID", "operator-quota")
+	if got := m.getQuotaProject(secretspizecomv1alpha1.GSMSecretEntry{SecretID: "s"}); got != "operator-quota" {
+		t.Errorf("expected env quota project, got %q", got)
+	}
+
+	m.store = &secretspizecomv1alpha1.GSMSecretStoreSpec{QuotaProjectID: "store-quota"}
+	if got := m.getQuotaProject(secretspizecomv1alpha1.GSMSecretEntry{SecretID: "s"}); got != "store-quota" {
+		t.Errorf("expected store quota project to win over env, got %q", got)
+	}
+	if got := m.getQuotaProject(secretspizecomv1alpha1.GSMSecretEntry{SecretID: "s", QuotaProjectID: "entry-quota"}); got != "entry-quota" {
+		t.Errorf("expected entry quota project to win, got %q", got)
+	}
+}
@@ -0,0 +1,166 @@
+/*
+Copyright 2025 Zera Holladay.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	secretspizecomv1alpha1 "github.com/zeraholladay/gsm-operator/api/v1alpha1"
+	"github.com/zeraholladay/gsm-operator/internal/authmode"
+)
+
+// GSMSecretStoreReconciler validates GSMSecretStores and reports the result in
+// their Ready condition.
+type GSMSecretStoreReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+}
+
+// +kubebuilder:rbac:groups=secrets.gsm-operator.io,resources=gsmsecretstores,verbs=get;list;watch
+// +kubebuilder:rbac:groups=secrets.gsm-operator.io,resources=gsmsecretstores/status,verbs=get;update;patch
+func (r *GSMSecretStoreReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := logf.FromContext(ctx)
+
+	var store secretspizecomv1alpha1.GSMSecretStore
+	if err := r.Get(ctx, req.NamespacedName, &store); err != nil {
+		if apierrors.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+		log.Error(err, "unable to fetch GSMSecretStore")
+		return ctrl.Result{}, err
+	}
+
+	setStoreReadyCondition(&store.Status, store.Generation, validateStoreSpec(&store.Spec, true))
+	if err := r.Status().Update(ctx, &store); err != nil {
+		log.Error(err, "failed to update GSMSecretStore status")
+		return ctrl.Result{}, err
+	}
+	return ctrl.Result{}, nil
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *GSMSecretStoreReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&secretspizecomv1alpha1.GSMSecretStore{},
+			builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Named("gsmsecretstore").
+		Complete(r)
+}
+
+// validateStoreSpec checks a store's auth configuration for consistency. The
+// same checks gate GSMSecrets that reference the store. Namespaced stores are
+// editable by tenants and may not use the operator's identity.
+func validateStoreSpec(spec *secretspizecomv1alpha1.GSMSecretStoreSpec, namespaced bool) error {
+	if namespaced {
+		if err := authmode.CheckNamespacedStore(spec); err != nil {
+			return err
+		}
+	}
+	switch spec.AuthMode {
+	case secretspizecomv1alpha1.AuthModeTrustedSubsystem:
+		if spec.Audience != "" || spec.ServiceAccountName != "" || spec.Impersonation != nil {
+			return fmt.Errorf("audience, serviceAccountName and impersonation are not used in TrustedSubsystem mode")
+		}
+	case secretspizecomv1alpha1.AuthModeCredentialsSecret:
+		if spec.CredentialsSecretRef == nil {
+			return fmt.Errorf("credentialsSecretRef is required in CredentialsSecret mode")
+		}
+		if spec.Audience != "" || spec.ServiceAccountName != "" {
+			return fmt.Errorf("audience and serviceAccountName are not used in CredentialsSecret mode")
+		}
+	case secretspizecomv1alpha1.AuthModeExternalAccount:
+		if spec.Audience != "" || spec.ServiceAccountName != "" {
+			return fmt.Errorf("audience and serviceAccountName are not used in ExternalAccount mode")
+		}
+		if spec.ExternalAccountConfigRef == nil && getExternalAccountConfigPath() == "" {
+			return fmt.Errorf("ExternalAccount mode requires externalAccountConfigRef or the EXTERNAL_ACCOUNT_CONFIG env var")
+		}
+	default:
+		if spec.Audience == "" && os.Getenv("WIFAUDIENCE") == "" {
+			return fmt.Errorf("audience is required in WorkloadIdentityFederation mode when the WIFAUDIENCE env var is not set")
+		}
+	}
+	if spec.CredentialsSecretRef != nil && spec.AuthMode != secretspizecomv1alpha1.AuthModeCredentialsSecret {
+		return fmt.Errorf("credentialsSecretRef is only used in CredentialsSecret mode")
+	}
+	if spec.ExternalAccountConfigRef != nil && spec.AuthMode != secretspizecomv1alpha1.AuthModeExternalAccount {
+		return fmt.Errorf("externalAccountConfigRef is only used in ExternalAccount mode")
+	}
+
+	if spec.STSEndpoint != "" && !strings.HasPrefix(spec.STSEndpoint, "https://") {
+		return fmt.Errorf("stsEndpoint must be an https:// URL")
+	}
+	if spec.IAMCredentialsEndpoint != "" && !strings.HasPrefix(spec.IAMCredentialsEndpoint, "https://") {
+		return fmt.Errorf("iamCredentialsEndpoint must be an https:// URL")
+	}
+
+	if imp := spec.Impersonation; imp != nil {
+		chain := &impersonationChain{Target: imp.ServiceAccount, Delegates: imp.Delegates}
+		if err := chain.validate(); err != nil {
+			return fmt.Errorf("impersonation: %w", err)
+		}
+		if imp.Lifetime != nil {
+			if err := validateImpersonationLifetime(imp.Lifetime.Duration); err != nil {
+				return fmt.Errorf("impersonation: %w", err)
+			}
+		}
+	}
+
+	if t := spec.Timeouts; t != nil {
+		if t.Request != nil && t.Request.Duration <= 0 {
+			return fmt.Errorf("timeouts.request must be greater than 0")
+		}
+		if t.TokenExpiration != nil && t.TokenExpiration.Duration < time.Duration(minTokenExpSeconds)*time.Second {
+			return fmt.Errorf("timeouts.tokenExpiration must be at least %s", time.Duration(minTokenExpSeconds)*time.Second)
+		}
+	}
+	return nil
+}
+
+// setStoreReadyCondition records the validation result in the store's Ready
+// condition. LastTransitionTime only moves when the status changes.
+func setStoreReadyCondition(status *secretspizecomv1alpha1.GSMSecretStoreStatus, generation int64, validationErr error) {
+	status.ObservedGeneration = generation
+
+	condition := metav1.Condition{
+		Type:               conditionTypeReady,
+		Status:             metav1.ConditionTrue,
+		ObservedGeneration: generation,
+		Reason:             "Valid",
+		Message:            "Store configuration is valid",
+	}
+	if validationErr != nil {
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = "InvalidConfiguration"
+		condition.Message = validationErr.Error()
+	}
+	apimeta.SetStatusCondition(&status.Conditions, condition)
+}
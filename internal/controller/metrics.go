@@ -0,0 +1,346 @@
+/*
+Copyright 2025 Zera Holladay.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/grpc/status"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	secretspizecomv1alpha1 "github.com/zeraholladay/gsm-operator/api/v1alpha1"
+)
+
+// metricsNamespace prefixes every custom metric exported by the operator.
+const metricsNamespace = "gsm_operator"
+
+var (
+	// credentialCacheHits counts getGcpCreds calls served from the credential cache.
+	credentialCacheHits = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "credential_cache_hits_total",
+		Help:      "Number of Google credential lookups served from the credential cache.",
+	})
+
+	// credentialCacheMisses counts getGcpCreds calls that ran the full
+	// TokenRequest, STS and impersonation chain.
+	credentialCacheMisses = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "credential_cache_misses_total",
+		Help:      "Number of Google credential lookups that required a new token exchange.",
+	})
+
+	// credentialCacheEvictions counts cache entries dropped after auth errors.
+	credentialCacheEvictions = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "credential_cache_evictions_total",
+		Help:      "Number of cached Google credentials evicted after authentication errors.",
+	})
+
+	// reconcileDuration observes every GSMSecret reconcile by the reason of
+	// its outcome; the _count series doubles as the outcome counter.
+	reconcileDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "reconcile_duration_seconds",
+		Help:      "Duration of GSMSecret reconciles by outcome reason.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"reason"})
+
+	// tokenExchangeDuration observes the TokenRequest, STS and impersonation
+	// calls made to mint Google credentials.
+	tokenExchangeDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "token_exchange_duration_seconds",
+		Help:      "Duration of TokenRequest, STS and impersonation calls by step.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"step"})
+
+	// tokenExchangeErrors counts failed TokenRequest, STS and impersonation calls.
+	tokenExchangeErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "token_exchange_errors_total",
+		Help:      "Number of failed TokenRequest, STS and impersonation calls by step.",
+	}, []string{"step"})
+
+	// secretAccessDuration observes AccessSecretVersion calls by project.
+	secretAccessDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "secret_access_duration_seconds",
+		Help:      "Duration of Secret Manager AccessSecretVersion calls by project.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"project"})
+
+	// secretAccessTotal counts AccessSecretVersion calls by project and gRPC code.
+	secretAccessTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "secret_access_total",
+		Help:      "Number of Secret Manager AccessSecretVersion calls by project and gRPC code.",
+	}, []string{"project", "code"})
+
+	// secretPayloadBytes observes the size of every payload read from Secret
+	// Manager, which caps payloads at 64 KiB.
+	secretPayloadBytes = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "secret_payload_bytes",
+		Help:      "Size of Secret Manager payloads read, by project.",
+		Buckets:   prometheus.ExponentialBuckets(64, 4, 7),
+	}, []string{"project"})
+
+	// secretVersionLookups counts GetSecretVersion calls by project and gRPC code.
+	secretVersionLookups = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "secret_version_lookups_total",
+		Help:      "Number of Secret Manager GetSecretVersion calls by project and gRPC code.",
+	}, []string{"project", "code"})
+
+	// versionChecks counts change detection runs by result: unchanged (no
+	// payload read), changed, or failed (payloads read instead).
+	versionChecks = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "version_checks_total",
+		Help:      "Number of GSMSecret version checks by result (unchanged, changed or failed).",
+	}, []string{"result"})
+
+	// secretWrites counts target Secret applies by result: written, or
+	// skipped because the Secret already matched.
+	secretWrites = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "secret_writes_total",
+		Help:      "Number of target Secret applies by result (written or skipped).",
+	}, []string{"result"})
+
+	// rateLimitWait observes how long Secret Manager, STS and IAM Credentials
+	// calls waited for their rate limiter.
+	rateLimitWait = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "rate_limit_wait_seconds",
+		Help:      "Time Google API calls waited for their rate limiter, by limiter.",
+		Buckets:   prometheus.ExponentialBuckets(0.001, 4, 8),
+	}, []string{"limiter"})
+
+	// payloadCacheRequests counts payload cache lookups by hit or miss.
+	payloadCacheRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "payload_cache_requests_total",
+		Help:      "Number of secret payload cache lookups by result (hit or miss).",
+	}, []string{"result"})
+
+	// payloadCacheEvictions counts payloads dropped to stay within the size bound.
+	payloadCacheEvictions = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "payload_cache_evictions_total",
+		Help:      "Number of cached secret payloads evicted to stay within PAYLOAD_CACHE_MAX_BYTES.",
+	})
+)
+
+// Token exchange steps, the values of the step label.
+const (
+	tokenStepTokenRequest  = "token_request"
+	tokenStepSTS           = "sts"
+	tokenStepImpersonation = "impersonation"
+)
+
+// reasonDeleted labels reconciles of GSMSecrets that are gone or going away.
+const reasonDeleted = "Deleted"
+
+func init() {
+	// Register with controller-runtime's registry so the metrics are served
+	// from the manager's existing metrics endpoint.
+	metrics.Registry.MustRegister(
+		credentialCacheHits,
+		credentialCacheMisses,
+		credentialCacheEvictions,
+		reconcileDuration,
+		tokenExchangeDuration,
+		tokenExchangeErrors,
+		secretAccessDuration,
+		secretAccessTotal,
+		secretPayloadBytes,
+		secretVersionLookups,
+		versionChecks,
+		secretWrites,
+		rateLimitWait,
+		payloadCacheRequests,
+		payloadCacheEvictions,
+	)
+}
+
+// reconcileOutcome carries the reason a reconcile ended with from
+// setStatusCondition back to Reconcile, which observes it.
+type reconcileOutcome struct {
+	reason string
+}
+
+type reconcileOutcomeKey struct{}
+
+// withReconcileOutcome returns a context that records the reconcile's reason
+// into outcome.
+func withReconcileOutcome(ctx context.Context, outcome *reconcileOutcome) context.Context {
+	return context.WithValue(ctx, reconcileOutcomeKey{}, outcome)
+}
+
+// recordReconcileReason stores reason as the outcome of the reconcile running
+// under ctx, if any.
+func recordReconcileReason(ctx context.Context, reason string) {
+	if outcome, ok := ctx.Value(reconcileOutcomeKey{}).(*reconcileOutcome); ok {
+		outcome.reason = reason
+	}
+}
+
+// observeReconcile records a finished reconcile. Reconciles that ended
+// before any condition was written are labeled "Error" or "None".
+func observeReconcile(outcome *reconcileOutcome, d time.Duration, err error) {
+	reason := outcome.reason
+	if reason == "" {
+		reason = "None"
+		if err != nil {
+			reason = "Error"
+		}
+	}
+	reconcileDuration.WithLabelValues(reason).Observe(d.Seconds())
+}
+
+// observeTokenExchange records one TokenRequest, STS or impersonation call.
+func observeTokenExchange(step string, start time.Time, failed bool) {
+	tokenExchangeDuration.WithLabelValues(step).Observe(time.Since(start).Seconds())
+	if failed {
+		tokenExchangeErrors.WithLabelValues(step).Inc()
+	}
+}
+
+// observeSecretAccess records one AccessSecretVersion call and, when it
+// succeeded, the size of the payload.
+func observeSecretAccess(projectID string, start time.Time, payload []byte, err error) {
+	secretAccessDuration.WithLabelValues(projectID).Observe(time.Since(start).Seconds())
+	secretAccessTotal.WithLabelValues(projectID, status.Code(err).String()).Inc()
+	if err == nil {
+		secretPayloadBytes.WithLabelValues(projectID).Observe(float64(len(payload)))
+	}
+}
+
+// observeVersionLookup records one GetSecretVersion call.
+func observeVersionLookup(projectID string, err error) {
+	secretVersionLookups.WithLabelValues(projectID, status.Code(err).String()).Inc()
+}
+
+// syncTracker remembers when each GSMSecret last synced successfully, for
+// the data age metric. It is in memory only, so the age of a GSMSecret is
+// unknown until its first sync after the operator starts.
+type syncTracker struct {
+	mu   sync.Mutex
+	last map[types.NamespacedName]time.Time
+}
+
+// lastSyncs is the process-wide sync tracker.
+var lastSyncs = &syncTracker{last: map[types.NamespacedName]time.Time{}}
+
+func (t *syncTracker) record(key types.NamespacedName, at time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.last[key] = at
+}
+
+func (t *syncTracker) get(key types.NamespacedName) (time.Time, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	at, ok := t.last[key]
+	return at, ok
+}
+
+func (t *syncTracker) forget(key types.NamespacedName) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.last, key)
+}
+
+var (
+	gsmSecretsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(metricsNamespace, "", "gsmsecrets"),
+		"Number of GSMSecrets by the status of their Ready condition.",
+		[]string{"ready"}, nil,
+	)
+	secretDataAgeDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(metricsNamespace, "", "secret_data_age_seconds"),
+		"Seconds since the target Secret of a GSMSecret was last synced from Secret Manager.",
+		[]string{"namespace", "name", "target_namespace", "target_secret"}, nil,
+	)
+)
+
+// gsmSecretCollector reports GSMSecrets by Ready state and the age of the
+// data in their target Secrets. It lists GSMSecrets from the manager's cache
+// on every scrape, so deleted GSMSecrets drop out without bookkeeping.
+type gsmSecretCollector struct {
+	reader client.Reader
+	syncs  *syncTracker
+	now    func() time.Time
+}
+
+// newGSMSecretCollector returns a collector reading GSMSecrets through reader.
+func newGSMSecretCollector(reader client.Reader) *gsmSecretCollector {
+	return &gsmSecretCollector{reader: reader, syncs: lastSyncs, now: time.Now}
+}
+
+// Describe implements prometheus.Collector.
+func (c *gsmSecretCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- gsmSecretsDesc
+	ch <- secretDataAgeDesc
+}
+
+// Collect implements prometheus.Collector. A failed list is logged rather
+// than reported, so it does not fail the whole scrape.
+func (c *gsmSecretCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var list secretspizecomv1alpha1.GSMSecretList
+	if err := c.reader.List(ctx, &list); err != nil {
+		logf.Log.WithName("metrics").Error(err, "failed to list GSMSecrets for metrics")
+		return
+	}
+
+	counts := map[string]int{"True": 0, "False": 0, "Unknown": 0}
+	now := c.now()
+	for i := range list.Items {
+		gsm := &list.Items[i]
+		ready := "Unknown"
+		if cond := apimeta.FindStatusCondition(gsm.Status.Conditions, conditionTypeReady); cond != nil {
+			ready = string(cond.Status)
+		}
+		counts[ready]++
+
+		at, ok := c.syncs.get(types.NamespacedName{Namespace: gsm.Namespace, Name: gsm.Name})
+		if !ok {
+			continue
+		}
+		target := gsm.Status.CurrentSecretName
+		if target == "" {
+			target = gsm.Spec.TargetSecret.Name
+		}
+		ch <- prometheus.MustNewConstMetric(secretDataAgeDesc, prometheus.GaugeValue, now.Sub(at).Seconds(),
+			gsm.Namespace, gsm.Name, targetNamespace(gsm), target)
+	}
+	for ready, n := range counts {
+		ch <- prometheus.MustNewConstMetric(gsmSecretsDesc, prometheus.GaugeValue, float64(n), ready)
+	}
+}
@@ -0,0 +1,169 @@
+/*
+Copyright 2025 Zera Holladay.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	secretspizecomv1alpha1 "github.com/zeraholladay/gsm-operator/api/v1alpha1"
+)
+
+// histogramSampleCount returns the number of observations in one series of h.
+func histogramSampleCount(t *testing.T, h *prometheus.HistogramVec, label string) uint64 {
+	t.Helper()
+	var m dto.Metric
+	if err := h.WithLabelValues(label).(prometheus.Metric).Write(&m); err != nil {
+		t.Fatalf("read histogram: %v", err)
+	}
+	return m.GetHistogram().GetSampleCount()
+}
+
+func TestSetStatusCondition_RecordsReconcileReason(t *testing.T) {
+	gsm := &secretspizecomv1alpha1.GSMSecret{
+		ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "default"},
+	}
+	r := newTestReconciler(gsm)
+	outcome := &reconcileOutcome{}
+	ctx := withReconcileOutcome(context.Background(), outcome)
+
+	if err := r.setStatusCondition(ctx, gsm, metav1.ConditionFalse, "PolicyDenied", "denied"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if outcome.reason != "PolicyDenied" {
+		t.Errorf("expected reason PolicyDenied, got %q", outcome.reason)
+	}
+}
+
+func TestReconcile_ObservesDeletedGSMSecret(t *testing.T) {
+	r := newTestReconciler()
+	key := types.NamespacedName{Namespace: "default", Name: "gone"}
+	lastSyncs.record(key, time.Now())
+	before := histogramSampleCount(t, reconcileDuration, reasonDeleted)
+
+	if _, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: key}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := histogramSampleCount(t, reconcileDuration, reasonDeleted); got != before+1 {
+		t.Errorf("expected one more %s reconcile, got %d after %d", reasonDeleted, got, before)
+	}
+	if _, ok := lastSyncs.get(key); ok {
+		t.Error("expected the last sync of a deleted GSMSecret to be forgotten")
+	}
+}
+
+func TestGSMSecretCollector(t *testing.T) {
+	withReady := func(name string, status metav1.ConditionStatus) *secretspizecomv1alpha1.GSMSecret {
+		return &secretspizecomv1alpha1.GSMSecret{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "apps"},
+			Spec: secretspizecomv1alpha1.GSMSecretSpec{
+				TargetSecret: secretspizecomv1alpha1.GSMSecretTargetSecret{Name: name + "-secret"},
+			},
+			Status: secretspizecomv1alpha1.GSMSecretStatus{
+				Conditions: []metav1.Condition{{Type: conditionTypeReady, Status: status}},
+			},
+		}
+	}
+	synced := withReady("synced", metav1.ConditionTrue)
+	synced.Status.CurrentSecretName = "synced-secret-abc123"
+	failing := withReady("failing", metav1.ConditionFalse)
+	pending := &secretspizecomv1alpha1.GSMSecret{ObjectMeta: metav1.ObjectMeta{Name: "pending", Namespace: "apps"}}
+
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	syncs := &syncTracker{last: map[types.NamespacedName]time.Time{
+		{Namespace: "apps", Name: "synced"}:  now.Add(-90 * time.Second),
+		{Namespace: "apps", Name: "failing"}: now.Add(-time.Hour),
+		{Namespace: "apps", Name: "deleted"}: now.Add(-time.Minute),
+	}}
+	c := &gsmSecretCollector{
+		reader: newTestReconciler(synced, failing, pending).Client,
+		syncs:  syncs,
+		now:    func() time.Time { return now },
+	}
+
+	want := `
+# HELP gsm_operator_gsmsecrets Number of GSMSecrets by the status of their Ready condition.
+# TYPE gsm_operator_gsmsecrets gauge
+gsm_operator_gsmsecrets{ready="False"} 1
+gsm_operator_gsmsecrets{ready="True"} 1
+gsm_operator_gsmsecrets{ready="Unknown"} 1
+# HELP gsm_operator_secret_data_age_seconds Seconds since the target Secret of a GSMSecret was last synced from Secret Manager.
+# TYPE gsm_operator_secret_data_age_seconds gauge
+gsm_operator_secret_data_age_seconds{name="failing",namespace="apps",target_namespace="apps",target_secret="failing-secret"} 3600
+gsm_operator_secret_data_age_seconds{name="synced",namespace="apps",target_namespace="apps",target_secret="synced-secret-abc123"} 90
+`
+	if err := testutil.CollectAndCompare(c, strings.NewReader(want)); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestTokenExchangeTransport(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v1/token" {
+			http.Error(w, `{"error":"invalid_grant"}`, http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: &tokenExchangeTransport{base: http.DefaultTransport, tokenURL: server.URL + "/v1/token"}}
+	stsErrors := testutil.ToFloat64(tokenExchangeErrors.WithLabelValues(tokenStepSTS))
+	impersonationCalls := histogramSampleCount(t, tokenExchangeDuration, tokenStepImpersonation)
+	impersonationErrors := testutil.ToFloat64(tokenExchangeErrors.WithLabelValues(tokenStepImpersonation))
+	stsWaits := histogramSampleCount(t, rateLimitWait, limiterSTS)
+	iamWaits := histogramSampleCount(t, rateLimitWait, limiterIAMCredentials)
+
+	for _, path := range []string{
+		"/v1/token",
+		"/v1/projects/-/serviceAccounts/" + readerGSA + ":generateAccessToken",
+		"/credential-source",
+	} {
+		resp, err := client.Post(server.URL+path, "application/json", nil)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		_ = resp.Body.Close()
+	}
+
+	if got := testutil.ToFloat64(tokenExchangeErrors.WithLabelValues(tokenStepSTS)); got != stsErrors+1 {
+		t.Errorf("expected the rejected exchange to count as an STS error, got %v after %v", got, stsErrors)
+	}
+	if got := histogramSampleCount(t, tokenExchangeDuration, tokenStepImpersonation); got != impersonationCalls+1 {
+		t.Errorf("expected one impersonation call to be observed, got %d after %d", got, impersonationCalls)
+	}
+	if got := testutil.ToFloat64(tokenExchangeErrors.WithLabelValues(tokenStepImpersonation)); got != impersonationErrors {
+		t.Errorf("expected no impersonation errors, got %v after %v", got, impersonationErrors)
+	}
+	if got := histogramSampleCount(t, rateLimitWait, limiterSTS); got != stsWaits+1 {
+		t.Errorf("expected the exchange to pass the STS limiter, got %d after %d", got, stsWaits)
+	}
+	if got := histogramSampleCount(t, rateLimitWait, limiterIAMCredentials); got != iamWaits+1 {
+		t.Errorf("expected the impersonation call to pass the IAM Credentials limiter, got %d after %d", got, iamWaits)
+	}
+}
@@ -23,9 +23,13 @@ import (
 	"strconv"
 	"strings"
 
+	"google.golang.org/api/option"
 	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	secretspizecomv1alpha1 "github.com/zeraholladay/gsm-operator/api/v1alpha1"
+	"github.com/zeraholladay/gsm-operator/internal/authmode"
+	"github.com/zeraholladay/gsm-operator/internal/ksaapproval"
 )
 
 const (
@@ -33,16 +37,38 @@ const (
 	minTokenExpSeconds uint64 = 10 * 60
 	// Default to the Kubernetes minimum unless explicitly overridden higher.
 	defaultTokenExpSeconds uint64 = minTokenExpSeconds
-	// defaultKSAName is the default Kubernetes ServiceAccount name when not specified.
-	defaultKSAName = "default"
 )
 
 // secretMaterializer holds the dependencies and state required to materialize
 // a Kubernetes Secret from a GSMSecret resource.
 type secretMaterializer struct {
-	gsmSecret    *secretspizecomv1alpha1.GSMSecret
-	payloads     []keyedSecretPayload
-	kubeClientFn func() (kubernetes.Interface, error)
+	gsmSecret *secretspizecomv1alpha1.GSMSecret
+	payloads  []keyedSecretPayload
+	// resolvedVersions lists the version each requested secret version
+	// resolved to, in spec order.
+	resolvedVersions []secretspizecomv1alpha1.ResolvedSecretVersion
+	// knownVersions are the versions of the last intact sync, if any. When
+	// set, resolvePayloads reads no payloads unless a version moved, and
+	// sets unchanged instead.
+	knownVersions []secretspizecomv1alpha1.ResolvedSecretVersion
+	unchanged     bool
+	// checkedVersions maps version names to the version number the check
+	// resolved them to, so payloads are read from exactly that version.
+	checkedVersions map[string]string
+	kubeClientFn    func() (kubernetes.Interface, error)
+	// store is the resolved spec.storeRef, if any. When set, identity comes
+	// from the store and GSMSecret annotations are ignored; fields the store
+	// leaves empty fall back to the operator's env defaults.
+	store *secretspizecomv1alpha1.GSMSecretStoreSpec
+	// credKey identifies the cached credentials used for this reconcile, if any,
+	// so they can be evicted when Secret Manager rejects them.
+	credKey *credentialCacheKey
+	// gsmClientOptions are appended to the options of new Secret Manager
+	// clients, e.g. to trust a fake's certificate in tests.
+	gsmClientOptions []option.ClientOption
+	// policyReader, when set, is used to enforce GSMAccessPolicies before any
+	// Google API call is made.
+	policyReader client.Reader
 }
 
 // keyedSecretPayload holds a Kubernetes Secret data key and its corresponding GSM payload.
@@ -65,31 +91,24 @@ func newKeyedSecretPayload(key string, value []byte) (keyedSecretPayload, error)
 	}, nil
 }
 
-// isTrustedSubsystem returns true if MODE=TRUSTED_SUBSYSTEM.
+// authMode returns the effective auth mode; see authmode.Resolve.
+func (m secretMaterializer) authMode() secretspizecomv1alpha1.GSMSecretStoreAuthMode {
+	return authmode.Resolve(m.gsmSecret, m.store)
+}
+
+// isTrustedSubsystem returns true if the effective auth mode is TrustedSubsystem.
 // In Trusted Subsystem mode, the operator acts as its own IAM principal
 // (i.e., KSA gsm-operator-controller-manager by default) and will not:
 // 1. Request a short-lived JWT for the tenant KSA.
 // 2. Exchange Kubernetes ServiceAccount token via Workload Identity Federation.
 // 3. Impersonate a GSA.
 func (m secretMaterializer) isTrustedSubsystem() bool {
-	return os.Getenv("MODE") == "TRUSTED_SUBSYSTEM"
+	return m.authMode() == secretspizecomv1alpha1.AuthModeTrustedSubsystem
 }
 
 // Get the KSA
 func (m *secretMaterializer) getKSA() string {
-	// Override the KSA via env var if your GKE RBAC requires a specific ServiceAccount (e.g., gsm-reader).
-	if v := os.Getenv("KSA"); v != "" {
-		return v
-	}
-
-	// Otherwise use the annotation
-	if ann := m.gsmSecret.GetAnnotations(); ann != nil {
-		if v := strings.TrimSpace(ann[secretspizecomv1alpha1.AnnotationKSA]); v != "" {
-			return v
-		}
-	}
-	// Fallback to the default KSA.
-	return defaultKSAName
+	return ksaapproval.ServiceAccount(m.gsmSecret, m.store)
 }
 
 // Use the GSA if provided -> the KSA must have permission to impersonate it
@@ -97,6 +116,12 @@ func (m *secretMaterializer) getKSA() string {
 // "principal://iam.googleapis.com/projects/${oidc_project_number}/locations/global/workloadIdentityPools/gsm-operator-pool/subject/system:serviceaccount:gsmsecret-test-ns:default"
 // **AND** this principal has "roles/iam.serviceAccountTokenCreator"
 func (m *secretMaterializer) getGSA() string {
+	if m.store != nil {
+		if m.store.Impersonation != nil {
+			return strings.TrimSpace(m.store.Impersonation.ServiceAccount)
+		}
+		return ""
+	}
 	if ann := m.gsmSecret.GetAnnotations(); ann != nil {
 		if v := strings.TrimSpace(ann[secretspizecomv1alpha1.AnnotationGSA]); v != "" {
 			return v
@@ -107,6 +132,15 @@ func (m *secretMaterializer) getGSA() string {
 
 // i.e. "//iam.googleapis.com/projects/${oidc_project_number}/locations/global/workloadIdentityPools/gsm-operator-pool/providers/gsm-operator-provider"
 func (m *secretMaterializer) getWIFAudience() (string, error) {
+	if m.store != nil {
+		if m.store.Audience != "" {
+			return m.store.Audience, nil
+		}
+		if v := os.Getenv("WIFAUDIENCE"); v != "" {
+			return v, nil
+		}
+		return "", fmt.Errorf("WIFAudience not set: set audience on the referenced store or the WIFAUDIENCE env var")
+	}
 	if v := os.Getenv("WIFAUDIENCE"); v != "" {
 		return v, nil
 	}
@@ -121,6 +155,12 @@ func (m *secretMaterializer) getWIFAudience() (string, error) {
 
 // The token may not specify a duration less than 10 minutes
 func (m *secretMaterializer) getTokenExpSeconds() uint64 {
+	if m.store != nil && m.store.Timeouts != nil && m.store.Timeouts.TokenExpiration != nil {
+		if secs := uint64(m.store.Timeouts.TokenExpiration.Seconds()); secs > minTokenExpSeconds {
+			return secs
+		}
+		return minTokenExpSeconds
+	}
 	if v := os.Getenv("TOKEN_EXP_SECONDS"); v != "" {
 		if parsed, err := strconv.ParseUint(v, 10, 64); err == nil && parsed > 0 {
 			if parsed < minTokenExpSeconds {
@@ -133,6 +173,11 @@ func (m *secretMaterializer) getTokenExpSeconds() uint64 {
 }
 
 func (m *secretMaterializer) getHTTPRequestTimeoutSeconds() uint64 {
+	if m.store != nil && m.store.Timeouts != nil && m.store.Timeouts.Request != nil {
+		if secs := uint64(m.store.Timeouts.Request.Seconds()); secs > 0 {
+			return secs
+		}
+	}
 	if v := os.Getenv("HTTP_TIMEOUT_SECONDS"); v != "" {
 		if parsed, err := strconv.ParseUint(v, 10, 64); err == nil && parsed > 0 {
 			return parsed
@@ -141,6 +186,47 @@ func (m *secretMaterializer) getHTTPRequestTimeoutSeconds() uint64 {
 	return 30
 }
 
+// getProjectID returns the entry's project, falling back to the store's
+// defaultProjectId.
+func (m *secretMaterializer) getProjectID(e secretspizecomv1alpha1.GSMSecretEntry) (string, error) {
+	if e.ProjectID != "" {
+		return e.ProjectID, nil
+	}
+	if m.store != nil && m.store.DefaultProjectID != "" {
+		return m.store.DefaultProjectID, nil
+	}
+	return "", fmt.Errorf("projectId not set for secret %q and no defaultProjectId on the referenced store", e.SecretID)
+}
+
+// getDefaultQuotaProject returns the quota project from the store, then the
+// QUOTA_PROJECT_ID env var, or "" to bill the credentials' own project.
+func (m *secretMaterializer) getDefaultQuotaProject() string {
+	var fromStore string
+	if m.store != nil {
+		fromStore = m.store.QuotaProjectID
+	}
+	return endpointSetting(fromStore, "QUOTA_PROJECT_ID")
+}
+
+// getQuotaProject returns the quota project for an entry's Secret Manager
+// call: the entry's own, then the default.
+func (m *secretMaterializer) getQuotaProject(e secretspizecomv1alpha1.GSMSecretEntry) string {
+	if e.QuotaProjectID != "" {
+		return e.QuotaProjectID
+	}
+	return m.getDefaultQuotaProject()
+}
+
+// snapshot returns a copy of the materializer for long-lived token sources and
+// clients, so they neither alias the reconcile's GSMSecret nor retain payloads.
+func (m *secretMaterializer) snapshot() *secretMaterializer {
+	c := *m
+	c.gsmSecret = m.gsmSecret.DeepCopy()
+	c.store = m.store.DeepCopy()
+	c.payloads = nil
+	return &c
+}
+
 func (m *secretMaterializer) getKubeClient() (kubernetes.Interface, error) {
 	if m.kubeClientFn != nil {
 		return m.kubeClientFn()
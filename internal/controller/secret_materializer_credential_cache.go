@@ -0,0 +1,140 @@
+package controller
+
+/*
+Copyright 2025 Zera Holladay.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+import (
+	"sync"
+	"time"
+
+	xoauth2 "golang.org/x/oauth2"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// defaultCredentialRefreshBefore is how long before expiry a token is
+	// refreshed, so it is renewed ahead of time instead of failing mid-call.
+	defaultCredentialRefreshBefore = 5 * time.Minute
+
+	// defaultCredentialIdleTTL is how long an unused cache entry is kept.
+	defaultCredentialIdleTTL = time.Hour
+)
+
+// credentialCacheKey identifies a federated or Secret-based (and optionally
+// impersonated) Google identity. Every GSMSecret resolving to the same key
+// shares a token.
+type credentialCacheKey struct {
+	Namespace string
+	KSA       string
+	Audience  string
+	// CredentialsSecret is "name/key@resourceVersion" of the credentials
+	// Secret, so a rotated key maps to a new entry.
+	CredentialsSecret string
+	// ExternalAccount is "path@sha256" of the operator's external_account
+	// configuration, or "kind/namespace/name/key@resourceVersion" of the one
+	// a store references, so an updated configuration maps to a new entry.
+	ExternalAccount string
+	// Endpoints is the STS token URL, IAM Credentials endpoint and universe
+	// domain the credentials were minted through.
+	Endpoints string
+	// QuotaProject is the x-goog-user-project sent to IAM Credentials.
+	QuotaProject string
+	GSA          string
+	// Delegates is the comma-joined delegation chain leading to GSA.
+	Delegates string
+	Lifetime  time.Duration
+}
+
+// credentialCacheEntry is a refreshing token source for one identity.
+type credentialCacheEntry struct {
+	ts       xoauth2.TokenSource
+	lastUsed time.Time
+}
+
+// credentialCache is a process-wide cache of refreshing token sources built
+// from TokenRequest, STS and impersonation. Each source renews its token
+// ahead of expiry, so a hit never hands out a token that is about to lapse.
+// It is safe for concurrent use.
+type credentialCache struct {
+	mu      sync.Mutex
+	entries map[credentialCacheKey]*credentialCacheEntry
+	idleTTL time.Duration
+	now     func() time.Time
+}
+
+// gcpCredentialCache is shared by every reconcile in the process.
+var gcpCredentialCache = newCredentialCache(defaultCredentialIdleTTL)
+
+func newCredentialCache(idleTTL time.Duration) *credentialCache {
+	return &credentialCache{
+		entries: map[credentialCacheKey]*credentialCacheEntry{},
+		idleTTL: idleTTL,
+		now:     time.Now,
+	}
+}
+
+// get returns the cached token source for key.
+func (c *credentialCache) get(key credentialCacheKey) (xoauth2.TokenSource, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	entry.lastUsed = c.now()
+	return entry.ts, true
+}
+
+// put stores ts for key and drops entries nobody has used within idleTTL.
+func (c *credentialCache) put(key credentialCacheKey, ts xoauth2.TokenSource) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for k, e := range c.entries {
+		if c.now().Sub(e.lastUsed) > c.idleTTL {
+			delete(c.entries, k)
+		}
+	}
+	c.entries[key] = &credentialCacheEntry{ts: ts, lastUsed: c.now()}
+}
+
+// evict removes key from the cache.
+func (c *credentialCache) evict(key credentialCacheKey) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.entries[key]; ok {
+		delete(c.entries, key)
+		credentialCacheEvictions.Inc()
+	}
+}
+
+// isAuthError reports whether err indicates the credentials themselves were
+// rejected, in which case a cached token should not be reused.
+// PermissionDenied is not one: it concerns a single secret the identity may
+// not read, and the same token still serves the GSMSecret's other entries
+// and every other GSMSecret sharing it.
+func isAuthError(err error) bool {
+	if err == nil {
+		return false
+	}
+	if s, ok := status.FromError(err); ok {
+		return s.Code() == codes.Unauthenticated
+	}
+	return false
+}
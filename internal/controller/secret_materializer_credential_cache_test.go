@@ -0,0 +1,164 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	secretspizecomv1alpha1 "github.com/zeraholladay/gsm-operator/api/v1alpha1"
+)
+
+func TestCredentialCache_GetPut(t *testing.T) {
+	c := newCredentialCache(time.Hour)
+	key := credentialCacheKey{Namespace: "ns", KSA: "default", Audience: "aud"}
+
+	if _, ok := c.get(key); ok {
+		t.Fatal("expected miss on empty cache")
+	}
+
+	c.put(key, mockTokenSource{token: "abc"})
+
+	ts, ok := c.get(key)
+	if !ok {
+		t.Fatal("expected cached token source")
+	}
+	if tok, _ := ts.Token(); tok.AccessToken != "abc" {
+		t.Errorf("expected token %q, got %q", "abc", tok.AccessToken)
+	}
+
+	// A different GSA is a different identity.
+	other := key
+	other.GSA = "gsa@p.iam.gserviceaccount.com"
+	if _, ok := c.get(other); ok {
+		t.Error("expected miss for a different GSA")
+	}
+}
+
+func TestCredentialCache_DropsIdleEntries(t *testing.T) {
+	c := newCredentialCache(time.Hour)
+	now := time.Now()
+	c.now = func() time.Time { return now }
+	idle := credentialCacheKey{Namespace: "idle", KSA: "default", Audience: "aud"}
+	active := credentialCacheKey{Namespace: "active", KSA: "default", Audience: "aud"}
+
+	c.put(idle, mockTokenSource{token: "idle"})
+	now = now.Add(2 * time.Hour)
+	c.put(active, mockTokenSource{token: "active"})
+
+	if _, ok := c.get(idle); ok {
+		t.Error("expected idle entry to be dropped")
+	}
+	if _, ok := c.get(active); !ok {
+		t.Error("expected active entry to be kept")
+	}
+}
+
+func TestCredentialCache_Evict(t *testing.T) {
+	c := newCredentialCache(time.Hour)
+	key := credentialCacheKey{Namespace: "ns", KSA: "default", Audience: "aud"}
+	c.put(key, mockTokenSource{token: "abc"})
+
+	before := testutil.ToFloat64(credentialCacheEvictions)
+	c.evict(key)
+
+	if _, ok := c.get(key); ok {
+		t.Error("expected miss after eviction")
+	}
+	if got := testutil.ToFloat64(credentialCacheEvictions) - before; got != 1 {
+		t.Errorf("expected 1 eviction recorded, got %v", got)
+	}
+}
+
+func TestIsAuthError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"nil", nil, false},
+		{"unauthenticated", status.Error(codes.Unauthenticated, "bad token"), true},
+		{"permission denied", status.Error(codes.PermissionDenied, "denied"), false},
+		{"wrapped", fmt.Errorf("fetch: %w", status.Error(codes.Unauthenticated, "bad token")), true},
+		{"not found", status.Error(codes.NotFound, "missing"), false},
+		{"plain error", errors.New("boom"), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isAuthError(tt.err); got != tt.want {
+				t.Errorf("isAuthError = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetGcpCreds_CacheHitSkipsTokenExchange(t *testing.T) {
+	t.Setenv("WIFAUDIENCE", "//iam.googleapis.com/projects/123/locations/global/workloadIdentityPools/pool/providers/provider")
+	t.Setenv("KSA", "cached-ksa")
+
+	key := credentialCacheKey{
+		Namespace: "cache-ns",
+		KSA:       "cached-ksa",
+		Audience:  "//iam.googleapis.com/projects/123/locations/global/workloadIdentityPools/pool/providers/provider",
+		Endpoints: (&secretMaterializer{}).endpointsKey(),
+	}
+	gcpCredentialCache.put(key, mockTokenSource{token: "cached"})
+	t.Cleanup(func() { gcpCredentialCache.evict(key) })
+
+	m := &secretMaterializer{
+		gsmSecret: &secretspizecomv1alpha1.GSMSecret{
+			ObjectMeta: metav1.ObjectMeta{Name: "test-gsmsecret", Namespace: "cache-ns"},
+		},
+		kubeClientFn: func() (kubernetes.Interface, error) {
+			t.Fatal("kube client should not be used on a cache hit")
+			return nil, nil
+		},
+	}
+
+	hits := testutil.ToFloat64(credentialCacheHits)
+	creds, err := m.getGcpCreds(context.Background())
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	tok, err := creds.TokenSource.Token()
+	if err != nil || tok.AccessToken != "cached" {
+		t.Fatalf("expected cached token, got %v, %v", tok, err)
+	}
+	if got := testutil.ToFloat64(credentialCacheHits) - hits; got != 1 {
+		t.Errorf("expected 1 cache hit recorded, got %v", got)
+	}
+	if m.credKey == nil || *m.credKey != key {
+		t.Errorf("expected credKey %+v, got %+v", key, m.credKey)
+	}
+}
+
+func TestGetGcpCreds_CacheMissRecorded(t *testing.T) {
+	t.Setenv("WIFAUDIENCE", "//iam.googleapis.com/projects/123/locations/global/workloadIdentityPools/pool/providers/provider")
+	t.Setenv("KSA", "missing-ksa")
+
+	m := &secretMaterializer{
+		gsmSecret: &secretspizecomv1alpha1.GSMSecret{
+			ObjectMeta: metav1.ObjectMeta{Name: "test-gsmsecret", Namespace: "miss-ns"},
+		},
+		kubeClientFn: func() (kubernetes.Interface, error) {
+			return nil, errors.New("kube client unavailable")
+		},
+	}
+
+	misses := testutil.ToFloat64(credentialCacheMisses)
+	if _, err := m.getGcpCreds(context.Background()); err == nil {
+		t.Fatal("expected error when the token request fails")
+	}
+	if got := testutil.ToFloat64(credentialCacheMisses) - misses; got != 1 {
+		t.Errorf("expected 1 cache miss recorded, got %v", got)
+	}
+}
@@ -0,0 +1,166 @@
+package controller
+
+/*
+Copyright 2025 Zera Holladay.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+
+	"golang.org/x/oauth2/google"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	secretspizecomv1alpha1 "github.com/zeraholladay/gsm-operator/api/v1alpha1"
+)
+
+// getCredentialsSecretRef returns the Secret holding Google credentials for
+// the GSMSecret, or nil when another auth mode is used.
+func (m *secretMaterializer) getCredentialsSecretRef() *secretspizecomv1alpha1.CredentialsSecretRef {
+	if m.authMode() != secretspizecomv1alpha1.AuthModeCredentialsSecret {
+		return nil
+	}
+	if m.store != nil {
+		return m.store.CredentialsSecretRef
+	}
+	return m.gsmSecret.Spec.CredentialsSecretRef
+}
+
+// readCredentialsSecret returns the credentials JSON from the referenced
+// Secret in the GSMSecret's namespace, and an identity that changes whenever
+// the Secret does, so a rotated key is never served from the cache.
+func (m *secretMaterializer) readCredentialsSecret(
+	ctx context.Context,
+	ref *secretspizecomv1alpha1.CredentialsSecretRef,
+) ([]byte, string, error) {
+	key := ref.Key
+	if key == "" {
+		key = secretspizecomv1alpha1.DefaultCredentialsSecretKey
+	}
+
+	kc, err := m.getKubeClient()
+	if err != nil {
+		return nil, "", fmt.Errorf("build kube client: %w", err)
+	}
+	secret, err := kc.CoreV1().Secrets(m.gsmSecret.Namespace).Get(ctx, ref.Name, metav1.GetOptions{})
+	if err != nil {
+		return nil, "", fmt.Errorf("get credentials Secret %q: %w", ref.Name, err)
+	}
+	data := secret.Data[key]
+	if len(data) == 0 {
+		return nil, "", fmt.Errorf("credentials Secret %q has no data under key %q", ref.Name, key)
+	}
+	return data, fmt.Sprintf("%s/%s@%s", ref.Name, key, secret.ResourceVersion), nil
+}
+
+// credentialsFile holds the fields of a credentials JSON that are checked
+// before it is handed to the Google auth library.
+type credentialsFile struct {
+	Type                           string `json:"type"`
+	TokenURL                       string `json:"token_url"`
+	ServiceAccountImpersonationURL string `json:"service_account_impersonation_url"`
+	CredentialSource               *struct {
+		File          string          `json:"file"`
+		URL           string          `json:"url"`
+		Executable    json.RawMessage `json:"executable"`
+		EnvironmentID string          `json:"environment_id"`
+	} `json:"credential_source"`
+}
+
+// credentialsFromJSON builds Google credentials from a service account key or
+// an external_account configuration supplied by a tenant. The JSON is
+// validated first: file, executable and AWS sources would run against the
+// operator's own pod and node, so only URL sources are accepted, and never
+// ones pointing at the metadata server or loopback. Google endpoints in the
+// JSON must be on googleapis.com or the configured universe domain.
+func credentialsFromJSON(ctx context.Context, data []byte, universeDomain string) (*google.Credentials, error) {
+	var f credentialsFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("parse credentials JSON: %w", err)
+	}
+
+	switch f.Type {
+	case "service_account":
+	case "external_account":
+		if err := validateExternalAccount(&f, universeDomain); err != nil {
+			return nil, fmt.Errorf("external_account credentials: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported credentials type %q; expected service_account or external_account", f.Type)
+	}
+
+	return google.CredentialsFromJSONWithParams(ctx, data, google.CredentialsParams{
+		Scopes:         []string{"https://www.googleapis.com/auth/cloud-platform"},
+		UniverseDomain: universeDomain,
+	})
+}
+
+func validateExternalAccount(f *credentialsFile, universeDomain string) error {
+	if err := validateGoogleAPIURL("token_url", f.TokenURL, universeDomain); err != nil {
+		return err
+	}
+	if f.ServiceAccountImpersonationURL != "" {
+		if err := validateGoogleAPIURL("service_account_impersonation_url", f.ServiceAccountImpersonationURL, universeDomain); err != nil {
+			return err
+		}
+	}
+
+	src := f.CredentialSource
+	switch {
+	case src == nil:
+		return fmt.Errorf("credential_source is required")
+	case src.File != "" || len(src.Executable) > 0 || src.EnvironmentID != "":
+		return fmt.Errorf("only url credential sources are supported from a Secret")
+	case src.URL == "":
+		return fmt.Errorf("credential_source.url is required")
+	}
+
+	u, err := url.Parse(src.URL)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		return fmt.Errorf("credential_source.url %q is not an http(s) URL", src.URL)
+	}
+	if isLocalHost(u.Hostname()) {
+		return fmt.Errorf("credential_source.url %q must not point at the metadata server or loopback", src.URL)
+	}
+	return nil
+}
+
+// validateGoogleAPIURL requires an https URL on a googleapis.com host or a
+// host in universeDomain.
+func validateGoogleAPIURL(field, raw, universeDomain string) error {
+	u, err := url.Parse(raw)
+	if err == nil && u.Scheme == "https" {
+		host := u.Hostname()
+		if strings.HasSuffix(host, "."+defaultUniverseDomain) || strings.HasSuffix(host, "."+universeDomain) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%s %q must be an https URL on %s or %s", field, raw, defaultUniverseDomain, universeDomain)
+}
+
+// isLocalHost reports whether host is the metadata server, loopback or a
+// link-local address.
+func isLocalHost(host string) bool {
+	switch strings.ToLower(strings.TrimSuffix(host, ".")) {
+	case "localhost", "metadata", "metadata.google.internal":
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && (ip.IsLoopback() || ip.IsLinkLocalUnicast() || ip.IsUnspecified())
+}
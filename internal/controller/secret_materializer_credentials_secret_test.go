@@ -0,0 +1,311 @@
+package controller
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	secretspizecomv1alpha1 "github.com/zeraholladay/gsm-operator/api/v1alpha1"
+)
+
+// newFakeOAuthServer serves the OAuth2 token endpoint used by service
+// account keys and counts the tokens it issues.
+func newFakeOAuthServer(t *testing.T, calls *atomic.Int32) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := calls.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"access_token": fmt.Sprintf("sa-key-token-%d", n),
+			"token_type":   "Bearer",
+			"expires_in":   3600,
+		})
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// newServiceAccountKeyJSON returns a service account key whose token_uri is tokenURL.
+func newServiceAccountKeyJSON(t *testing.T, tokenURL string) []byte {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatalf("failed to marshal key: %v", err)
+	}
+	data, _ := json.Marshal(map[string]string{
+		"type":           "service_account",
+		"project_id":     "key-proj",
+		"private_key_id": "1",
+		"private_key":    string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})),
+		"client_email":   "key-sa@key-proj.iam.gserviceaccount.com",
+		"token_uri":      tokenURL,
+	})
+	return data
+}
+
+func newCredentialsSecretMaterializer(namespace string, kube kubernetes.Interface) *secretMaterializer {
+	return &secretMaterializer{
+		gsmSecret: &secretspizecomv1alpha1.GSMSecret{
+			ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: namespace},
+			Spec: secretspizecomv1alpha1.GSMSecretSpec{
+				CredentialsSecretRef: &secretspizecomv1alpha1.CredentialsSecretRef{Name: "gcp-key"},
+			},
+		},
+		kubeClientFn: func() (kubernetes.Interface, error) { return kube, nil },
+	}
+}
+
+func TestGetCredentialsSecretRef(t *testing.T) {
+	m := newCredentialsSecretMaterializer("creds-ns", nil)
+	if got := m.getCredentialsSecretRef(); got == nil || got.Name != "gcp-key" {
+		t.Errorf("expected the GSMSecret's ref, got %+v", got)
+	}
+
+	m.store = &secretspizecomv1alpha1.GSMSecretStoreSpec{AuthMode: secretspizecomv1alpha1.AuthModeWorkloadIdentityFederation}
+	if got := m.getCredentialsSecretRef(); got != nil {
+		t.Errorf("expected a WIF store to disable the ref, got %+v", got)
+	}
+
+	m.store = &secretspizecomv1alpha1.GSMSecretStoreSpec{
+		AuthMode:             secretspizecomv1alpha1.AuthModeCredentialsSecret,
+		CredentialsSecretRef: &secretspizecomv1alpha1.CredentialsSecretRef{Name: "store-key", Key: "sa.json"},
+	}
+	if got := m.getCredentialsSecretRef(); got == nil || got.Name != "store-key" {
+		t.Errorf("expected the store's ref, got %+v", got)
+	}
+}
+
+func TestGetGcpCreds_CredentialsSecret(t *testing.T) {
+	t.Setenv("MODE", "TRUSTED_SUBSYSTEM") // the GSMSecret's ref wins over the env mode
+	var issued atomic.Int32
+	oauth := newFakeOAuthServer(t, &issued)
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "gcp-key", Namespace: "creds-ns", ResourceVersion: "1"},
+		Data:       map[string][]byte{secretspizecomv1alpha1.DefaultCredentialsSecretKey: newServiceAccountKeyJSON(t, oauth.URL)},
+	}
+	kube := k8sfake.NewClientset(secret)
+	m := newCredentialsSecretMaterializer("creds-ns", kube)
+	if m.isTrustedSubsystem() {
+		t.Fatal("expected credentialsSecretRef to override MODE=TRUSTED_SUBSYSTEM")
+	}
+
+	creds, err := m.getGcpCreds(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	tok, err := creds.TokenSource.Token()
+	if err != nil || tok.AccessToken != "sa-key-token-1" {
+		t.Fatalf("expected a token from the service account key, got %v, %v", tok, err)
+	}
+	if m.credKey.CredentialsSecret != "gcp-key/credentials.json@1" || m.credKey.KSA != "" {
+		t.Errorf("unexpected cache key %+v", *m.credKey)
+	}
+
+	// Same Secret version: served from the cache.
+	if _, err := newCredentialsSecretMaterializer("creds-ns", kube).getGcpCreds(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := issued.Load(); got != 1 {
+		t.Errorf("expected cached credentials to be reused, got %d token requests", got)
+	}
+
+	// A rotated key gets fresh credentials.
+	secret.ResourceVersion = "2"
+	if _, err := kube.CoreV1().Secrets("creds-ns").Update(context.Background(), secret, metav1.UpdateOptions{}); err != nil {
+		t.Fatalf("failed to rotate Secret: %v", err)
+	}
+	m = newCredentialsSecretMaterializer("creds-ns", kube)
+	if _, err := m.getGcpCreds(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := issued.Load(); got != 2 || m.credKey.CredentialsSecret != "gcp-key/credentials.json@2" {
+		t.Errorf("expected new credentials after rotation, got %d token requests and key %+v", got, *m.credKey)
+	}
+}
+
+func TestGetGcpCreds_CredentialsSecretErrors(t *testing.T) {
+	kube := k8sfake.NewClientset(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "gcp-key", Namespace: "creds-err-ns"},
+		Data:       map[string][]byte{"other": []byte("{}")},
+	})
+
+	m := newCredentialsSecretMaterializer("creds-err-ns", kube)
+	if _, err := m.getGcpCreds(context.Background()); err == nil || !strings.Contains(err.Error(), `no data under key "credentials.json"`) {
+		t.Errorf("expected missing key error, got %v", err)
+	}
+
+	m = newCredentialsSecretMaterializer("creds-missing-ns", kube)
+	if _, err := m.getGcpCreds(context.Background()); err == nil || !strings.Contains(err.Error(), `get credentials Secret "gcp-key"`) {
+		t.Errorf("expected missing Secret error, got %v", err)
+	}
+}
+
+func TestCredentialsFromJSON(t *testing.T) {
+	externalAccount := func(source map[string]any, mutate func(map[string]any)) []byte {
+		cfg := map[string]any{
+			"type":               "external_account",
+			"audience":           testWIFAudience,
+			"subject_token_type": "urn:ietf:params:oauth:token-type:jwt",
+			"token_url":          "https://sts.googleapis.com/v1/token",
+			"credential_source":  source,
+		}
+		if mutate != nil {
+			mutate(cfg)
+		}
+		data, _ := json.Marshal(cfg)
+		return data
+	}
+
+	tests := []struct {
+		name    string
+		data    []byte
+		wantErr string
+	}{
+		{
+			name: "service account key",
+			data: newServiceAccountKeyJSON(t, "https://oauth2.googleapis.com/token"),
+		},
+		{
+			name: "external account with url source",
+			data: externalAccount(map[string]any{"url": "https://idp.example.com/token"}, nil),
+		},
+		{
+			name:    "authorized user rejected",
+			data:    []byte(`{"type":"authorized_user","client_id":"x","client_secret":"y","refresh_token":"z"}`),
+			wantErr: `unsupported credentials type "authorized_user"`,
+		},
+		{
+			name:    "oauth client rejected",
+			data:    []byte(`{"installed":{"client_id":"x"}}`),
+			wantErr: `unsupported credentials type ""`,
+		},
+		{
+			name:    "file source rejected",
+			data:    externalAccount(map[string]any{"file": "/var/run/secrets/kubernetes.io/serviceaccount/token"}, nil),
+			wantErr: "only url credential sources",
+		},
+		{
+			name:    "executable source rejected",
+			data:    externalAccount(map[string]any{"executable": map[string]any{"command": "/bin/sh"}}, nil),
+			wantErr: "only url credential sources",
+		},
+		{
+			name:    "aws source rejected",
+			data:    externalAccount(map[string]any{"environment_id": "aws1", "url": "http://169.254.169.254/latest"}, nil),
+			wantErr: "only url credential sources",
+		},
+		{
+			name:    "metadata server url rejected",
+			data:    externalAccount(map[string]any{"url": "http://metadata.google.internal/computeMetadata/v1/instance/service-accounts/default/identity"}, nil),
+			wantErr: "must not point at the metadata server",
+		},
+		{
+			name:    "link-local url rejected",
+			data:    externalAccount(map[string]any{"url": "http://169.254.169.254/token"}, nil),
+			wantErr: "must not point at the metadata server",
+		},
+		{
+			name: "token_url outside googleapis.com rejected",
+			data: externalAccount(map[string]any{"url": "https://idp.example.com/token"}, func(cfg map[string]any) {
+				cfg["token_url"] = "https://attacker.example.com/token"
+			}),
+			wantErr: "token_url",
+		},
+		{
+			name: "impersonation url outside googleapis.com rejected",
+			data: externalAccount(map[string]any{"url": "https://idp.example.com/token"}, func(cfg map[string]any) {
+				cfg["service_account_impersonation_url"] = "http://iamcredentials.googleapis.com/v1/x"
+			}),
+			wantErr: "service_account_impersonation_url",
+		},
+		{
+			name:    "not JSON",
+			data:    []byte("not json"),
+			wantErr: "parse credentials JSON",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			creds, err := credentialsFromJSON(context.Background(), tt.data, defaultUniverseDomain)
+			if tt.wantErr == "" {
+				if err != nil || creds == nil {
+					t.Fatalf("expected credentials, got %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestMapCredentialsSecretToGSMSecrets(t *testing.T) {
+	direct := newStoreGSMSecret("team", "direct", nil)
+	direct.Spec.CredentialsSecretRef = &secretspizecomv1alpha1.CredentialsSecretRef{Name: "gcp-key"}
+	otherKey := newStoreGSMSecret("team", "other-key", nil)
+	otherKey.Spec.CredentialsSecretRef = &secretspizecomv1alpha1.CredentialsSecretRef{Name: "other"}
+	keyStore := secretspizecomv1alpha1.GSMSecretStoreSpec{
+		AuthMode:             secretspizecomv1alpha1.AuthModeCredentialsSecret,
+		CredentialsSecretRef: &secretspizecomv1alpha1.CredentialsSecretRef{Name: "gcp-key"},
+	}
+
+	objs := []client.Object{
+		direct,
+		otherKey,
+		newStoreGSMSecret("team", "via-store", &secretspizecomv1alpha1.GSMSecretStoreRef{Name: "key-store"}),
+		newStoreGSMSecret("team", "via-cluster-store", &secretspizecomv1alpha1.GSMSecretStoreRef{
+			Kind: secretspizecomv1alpha1.ClusterGSMSecretStoreKind, Name: "cluster-key-store",
+		}),
+		newStoreGSMSecret("elsewhere", "via-cluster-store", &secretspizecomv1alpha1.GSMSecretStoreRef{
+			Kind: secretspizecomv1alpha1.ClusterGSMSecretStoreKind, Name: "cluster-key-store",
+		}),
+		newStoreGSMSecret("team", "wif", nil),
+		&secretspizecomv1alpha1.GSMSecretStore{ObjectMeta: metav1.ObjectMeta{Name: "key-store", Namespace: "team"}, Spec: keyStore},
+		&secretspizecomv1alpha1.ClusterGSMSecretStore{ObjectMeta: metav1.ObjectMeta{Name: "cluster-key-store"}, Spec: keyStore},
+	}
+	c := fake.NewClientBuilder().
+		WithScheme(newTestScheme()).
+		WithObjects(objs...).
+		WithIndex(&secretspizecomv1alpha1.GSMSecret{}, credentialsSecretIndexField, indexGSMSecretCredentialsSecret).
+		WithIndex(&secretspizecomv1alpha1.GSMSecretStore{}, credentialsSecretIndexField, indexStoreCredentialsSecret).
+		WithIndex(&secretspizecomv1alpha1.ClusterGSMSecretStore{}, credentialsSecretIndexField, indexStoreCredentialsSecret).
+		Build()
+	r := &GSMSecretReconciler{Client: c, Scheme: c.Scheme()}
+
+	requests := r.mapCredentialsSecretToGSMSecrets(context.Background(),
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "gcp-key", Namespace: "team"}})
+	got := map[string]bool{}
+	for _, req := range requests {
+		got[req.String()] = true
+	}
+	for _, want := range []string{"team/direct", "team/via-store", "team/via-cluster-store"} {
+		if !got[want] {
+			t.Errorf("expected %s to be enqueued, got %v", want, requests)
+		}
+	}
+	if len(requests) != 3 {
+		t.Errorf("expected exactly 3 requests, got %v", requests)
+	}
+}
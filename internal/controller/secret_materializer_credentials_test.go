@@ -437,6 +437,8 @@ func TestResolvePayloads_WIFAudienceError(t *testing.T) {
 // ==================== GSA Impersonation Tests ====================
 
 func TestGsaCredsFromGcpCreds_ReturnsCredentials(t *testing.T) {
+	iam := newFakeIAMCredentials(t, "test-gsa@project.iam.gserviceaccount.com")
+	t.Setenv("IAM_CREDENTIALS_ENDPOINT", iam.server.URL)
 	m := &secretMaterializer{
 		gsmSecret: &secretspizecomv1alpha1.GSMSecret{
 			ObjectMeta: metav1.ObjectMeta{
@@ -451,10 +453,9 @@ func TestGsaCredsFromGcpCreds_ReturnsCredentials(t *testing.T) {
 		TokenSource: mockTokenSource{token: "mock-access-token"},
 	}
 
-	// gsaCredsFromGcpCreds creates an impersonated token source.
-	// The impersonate library creates the token source successfully,
-	// but token fetches will fail without real GCP credentials.
-	creds, err := m.gsaCredsFromGcpCreds(context.Background(), mockCreds, "test-gsa@project.iam.gserviceaccount.com")
+	// gsaCredsFromGcpCreds mints the first impersonated token eagerly.
+	chain := &impersonationChain{Target: "test-gsa@project.iam.gserviceaccount.com"}
+	creds, err := m.gsaCredsFromGcpCreds(context.Background(), mockCreds, chain)
 	if err != nil {
 		t.Fatalf("unexpected error creating impersonated credentials: %v", err)
 	}
@@ -482,10 +483,12 @@ func TestGsaCredsFromGcpCreds_EmptyGSA(t *testing.T) {
 		TokenSource: mockTokenSource{token: "mock-access-token"},
 	}
 
-	// Empty GSA should fail
-	_, err := m.gsaCredsFromGcpCreds(context.Background(), mockCreds, "")
-	if err == nil {
-		t.Fatal("expected error when impersonating empty GSA")
+	// An empty GSA is rejected by validation, before any call to IAM.
+
+	_, err := m.gsaCredsFromGcpCreds(context.Background(), mockCreds, &impersonationChain{})
+	want := `impersonation hop 1 of 1: "" is not a service account email`
+	if err == nil || err.Error() != want {
+		t.Fatalf("expected error %q, got %v", want, err)
 	}
 }
 
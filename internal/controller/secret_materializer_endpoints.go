@@ -0,0 +1,102 @@
+package controller
+
+/*
+Copyright 2025 Zera Holladay.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+import (
+	"os"
+	"strings"
+
+	secretspizecomv1alpha1 "github.com/zeraholladay/gsm-operator/api/v1alpha1"
+	"github.com/zeraholladay/gsm-operator/internal/authmode"
+)
+
+// defaultUniverseDomain is the public Google Cloud universe.
+const defaultUniverseDomain = "googleapis.com"
+
+// endpointSetting returns the store's value when set, then the env var, then "".
+func endpointSetting(storeValue, env string) string {
+	if storeValue != "" {
+		return storeValue
+	}
+	return strings.TrimSpace(os.Getenv(env))
+}
+
+// endpointStore returns the store whose endpoint settings apply, or nil.
+// Operator-identity modes use only the operator's env settings, so no store
+// can send the operator's tokens to a host of its choosing.
+func (m *secretMaterializer) endpointStore() *secretspizecomv1alpha1.GSMSecretStoreSpec {
+	if m.store == nil || authmode.OperatorIdentity(m.authMode()) {
+		return nil
+	}
+	return m.store
+}
+
+// getUniverseDomain returns the Google Cloud universe domain the default
+// STS, IAM Credentials and Secret Manager endpoints are built from.
+func (m *secretMaterializer) getUniverseDomain() string {
+	var fromStore string
+	if store := m.endpointStore(); store != nil {
+		fromStore = store.UniverseDomain
+	}
+	if v := endpointSetting(fromStore, "UNIVERSE_DOMAIN"); v != "" {
+		return v
+	}
+	return defaultUniverseDomain
+}
+
+// getSecretManagerEndpoint returns the Secret Manager endpoint override as
+// host:port, or "" for the library default in the universe domain.
+func (m *secretMaterializer) getSecretManagerEndpoint() string {
+	var fromStore string
+	if store := m.endpointStore(); store != nil {
+		fromStore = store.Endpoint
+	}
+	return endpointSetting(fromStore, "SECRET_MANAGER_ENDPOINT")
+}
+
+// getSTSEndpoint returns the STS base URL override, or "" for the default.
+func (m *secretMaterializer) getSTSEndpoint() string {
+	var fromStore string
+	if store := m.endpointStore(); store != nil {
+		fromStore = store.STSEndpoint
+	}
+	return strings.TrimSuffix(endpointSetting(fromStore, "STS_ENDPOINT"), "/")
+}
+
+// getIAMCredentialsEndpoint returns the IAM Credentials base URL override, or
+// "" for the default.
+func (m *secretMaterializer) getIAMCredentialsEndpoint() string {
+	var fromStore string
+	if store := m.endpointStore(); store != nil {
+		fromStore = store.IAMCredentialsEndpoint
+	}
+	return strings.TrimSuffix(endpointSetting(fromStore, "IAM_CREDENTIALS_ENDPOINT"), "/")
+}
+
+// stsTokenURL returns the STS token exchange URL.
+func (m *secretMaterializer) stsTokenURL() string {
+	if endpoint := m.getSTSEndpoint(); endpoint != "" {
+		return endpoint + "/v1/token"
+	}
+	return "https://sts." + m.getUniverseDomain() + "/v1/token"
+}
+
+// endpointsKey identifies the endpoints credentials are minted through, for
+// the credential cache.
+func (m *secretMaterializer) endpointsKey() string {
+	return strings.Join([]string{m.stsTokenURL(), m.getIAMCredentialsEndpoint(), m.getUniverseDomain()}, "|")
+}
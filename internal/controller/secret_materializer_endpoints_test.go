@@ -0,0 +1,142 @@
+package controller
+
+import (
+	"strings"
+	"testing"
+
+	secretspizecomv1alpha1 "github.com/zeraholladay/gsm-operator/api/v1alpha1"
+)
+
+func TestEndpoints_Precedence(t *testing.T) {
+	tests := []struct {
+		name              string
+		store             *secretspizecomv1alpha1.GSMSecretStoreSpec
+		env               map[string]string
+		wantSTS           string
+		wantIAM           string
+		wantSecretManager string
+		wantUniverse      string
+	}{
+		{
+			name:         "defaults",
+			wantSTS:      "https://sts.googleapis.com/v1/token",
+			wantUniverse: defaultUniverseDomain,
+		},
+		{
+			name:         "universe domain from env",
+			env:          map[string]string{"UNIVERSE_DOMAIN": "example-universe.goog"},
+			wantSTS:      "https://sts.example-universe.goog/v1/token",
+			wantUniverse: "example-universe.goog",
+		},
+		{
+			name: "operator endpoints",
+			env: map[string]string{
+				"STS_ENDPOINT":             "https://sts.internal.example.com/",
+				"IAM_CREDENTIALS_ENDPOINT": "https://iam.internal.example.com",
+				"SECRET_MANAGER_ENDPOINT":  "sm.internal.example.com:443",
+			},
+			wantSTS:           "https://sts.internal.example.com/v1/token",
+			wantIAM:           "https://iam.internal.example.com",
+			wantSecretManager: "sm.internal.example.com:443",
+			wantUniverse:      defaultUniverseDomain,
+		},
+		{
+			name: "store wins over env",
+			store: &secretspizecomv1alpha1.GSMSecretStoreSpec{
+				STSEndpoint:            "https://sts.store.example.com",
+				IAMCredentialsEndpoint: "https://iam.store.example.com/",
+				Endpoint:               "sm.store.example.com:443",
+				UniverseDomain:         "store-universe.goog",
+			},
+			env: map[string]string{
+				"STS_ENDPOINT":             "https://sts.internal.example.com",
+				"IAM_CREDENTIALS_ENDPOINT": "https://iam.internal.example.com",
+				"SECRET_MANAGER_ENDPOINT":  "sm.internal.example.com:443",
+				"UNIVERSE_DOMAIN":          "example-universe.goog",
+			},
+			wantSTS:           "https://sts.store.example.com/v1/token",
+			wantIAM:           "https://iam.store.example.com",
+			wantSecretManager: "sm.store.example.com:443",
+			wantUniverse:      "store-universe.goog",
+		},
+		{
+			name: "store ignored in operator-identity modes",
+			store: &secretspizecomv1alpha1.GSMSecretStoreSpec{
+				AuthMode:               secretspizecomv1alpha1.AuthModeTrustedSubsystem,
+				STSEndpoint:            "https://sts.store.example.com",
+				IAMCredentialsEndpoint: "https://iam.store.example.com",
+				Endpoint:               "sm.store.example.com:443",
+				UniverseDomain:         "store-universe.goog",
+			},
+			env:               map[string]string{"SECRET_MANAGER_ENDPOINT": "sm.internal.example.com:443"},
+			wantSTS:           "https://sts.googleapis.com/v1/token",
+			wantSecretManager: "sm.internal.example.com:443",
+			wantUniverse:      defaultUniverseDomain,
+		},
+		{
+			name:  "env fills fields the store leaves empty",
+			store: &secretspizecomv1alpha1.GSMSecretStoreSpec{UniverseDomain: "store-universe.goog"},
+			env:   map[string]string{"IAM_CREDENTIALS_ENDPOINT": "https://iam.internal.example.com"},
+
+			wantSTS:      "https://sts.store-universe.goog/v1/token",
+			wantIAM:      "https://iam.internal.example.com",
+			wantUniverse: "store-universe.goog",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, env := range []string{"STS_ENDPOINT", "IAM_CREDENTIALS_ENDPOINT", "SECRET_MANAGER_ENDPOINT", "UNIVERSE_DOMAIN"} {
+				t.Setenv(env, tt.env[env])
+			}
+			m := &secretMaterializer{store: tt.store}
+			if got := m.stsTokenURL(); got != tt.wantSTS {
+				t.Errorf("expected STS token URL %q, got %q", tt.wantSTS, got)
+			}
+			if got := m.getIAMCredentialsEndpoint(); got != tt.wantIAM {
+				t.Errorf("expected IAM Credentials endpoint %q, got %q", tt.wantIAM, got)
+			}
+			if got := m.getSecretManagerEndpoint(); got != tt.wantSecretManager {
+				t.Errorf("expected Secret Manager endpoint %q, got %q", tt.wantSecretManager, got)
+			}
+			if got := m.getUniverseDomain(); got != tt.wantUniverse {
+				t.Errorf("expected universe domain %q, got %q", tt.wantUniverse, got)
+			}
+		})
+	}
+}
+
+func TestEndpointsKey_ChangesWithEndpoints(t *testing.T) {
+	t.Setenv("STS_ENDPOINT", "")
+	t.Setenv("IAM_CREDENTIALS_ENDPOINT", "")
+	t.Setenv("UNIVERSE_DOMAIN", "")
+	base := (&secretMaterializer{}).endpointsKey()
+	other := (&secretMaterializer{store: &secretspizecomv1alpha1.GSMSecretStoreSpec{
+		STSEndpoint: "https://sts.internal.example.com",
+	}}).endpointsKey()
+	if base == other {
+		t.Errorf("expected different cache keys for different STS endpoints, got %q", base)
+	}
+}
+
+func TestValidateGoogleAPIURL_UniverseDomain(t *testing.T) {
+	tests := []struct {
+		raw     string
+		wantErr bool
+	}{
+		{raw: "https://sts.googleapis.com/v1/token"},
+		{raw: "https://sts.example-universe.goog/v1/token"},
+		{raw: "http://sts.example-universe.goog/v1/token", wantErr: true},
+		{raw: "https://sts.attacker.example.com/v1/token", wantErr: true},
+		{raw: "https://example-universe.goog.attacker.example.com/v1/token", wantErr: true},
+	}
+	for _, tt := range tests {
+		err := validateGoogleAPIURL("token_url", tt.raw, "example-universe.goog")
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: expected error %v, got %v", tt.raw, tt.wantErr, err)
+		}
+		if err != nil && !strings.Contains(err.Error(), "example-universe.goog") {
+			t.Errorf("%s: expected error to name the universe domain, got %v", tt.raw, err)
+		}
+	}
+}
@@ -0,0 +1,252 @@
+package controller
+
+/*
+Copyright 2025 Zera Holladay.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	xoauth2 "golang.org/x/oauth2"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+// defaultQuotaRetryAfter is how long to wait after a quota error that carries
+// no retry hint.
+const defaultQuotaRetryAfter = time.Minute
+
+// errorClass categorizes materialization failures. Its value is the status
+// reason reported on the GSMSecret.
+type errorClass string
+
+const (
+	// errorClassAuth means Google credentials could not be obtained or were
+	// rejected. Retried with backoff: tokens are re-minted on the next try.
+	errorClassAuth errorClass = "AuthFailed"
+	// errorClassNotFound means the secret or version does not exist or is
+	// disabled.
+	errorClassNotFound errorClass = "SecretNotFound"
+	// errorClassPermission means the identity may not read the secret.
+	errorClassPermission errorClass = "PermissionDenied"
+	// errorClassParse means a payload could not be mapped to Secret keys, e.g.
+	// it is not JSON or a JSON Pointer does not resolve.
+	errorClassParse errorClass = "ParseFailed"
+	// errorClassQuota means a quota was exhausted. Retried after the delay
+	// the server asked for.
+	errorClassQuota errorClass = "QuotaExceeded"
+	// errorClassUnavailable means a Google API could not be reached or
+	// failed server-side. Retried with backoff.
+	errorClassUnavailable errorClass = "Unavailable"
+	// errorClassUnknown is the class of an unclassified error. Retried with
+	// backoff.
+	errorClassUnknown errorClass = "FetchFailed"
+)
+
+// permanent reports whether retrying cannot succeed until something changes,
+// such as the spec, an IAM binding or a new secret version.
+func (c errorClass) permanent() bool {
+	switch c {
+	case errorClassNotFound, errorClassPermission, errorClassParse:
+		return true
+	}
+	return false
+}
+
+// materializeError is a classified materialization failure.
+type materializeError struct {
+	Class errorClass
+	// RetryAfter is the delay the server asked for, for quota errors.
+	RetryAfter time.Duration
+	Err        error
+}
+
+func (e *materializeError) Error() string { return e.Err.Error() }
+
+func (e *materializeError) Unwrap() error { return e.Err }
+
+// newMaterializeError wraps err with class, unless err already carries a
+// more specific class in its chain.
+func newMaterializeError(class errorClass, err error) error {
+	if classified := classifyError(err); classified != nil {
+		return &materializeError{Class: classified.Class, RetryAfter: classified.RetryAfter, Err: err}
+	}
+	return &materializeError{Class: class, Err: err}
+}
+
+// classifyError returns the class of err, from a *materializeError in its
+// chain or from the gRPC, Google API or OAuth2 error it wraps. It returns nil
+// when err cannot be classified.
+func classifyError(err error) *materializeError {
+	if err == nil {
+		return nil
+	}
+	var merr *materializeError
+	if errors.As(err, &merr) {
+		return merr
+	}
+
+	if s, ok := status.FromError(err); ok {
+		switch s.Code() {
+		case codes.NotFound, codes.FailedPrecondition:
+			return &materializeError{Class: errorClassNotFound, Err: err}
+		case codes.PermissionDenied:
+			return &materializeError{Class: errorClassPermission, Err: err}
+		case codes.Unauthenticated:
+			return &materializeError{Class: errorClassAuth, Err: err}
+		case codes.ResourceExhausted:
+			return &materializeError{Class: errorClassQuota, RetryAfter: grpcRetryDelay(s), Err: err}
+		case codes.Unavailable, codes.DeadlineExceeded, codes.Aborted, codes.Internal:
+			return &materializeError{Class: errorClassUnavailable, Err: err}
+		}
+	}
+
+	var gerr *googleapi.Error
+	if errors.As(err, &gerr) {
+		if class, ok := httpStatusClass(gerr.Code); ok {
+			return &materializeError{Class: class, RetryAfter: retryAfterHeader(gerr.Header), Err: err}
+		}
+	}
+
+	var rerr *xoauth2.RetrieveError
+	if errors.As(err, &rerr) && rerr.Response != nil {
+		class, ok := httpStatusClass(rerr.Response.StatusCode)
+		if !ok || class == errorClassNotFound || class == errorClassPermission {
+			// A token endpoint refusing the exchange is an auth failure.
+			class = errorClassAuth
+		}
+		return &materializeError{Class: class, RetryAfter: retryAfterHeader(rerr.Response.Header), Err: err}
+	}
+
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || errors.As(err, &netErr) {
+		return &materializeError{Class: errorClassUnavailable, Err: err}
+	}
+	return nil
+}
+
+// httpStatusClass maps an HTTP status code from a Google API to a class.
+func httpStatusClass(code int) (errorClass, bool) {
+	switch {
+	case code == http.StatusNotFound:
+		return errorClassNotFound, true
+	case code == http.StatusForbidden:
+		return errorClassPermission, true
+	case code == http.StatusUnauthorized:
+		return errorClassAuth, true
+	case code == http.StatusTooManyRequests:
+		return errorClassQuota, true
+	case code >= http.StatusInternalServerError:
+		return errorClassUnavailable, true
+	}
+	return "", false
+}
+
+// grpcRetryDelay returns the RetryInfo delay attached to s, if any.
+func grpcRetryDelay(s *status.Status) time.Duration {
+	for _, d := range s.Details() {
+		if info, ok := d.(*errdetails.RetryInfo); ok && info.GetRetryDelay() != nil {
+			return info.GetRetryDelay().AsDuration()
+		}
+	}
+	return 0
+}
+
+// retryAfterHeader parses a Retry-After header given in seconds.
+func retryAfterHeader(h http.Header) time.Duration {
+	if seconds, err := strconv.Atoi(h.Get("Retry-After")); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	return 0
+}
+
+// entryErrors aggregates the failures of several GSMSecret entries, so every
+// broken entry is reported at once.
+type entryErrors struct {
+	errs  []error
+	total int
+}
+
+func (e *entryErrors) Error() string {
+	msgs := make([]string, len(e.errs))
+	for i, err := range e.errs {
+		msgs[i] = err.Error()
+	}
+	return fmt.Sprintf("%d of %d entries failed: %s", len(e.errs), e.total, strings.Join(msgs, "; "))
+}
+
+func (e *entryErrors) Unwrap() []error { return e.errs }
+
+// aggregateEntryErrors combines the failures of total entries into one
+// error. A single failure is returned as is. Otherwise the GSMSecret is
+// retried like its most retryable failure: the first transient or
+// unclassified error decides, and only if every failure is permanent is the
+// aggregate permanent.
+func aggregateEntryErrors(errs []error, total int) error {
+	if len(errs) == 1 {
+		return errs[0]
+	}
+	agg := &entryErrors{errs: errs, total: total}
+	var decisive *materializeError
+	for _, err := range errs {
+		merr := classifyError(err)
+		if merr == nil {
+			merr = &materializeError{Class: errorClassUnknown}
+		}
+		if !merr.Class.permanent() {
+			decisive = merr
+			break
+		}
+		if decisive == nil {
+			decisive = merr
+		}
+	}
+	return &materializeError{Class: decisive.Class, RetryAfter: decisive.RetryAfter, Err: agg}
+}
+
+// fetchErrorResult maps a resolvePayloads error to a status reason and the
+// result Reconcile should return:
+//   - permanent errors are not retried with backoff. Spec and store changes
+//     trigger a reconcile, and fixes outside the cluster (IAM bindings, new
+//     secret versions) are picked up on the next resync.
+//   - quota errors are requeued after the server's retry delay, plus jitter.
+//   - every other error is returned, so the controller backs off exponentially.
+func fetchErrorResult(err error) (string, ctrl.Result, error) {
+	merr := classifyError(err)
+	switch {
+	case merr == nil:
+		return "FetchFailed", ctrl.Result{}, err
+	case merr.Class.permanent():
+		return string(merr.Class), ctrl.Result{RequeueAfter: resyncAfter()}, nil
+	case merr.Class == errorClassQuota:
+		retryAfter := merr.RetryAfter
+		if retryAfter <= 0 {
+			retryAfter = defaultQuotaRetryAfter
+		}
+		return string(merr.Class), ctrl.Result{RequeueAfter: jitter(retryAfter)}, nil
+	default:
+		return string(merr.Class), ctrl.Result{}, err
+	}
+}
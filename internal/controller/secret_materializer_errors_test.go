@@ -0,0 +1,190 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	xoauth2 "golang.org/x/oauth2"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+func quotaStatusError(t *testing.T, delay time.Duration) error {
+	t.Helper()
+	s, err := status.New(codes.ResourceExhausted, "quota exceeded").
+		WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(delay)})
+	if err != nil {
+		t.Fatalf("failed to attach RetryInfo: %v", err)
+	}
+	return s.Err()
+}
+
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		want       errorClass
+		retryAfter time.Duration
+	}{
+		{name: "unclassified", err: errors.New("boom")},
+		{name: "grpc not found", err: status.Error(codes.NotFound, "secret not found"), want: errorClassNotFound},
+		{name: "grpc disabled version", err: status.Error(codes.FailedPrecondition, "version is disabled"), want: errorClassNotFound},
+		{name: "wrapped grpc permission denied", err: fmt.Errorf("fetch: %w", status.Error(codes.PermissionDenied, "denied")), want: errorClassPermission},
+		{name: "grpc unauthenticated", err: status.Error(codes.Unauthenticated, "bad token"), want: errorClassAuth},
+		{name: "grpc unavailable", err: status.Error(codes.Unavailable, "try again"), want: errorClassUnavailable},
+		{name: "grpc quota with retry info", err: fmt.Errorf("fetch: %w", quotaStatusError(t, 42*time.Second)), want: errorClassQuota, retryAfter: 42 * time.Second},
+		{name: "grpc quota without retry info", err: status.Error(codes.ResourceExhausted, "quota"), want: errorClassQuota},
+		{name: "googleapi forbidden", err: fmt.Errorf("impersonate: %w", &googleapi.Error{Code: http.StatusForbidden}), want: errorClassPermission},
+		{
+			name:       "googleapi too many requests",
+			err:        &googleapi.Error{Code: http.StatusTooManyRequests, Header: http.Header{"Retry-After": []string{"30"}}},
+			want:       errorClassQuota,
+			retryAfter: 30 * time.Second,
+		},
+		{name: "googleapi server error", err: &googleapi.Error{Code: http.StatusBadGateway}, want: errorClassUnavailable},
+		{name: "googleapi bad request", err: &googleapi.Error{Code: http.StatusBadRequest}},
+		{name: "token endpoint rejects grant", err: &xoauth2.RetrieveError{Response: &http.Response{StatusCode: http.StatusBadRequest}}, want: errorClassAuth},
+		{name: "token endpoint forbidden", err: &xoauth2.RetrieveError{Response: &http.Response{StatusCode: http.StatusForbidden}}, want: errorClassAuth},
+		{name: "token endpoint unavailable", err: &xoauth2.RetrieveError{Response: &http.Response{StatusCode: http.StatusServiceUnavailable}}, want: errorClassUnavailable},
+		{name: "deadline exceeded", err: fmt.Errorf("call: %w", context.DeadlineExceeded), want: errorClassUnavailable},
+		{name: "typed", err: fmt.Errorf("outer: %w", &materializeError{Class: errorClassParse, Err: errors.New("bad pointer")}), want: errorClassParse},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := classifyError(tt.err)
+			if tt.want == "" {
+				if got != nil {
+					t.Fatalf("expected unclassified, got %s", got.Class)
+				}
+				return
+			}
+			if got == nil || got.Class != tt.want {
+				t.Fatalf("expected class %s, got %+v", tt.want, got)
+			}
+			if got.RetryAfter != tt.retryAfter {
+				t.Errorf("expected retry after %s, got %s", tt.retryAfter, got.RetryAfter)
+			}
+		})
+	}
+}
+
+func TestNewMaterializeError_KeepsSpecificClass(t *testing.T) {
+	err := newMaterializeError(errorClassAuth, fmt.Errorf("obtain Google credentials: %w", &googleapi.Error{Code: http.StatusForbidden}))
+	if got := classifyError(err); got == nil || got.Class != errorClassPermission {
+		t.Errorf("expected the wrapped permission error to win, got %+v", got)
+	}
+
+	err = newMaterializeError(errorClassAuth, errors.New("WIFAudience not set"))
+	if got := classifyError(err); got == nil || got.Class != errorClassAuth {
+		t.Errorf("expected the default class, got %+v", got)
+	}
+}
+
+func TestFetchErrorResult(t *testing.T) {
+	t.Setenv("RESYNC_INTERVAL_SECONDS", "120")
+	t.Setenv("RESYNC_JITTER_PERCENT", "0")
+
+	tests := []struct {
+		name             string
+		err              error
+		wantReason       string
+		wantRequeueAfter time.Duration
+		wantErr          bool
+	}{
+		{name: "unclassified backs off", err: errors.New("boom"), wantReason: "FetchFailed", wantErr: true},
+		{name: "unavailable backs off", err: status.Error(codes.Unavailable, "down"), wantReason: "Unavailable", wantErr: true},
+		{name: "auth backs off", err: status.Error(codes.Unauthenticated, "bad token"), wantReason: "AuthFailed", wantErr: true},
+		{name: "not found waits", err: status.Error(codes.NotFound, "missing"), wantReason: "SecretNotFound", wantRequeueAfter: 2 * time.Minute},
+		{name: "permission waits", err: status.Error(codes.PermissionDenied, "denied"), wantReason: "PermissionDenied", wantRequeueAfter: 2 * time.Minute},
+		{name: "parse waits", err: &materializeError{Class: errorClassParse, Err: errors.New("bad pointer")}, wantReason: "ParseFailed", wantRequeueAfter: 2 * time.Minute},
+		{name: "quota honours retry delay", err: quotaStatusError(t, 15*time.Second), wantReason: "QuotaExceeded", wantRequeueAfter: 15 * time.Second},
+		{name: "quota default delay", err: status.Error(codes.ResourceExhausted, "quota"), wantReason: "QuotaExceeded", wantRequeueAfter: defaultQuotaRetryAfter},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reason, result, err := fetchErrorResult(tt.err)
+			if reason != tt.wantReason {
+				t.Errorf("expected reason %q, got %q", tt.wantReason, reason)
+			}
+			if result.RequeueAfter != tt.wantRequeueAfter {
+				t.Errorf("expected requeue after %s, got %s", tt.wantRequeueAfter, result.RequeueAfter)
+			}
+			if (err != nil) != tt.wantErr {
+				t.Errorf("expected error %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestAggregateEntryErrors(t *testing.T) {
+	notFound := &materializeError{Class: errorClassNotFound, Err: errors.New("missing")}
+	parse := &materializeError{Class: errorClassParse, Err: errors.New("not json")}
+	quota := &materializeError{Class: errorClassQuota, RetryAfter: 30 * time.Second, Err: errors.New("quota")}
+	unknown := errors.New("invalid GSMSecret entry")
+
+	if err := aggregateEntryErrors([]error{notFound}, 3); err != notFound {
+		t.Errorf("expected a single failure to be returned as is, got %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		errs       []error
+		wantClass  errorClass
+		wantRetry  time.Duration
+		wantPrefix string
+	}{
+		{"all permanent", []error{notFound, parse}, errorClassNotFound, 0, "2 of 4 entries failed: missing; not json"},
+		{"transient wins", []error{parse, quota, notFound}, errorClassQuota, 30 * time.Second, "3 of 4 entries failed: "},
+		{"unclassified is retried", []error{notFound, unknown}, errorClassUnknown, 0, "2 of 4 entries failed: "},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := aggregateEntryErrors(tt.errs, 4)
+			got := classifyError(err)
+			if got == nil || got.Class != tt.wantClass || got.RetryAfter != tt.wantRetry {
+				t.Fatalf("expected class %s retry %v, got %+v", tt.wantClass, tt.wantRetry, got)
+			}
+			if !strings.HasPrefix(err.Error(), tt.wantPrefix) {
+				t.Errorf("expected message starting with %q, got %q", tt.wantPrefix, err.Error())
+			}
+			for _, e := range tt.errs {
+				if !errors.Is(err, e) {
+					t.Errorf("expected %v to be in the aggregate", e)
+				}
+			}
+		})
+	}
+
+	// Unclassified failures keep the FetchFailed reason and backoff.
+	reason, _, retErr := fetchErrorResult(aggregateEntryErrors([]error{notFound, unknown}, 2))
+	if reason != "FetchFailed" || retErr == nil {
+		t.Errorf("expected FetchFailed with backoff, got reason=%q err=%v", reason, retErr)
+	}
+}
+
+func TestGetFetchConcurrency(t *testing.T) {
+	for _, tt := range []struct {
+		env  string
+		want int
+	}{
+		{"", defaultFetchConcurrency},
+		{"16", 16},
+		{"0", defaultFetchConcurrency},
+		{"many", defaultFetchConcurrency},
+	} {
+		t.Setenv("FETCH_CONCURRENCY", tt.env)
+		if got := getFetchConcurrency(); got != tt.want {
+			t.Errorf("FETCH_CONCURRENCY=%q: expected %d, got %d", tt.env, tt.want, got)
+		}
+	}
+}
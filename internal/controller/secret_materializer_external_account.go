@@ -0,0 +1,253 @@
+package controller
+
+/*
+Copyright 2025 Zera Holladay.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	xoauth2 "golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+	"golang.org/x/oauth2/google/externalaccount"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	secretspizecomv1alpha1 "github.com/zeraholladay/gsm-operator/api/v1alpha1"
+)
+
+// jwtSubjectTokenType is the STS subject token type of a KSA token.
+const jwtSubjectTokenType = "urn:ietf:params:oauth:token-type:jwt"
+
+// getExternalAccountConfigPath returns the path of the operator's
+// external_account credential configuration, used in ExternalAccount mode.
+func getExternalAccountConfigPath() string {
+	return strings.TrimSpace(os.Getenv("EXTERNAL_ACCOUNT_CONFIG"))
+}
+
+// ksaSubjectTokenSupplier supplies the tenant KSA token, requested through the
+// Kubernetes TokenRequest API, as the subject token of an STS exchange. It is
+// the supplier used in Workload Identity Federation mode; ExternalAccount mode
+// uses the file, URL, executable or AWS source of the configuration instead.
+type ksaSubjectTokenSupplier struct {
+	m *secretMaterializer
+}
+
+// SubjectToken implements externalaccount.SubjectTokenSupplier.
+func (s *ksaSubjectTokenSupplier) SubjectToken(ctx context.Context, _ externalaccount.SupplierOptions) (string, error) {
+	token, err := s.m.requestKSAToken(ctx)
+	if err != nil {
+		return "", fmt.Errorf("request KSA token: %w", err)
+	}
+	return token, nil
+}
+
+// externalAccountTokenSource mints a Google access token by fetching a fresh
+// subject token and exchanging it with STS. A new library token source is
+// built on every call because the library caches tokens with its own, much
+// shorter, refresh margin; the caller wraps this in a ReuseTokenSource.
+type externalAccountTokenSource struct {
+	ctx     context.Context
+	config  externalaccount.Config
+	timeout time.Duration
+}
+
+// Token implements oauth2.TokenSource.
+func (s *externalAccountTokenSource) Token() (*xoauth2.Token, error) {
+	ctx, cancel := context.WithTimeout(s.ctx, s.timeout)
+	defer cancel()
+	// The library makes its STS and impersonation calls with this client.
+	ctx = context.WithValue(ctx, xoauth2.HTTPClient, &http.Client{
+		Transport: &tokenExchangeTransport{base: http.DefaultTransport, tokenURL: s.config.TokenURL},
+	})
+
+	ts, err := externalaccount.NewTokenSource(ctx, s.config)
+	if err != nil {
+		return nil, err
+	}
+	return ts.Token()
+}
+
+// externalAccountCreds returns refreshing credentials for config. The first
+// token is minted here so a broken subject token source or a rejected
+// exchange fails the current reconcile.
+func (m *secretMaterializer) externalAccountCreds(ctx context.Context, config externalaccount.Config) (*google.Credentials, error) {
+	config.Scopes = []string{"https://www.googleapis.com/auth/cloud-platform"}
+	// Spell out the library's default so the exchange can be observed.
+	if config.TokenURL == "" {
+		config.TokenURL = m.stsTokenURL()
+	}
+	ts := &externalAccountTokenSource{
+		ctx:     context.WithoutCancel(ctx),
+		config:  config,
+		timeout: time.Duration(m.getHTTPRequestTimeoutSeconds()) * time.Second,
+	}
+	token, err := ts.Token()
+	if err != nil {
+		return nil, err
+	}
+	return &google.Credentials{
+		TokenSource: xoauth2.ReuseTokenSourceWithExpiry(token, ts, defaultCredentialRefreshBefore),
+	}, nil
+}
+
+// externalAccountFile is the external_account credential configuration
+// format written by "gcloud iam workload-identity-pools create-cred-config".
+type externalAccountFile struct {
+	Type                           string `json:"type"`
+	Audience                       string `json:"audience"`
+	SubjectTokenType               string `json:"subject_token_type"`
+	TokenURL                       string `json:"token_url"`
+	TokenInfoURL                   string `json:"token_info_url"`
+	ServiceAccountImpersonationURL string `json:"service_account_impersonation_url"`
+	ServiceAccountImpersonation    struct {
+		TokenLifetimeSeconds int `json:"token_lifetime_seconds"`
+	} `json:"service_account_impersonation"`
+	ClientID                 string                            `json:"client_id"`
+	ClientSecret             string                            `json:"client_secret"`
+	CredentialSource         *externalaccount.CredentialSource `json:"credential_source"`
+	QuotaProjectID           string                            `json:"quota_project_id"`
+	WorkforcePoolUserProject string                            `json:"workforce_pool_user_project"`
+	UniverseDomain           string                            `json:"universe_domain"`
+}
+
+// allowExecutablesEnv must be "1" on the manager before the Google auth
+// library runs an executable credential source.
+const allowExecutablesEnv = "GOOGLE_EXTERNAL_ACCOUNT_ALLOW_EXECUTABLES"
+
+// loadExternalAccountConfig reads the operator's external_account
+// configuration. It also returns an identity that changes whenever the file
+// does, so an updated configuration is never served from the cache.
+func loadExternalAccountConfig(path string) (externalaccount.Config, string, error) {
+	if path == "" {
+		return externalaccount.Config{}, "", fmt.Errorf("the EXTERNAL_ACCOUNT_CONFIG env var must point at an external_account configuration")
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return externalaccount.Config{}, "", fmt.Errorf("read external_account configuration: %w", err)
+	}
+	config, err := parseExternalAccountConfig(data, path)
+	if err != nil {
+		return externalaccount.Config{}, "", err
+	}
+	sum := sha256.Sum256(data)
+	return config, path + "@" + hex.EncodeToString(sum[:8]), nil
+}
+
+// parseExternalAccountConfig parses the external_account configuration read
+// from source. An executable source is rejected up front unless the manager
+// allows executables; the library would otherwise only fail at the first
+// token request.
+func parseExternalAccountConfig(data []byte, source string) (externalaccount.Config, error) {
+	var f externalAccountFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return externalaccount.Config{}, fmt.Errorf("parse external_account configuration %s: %w", source, err)
+	}
+	if f.Type != "external_account" {
+		return externalaccount.Config{}, fmt.Errorf("%s: unsupported credentials type %q; expected external_account", source, f.Type)
+	}
+	if f.CredentialSource == nil {
+		return externalaccount.Config{}, fmt.Errorf("%s: credential_source is required", source)
+	}
+	if f.CredentialSource.Executable != nil && os.Getenv(allowExecutablesEnv) != "1" {
+		return externalaccount.Config{}, fmt.Errorf("%s: executable credential sources require %s=1 on the manager", source, allowExecutablesEnv)
+	}
+
+	return externalaccount.Config{
+		Audience:                       f.Audience,
+		SubjectTokenType:               f.SubjectTokenType,
+		TokenURL:                       f.TokenURL,
+		TokenInfoURL:                   f.TokenInfoURL,
+		ServiceAccountImpersonationURL: f.ServiceAccountImpersonationURL,
+		ServiceAccountImpersonationLifetimeSeconds: f.ServiceAccountImpersonation.TokenLifetimeSeconds,
+		ClientID:                 f.ClientID,
+		ClientSecret:             f.ClientSecret,
+		CredentialSource:         f.CredentialSource,
+		QuotaProjectID:           f.QuotaProjectID,
+		WorkforcePoolUserProject: f.WorkforcePoolUserProject,
+		UniverseDomain:           f.UniverseDomain,
+	}, nil
+}
+
+// externalAccountConfig returns the external_account configuration for
+// ExternalAccount mode: the store's externalAccountConfigRef, or else the
+// operator's EXTERNAL_ACCOUNT_CONFIG. The identity it also returns changes
+// whenever the configuration does.
+func (m *secretMaterializer) externalAccountConfig(ctx context.Context) (externalaccount.Config, string, error) {
+	if m.store != nil && m.store.ExternalAccountConfigRef != nil {
+		return m.readExternalAccountConfigRef(ctx, m.store.ExternalAccountConfigRef)
+	}
+	return loadExternalAccountConfig(getExternalAccountConfigPath())
+}
+
+// readExternalAccountConfigRef reads and parses the configuration a store
+// references. Its identity carries the object's resourceVersion, so an
+// edited configuration maps to a new cache entry.
+func (m *secretMaterializer) readExternalAccountConfigRef(
+	ctx context.Context,
+	ref *secretspizecomv1alpha1.ExternalAccountConfigRef,
+) (externalaccount.Config, string, error) {
+	kind := ref.Kind
+	if kind == "" {
+		kind = secretspizecomv1alpha1.ExternalAccountConfigRefKindSecret
+	}
+	key := ref.Key
+	if key == "" {
+		key = secretspizecomv1alpha1.DefaultCredentialsSecretKey
+	}
+	source := fmt.Sprintf("%s %s/%s", kind, ref.Namespace, ref.Name)
+
+	kc, err := m.getKubeClient()
+	if err != nil {
+		return externalaccount.Config{}, "", fmt.Errorf("build kube client: %w", err)
+	}
+	var data []byte
+	var resourceVersion string
+	switch kind {
+	case secretspizecomv1alpha1.ExternalAccountConfigRefKindConfigMap:
+		cm, err := kc.CoreV1().ConfigMaps(ref.Namespace).Get(ctx, ref.Name, metav1.GetOptions{})
+		if err != nil {
+			return externalaccount.Config{}, "", fmt.Errorf("get %s: %w", source, err)
+		}
+		data = []byte(cm.Data[key])
+		if len(data) == 0 {
+			data = cm.BinaryData[key]
+		}
+		resourceVersion = cm.ResourceVersion
+	default:
+		secret, err := kc.CoreV1().Secrets(ref.Namespace).Get(ctx, ref.Name, metav1.GetOptions{})
+		if err != nil {
+			return externalaccount.Config{}, "", fmt.Errorf("get %s: %w", source, err)
+		}
+		data = secret.Data[key]
+		resourceVersion = secret.ResourceVersion
+	}
+	if len(data) == 0 {
+		return externalaccount.Config{}, "", fmt.Errorf("%s has no data under key %q", source, key)
+	}
+
+	config, err := parseExternalAccountConfig(data, source)
+	if err != nil {
+		return externalaccount.Config{}, "", err
+	}
+	return config, fmt.Sprintf("%s/%s/%s/%s@%s", kind, ref.Namespace, ref.Name, key, resourceVersion), nil
+}
@@ -0,0 +1,250 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+
+	secretspizecomv1alpha1 "github.com/zeraholladay/gsm-operator/api/v1alpha1"
+)
+
+const testExternalAudience = "//iam.googleapis.com/projects/456/locations/global/workloadIdentityPools/eks/providers/oidc"
+
+// writeExternalAccountConfig writes an external_account configuration whose
+// subject token is read from a file and exchanged with sts, and returns the
+// paths of the configuration and of the subject token file.
+func writeExternalAccountConfig(t *testing.T, sts *fakeSTS) (string, string) {
+	t.Helper()
+	dir := t.TempDir()
+	tokenPath := filepath.Join(dir, "token")
+	if err := os.WriteFile(tokenPath, []byte("oidc-token-1"), 0o600); err != nil {
+		t.Fatalf("failed to write subject token: %v", err)
+	}
+	data, _ := json.Marshal(map[string]any{
+		"type":               "external_account",
+		"audience":           testExternalAudience,
+		"subject_token_type": "urn:ietf:params:oauth:token-type:id_token",
+		"token_url":          sts.server.URL + "/v1/token",
+		"credential_source":  map[string]any{"file": tokenPath},
+	})
+	configPath := filepath.Join(dir, "config.json")
+	if err := os.WriteFile(configPath, data, 0o600); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	return configPath, tokenPath
+}
+
+func TestAuthMode(t *testing.T) {
+	ref := &secretspizecomv1alpha1.CredentialsSecretRef{Name: "gcp-key"}
+	tests := []struct {
+		name  string
+		env   string
+		store *secretspizecomv1alpha1.GSMSecretStoreSpec
+		ref   *secretspizecomv1alpha1.CredentialsSecretRef
+		want  secretspizecomv1alpha1.GSMSecretStoreAuthMode
+	}{
+		{name: "default", want: secretspizecomv1alpha1.AuthModeWorkloadIdentityFederation},
+		{name: "trusted env", env: "TRUSTED_SUBSYSTEM", want: secretspizecomv1alpha1.AuthModeTrustedSubsystem},
+		{name: "external account env", env: "EXTERNAL_ACCOUNT", want: secretspizecomv1alpha1.AuthModeExternalAccount},
+		{name: "credentialsSecretRef wins over env", env: "EXTERNAL_ACCOUNT", ref: ref, want: secretspizecomv1alpha1.AuthModeCredentialsSecret},
+		{
+			name:  "store wins over env",
+			env:   "TRUSTED_SUBSYSTEM",
+			store: &secretspizecomv1alpha1.GSMSecretStoreSpec{AuthMode: secretspizecomv1alpha1.AuthModeExternalAccount},
+			want:  secretspizecomv1alpha1.AuthModeExternalAccount,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("MODE", tt.env)
+			m := &secretMaterializer{
+				gsmSecret: &secretspizecomv1alpha1.GSMSecret{Spec: secretspizecomv1alpha1.GSMSecretSpec{CredentialsSecretRef: tt.ref}},
+				store:     tt.store,
+			}
+			if got := m.authMode(); got != tt.want {
+				t.Errorf("authMode() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadExternalAccountConfig(t *testing.T) {
+	sts := newFakeSTS(t, 3600)
+	configPath, _ := writeExternalAccountConfig(t, sts)
+
+	config, id, err := loadExternalAccountConfig(configPath)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if config.Audience != testExternalAudience || config.CredentialSource == nil || config.CredentialSource.File == "" {
+		t.Errorf("unexpected config %+v", config)
+	}
+	if !strings.HasPrefix(id, configPath+"@") {
+		t.Errorf("expected id to start with the path, got %q", id)
+	}
+
+	// Rewriting the file changes its identity.
+	data, _ := os.ReadFile(configPath)
+	if err := os.WriteFile(configPath, append(data, '\n'), 0o600); err != nil {
+		t.Fatalf("failed to rewrite config: %v", err)
+	}
+	if _, id2, err := loadExternalAccountConfig(configPath); err != nil || id2 == id {
+		t.Errorf("expected a new identity after the file changed, got %q, %v", id2, err)
+	}
+
+	dir := t.TempDir()
+	for name, tt := range map[string]struct {
+		content string
+		wantErr string
+	}{
+		"service account key":  {content: `{"type":"service_account"}`, wantErr: "expected external_account"},
+		"no credential source": {content: `{"type":"external_account","audience":"a"}`, wantErr: "credential_source is required"},
+		"not JSON":             {content: "nope", wantErr: "parse external_account configuration"},
+		"executable": {
+			content: `{"type":"external_account","credential_source":{"executable":{"command":"/bin/token"}}}`,
+			wantErr: "executable credential sources require GOOGLE_EXTERNAL_ACCOUNT_ALLOW_EXECUTABLES=1",
+		},
+	} {
+		path := filepath.Join(dir, strings.ReplaceAll(name, " ", "-"))
+		if err := os.WriteFile(path, []byte(tt.content), 0o600); err != nil {
+			t.Fatalf("failed to write config: %v", err)
+		}
+		if _, _, err := loadExternalAccountConfig(path); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("%s: expected error containing %q, got %v", name, tt.wantErr, err)
+		}
+	}
+
+	if _, _, err := loadExternalAccountConfig(""); err == nil || !strings.Contains(err.Error(), "EXTERNAL_ACCOUNT_CONFIG") {
+		t.Errorf("expected missing path error, got %v", err)
+	}
+}
+
+func TestParseExternalAccountConfig_AllowExecutables(t *testing.T) {
+	data := []byte(`{"type":"external_account","credential_source":{"executable":{"command":"/bin/token"}}}`)
+	t.Setenv("GOOGLE_EXTERNAL_ACCOUNT_ALLOW_EXECUTABLES", "1")
+	config, err := parseExternalAccountConfig(data, "test")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if config.CredentialSource.Executable == nil || config.CredentialSource.Executable.Command != "/bin/token" {
+		t.Errorf("unexpected credential source %+v", config.CredentialSource)
+	}
+}
+
+func TestGetGcpCreds_ExternalAccountStoreConfigRef(t *testing.T) {
+	sts := newFakeSTS(t, 3600)
+	sts.audience = testExternalAudience
+	configPath, _ := writeExternalAccountConfig(t, sts)
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		t.Fatalf("failed to read config: %v", err)
+	}
+	// The operator-wide configuration is not used when the store has its own.
+	t.Setenv("EXTERNAL_ACCOUNT_CONFIG", "")
+
+	kube := fake.NewClientset(&corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "ext", Namespace: "ops", ResourceVersion: "7"},
+		Data:       map[string]string{"config.json": string(data)},
+	})
+	store := &secretspizecomv1alpha1.GSMSecretStoreSpec{
+		AuthMode: secretspizecomv1alpha1.AuthModeExternalAccount,
+		ExternalAccountConfigRef: &secretspizecomv1alpha1.ExternalAccountConfigRef{
+			Kind: secretspizecomv1alpha1.ExternalAccountConfigRefKindConfigMap, Name: "ext", Namespace: "ops", Key: "config.json",
+		},
+	}
+	m := &secretMaterializer{
+		gsmSecret: &secretspizecomv1alpha1.GSMSecret{
+			ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "external-ns"},
+		},
+		store:        store,
+		kubeClientFn: func() (kubernetes.Interface, error) { return kube, nil },
+	}
+	t.Cleanup(func() {
+		if m.credKey != nil {
+			gcpCredentialCache.evict(*m.credKey)
+		}
+	})
+
+	if _, err := m.getGcpCreds(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if subject, _ := sts.lastSubject(); subject != "oidc-token-1" {
+		t.Errorf("expected the store configuration's subject token to be exchanged, got %q", subject)
+	}
+	if want := "ConfigMap/ops/ext/config.json@7"; m.credKey.ExternalAccount != want {
+		t.Errorf("expected cache identity %q, got %q", want, m.credKey.ExternalAccount)
+	}
+
+	// A missing key names the object it looked in.
+	store.ExternalAccountConfigRef.Key = "missing.json"
+	if _, err := m.getGcpCreds(context.Background()); err == nil || !strings.Contains(err.Error(), `ConfigMap ops/ext has no data under key "missing.json"`) {
+		t.Errorf("expected missing key error, got %v", err)
+	}
+}
+
+func TestGetGcpCreds_ExternalAccountFileSource(t *testing.T) {
+	sts := newFakeSTS(t, 60)
+	sts.audience = testExternalAudience
+	configPath, tokenPath := writeExternalAccountConfig(t, sts)
+	t.Setenv("MODE", "EXTERNAL_ACCOUNT")
+	t.Setenv("EXTERNAL_ACCOUNT_CONFIG", configPath)
+
+	var tokenRequests atomic.Int32
+	kube := newFakeTokenRequestClient(&tokenRequests)
+	m := &secretMaterializer{
+		gsmSecret: &secretspizecomv1alpha1.GSMSecret{
+			ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "external-ns"},
+		},
+		kubeClientFn: func() (kubernetes.Interface, error) { return kube, nil },
+	}
+	t.Cleanup(func() { gcpCredentialCache.evict(*m.credKey) })
+
+	creds, err := m.getGcpCreds(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	subject, subjectType := sts.lastSubject()
+	if subject != "oidc-token-1" || subjectType != "urn:ietf:params:oauth:token-type:id_token" {
+		t.Errorf("expected the file subject token to be exchanged, got %q (%s)", subject, subjectType)
+	}
+	if m.credKey.ExternalAccount == "" || m.credKey.KSA != "" {
+		t.Errorf("unexpected cache key %+v", *m.credKey)
+	}
+	if got := tokenRequests.Load(); got != 0 {
+		t.Errorf("expected no KSA TokenRequest in ExternalAccount mode, got %d", got)
+	}
+
+	// A refresh re-reads the subject token file.
+	if err := os.WriteFile(tokenPath, []byte("oidc-token-2"), 0o600); err != nil {
+		t.Fatalf("failed to rotate subject token: %v", err)
+	}
+	if _, err := creds.TokenSource.Token(); err != nil {
+		t.Fatalf("expected refresh to succeed, got %v", err)
+	}
+	if subject, _ := sts.lastSubject(); subject != "oidc-token-2" {
+		t.Errorf("expected the rotated subject token to be exchanged, got %q", subject)
+	}
+}
+
+func TestGetGcpCreds_ExternalAccountMissingConfig(t *testing.T) {
+	t.Setenv("MODE", "EXTERNAL_ACCOUNT")
+	t.Setenv("EXTERNAL_ACCOUNT_CONFIG", "")
+	m := &secretMaterializer{
+		gsmSecret: &secretspizecomv1alpha1.GSMSecret{
+			ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "external-ns"},
+		},
+	}
+	if _, err := m.getGcpCreds(context.Background()); err == nil || !strings.Contains(err.Error(), "load external_account configuration") {
+		t.Errorf("expected configuration error, got %v", err)
+	}
+}
@@ -19,149 +19,222 @@ limitations under the License.
 import (
 	"context"
 	"fmt"
+	"strings"
 	"time"
 
+	"github.com/go-logr/logr"
 	xoauth2 "golang.org/x/oauth2"
 	"golang.org/x/oauth2/google"
-	"google.golang.org/api/impersonate"
-	"google.golang.org/api/option"
-	"google.golang.org/api/sts/v1"
+	"golang.org/x/oauth2/google/externalaccount"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	secretspizecomv1alpha1 "github.com/zeraholladay/gsm-operator/api/v1alpha1"
 )
 
-// getGcpCreds builds Google credentials for the current GSMSecret by
-// requesting a KSA token and exchanging it via Workload Identity Federation.
+// getGcpCreds builds Google credentials for the current GSMSecret from the
+// source selected by its auth mode: the tenant KSA token exchanged via
+// Workload Identity Federation, the credentials Secret it references, or the
+// external_account configuration of its store or the operator.
 func (m *secretMaterializer) getGcpCreds(ctx context.Context) (*google.Credentials, error) {
 	log := logf.FromContext(ctx).WithValues(
 		"gsmsecret", m.gsmSecret.Name,
 		"namespace", m.gsmSecret.Namespace,
 	)
 
-	// STEP 0: Resolve WIF audience upfront so we fail fast if misconfigured.
-	wifAudience, err := m.getWIFAudience()
-	if err != nil {
-		log.Error(err, "failed to get WIF audience")
-		return nil, fmt.Errorf("get WIF audience: %w", err)
+	// STEP 0: Resolve where the base credentials come from upfront so we fail
+	// fast if misconfigured.
+	mode := m.authMode()
+	key := credentialCacheKey{
+		Namespace: m.gsmSecret.Namespace,
+		Endpoints: m.endpointsKey(),
+	}
+	var credentialsJSON []byte
+	var externalAccount externalaccount.Config
+	var wifAudience string
+	switch mode {
+	case secretspizecomv1alpha1.AuthModeCredentialsSecret:
+		ref := m.getCredentialsSecretRef()
+		if ref == nil {
+			return nil, fmt.Errorf("credentialsSecretRef is required in CredentialsSecret mode")
+		}
+		data, id, err := m.readCredentialsSecret(ctx, ref)
+		if err != nil {
+			log.Error(err, "failed to read credentials Secret")
+			return nil, fmt.Errorf("read credentials Secret: %w", err)
+		}
+		credentialsJSON = data
+		key.CredentialsSecret = id
+	case secretspizecomv1alpha1.AuthModeExternalAccount:
+		config, id, err := m.externalAccountConfig(ctx)
+		if err != nil {
+			log.Error(err, "failed to load external_account configuration")
+			return nil, fmt.Errorf("load external_account configuration: %w", err)
+		}
+		// Operator endpoint settings win over the configuration's. Store
+		// settings are never applied to the operator's identity.
+		if endpoint := m.getSTSEndpoint(); endpoint != "" {
+			config.TokenURL = m.stsTokenURL()
+		}
+		if config.UniverseDomain == "" {
+			config.UniverseDomain = m.getUniverseDomain()
+		}
+		externalAccount = config
+		key.ExternalAccount = id
+	default:
+		aud, err := m.getWIFAudience()
+		if err != nil {
+			log.Error(err, "failed to get WIF audience")
+			return nil, fmt.Errorf("get WIF audience: %w", err)
+		}
+		wifAudience = aud
+		key.KSA = m.getKSA()
+		key.Audience = wifAudience
 	}
 
-	// STEP 1: Request a short-lived JWT for the tenant KSA.
-	log.Info("requesting Kubernetes ServiceAccount token for GSM payload fetch")
-	token, err := m.requestKSAToken(ctx)
+	// Validate the impersonation chain before any token exchange.
+	chain, err := m.getImpersonationChain()
 	if err != nil {
-		log.Error(err, "failed to request Kubernetes ServiceAccount token")
-		return nil, fmt.Errorf("request KSA token: %w", err)
+		log.Error(err, "invalid GSA impersonation chain")
+		return nil, fmt.Errorf("invalid GSA impersonation chain: %w", err)
 	}
 
-	// STEP 2: Exchange the KSA token for Google credentials via Workload Identity to get GCP creds.
-	log.Info("exchanging Kubernetes ServiceAccount token via Workload Identity Federation")
-	creds, err := m.gcpCredsFromK8sToken(ctx, token, wifAudience)
+	// Reuse the refreshing token source for the same namespace, base identity
+	// and impersonation chain.
+	if chain != nil {
+		key.GSA = chain.Target
+		key.Delegates = strings.Join(chain.Delegates, ",")
+		key.Lifetime = chain.Lifetime
+		key.QuotaProject = m.getDefaultQuotaProject()
+	}
+	m.credKey = &key
+	if ts, ok := gcpCredentialCache.get(key); ok {
+		credentialCacheHits.Inc()
+		log.V(1).Info("using cached Google credentials", "ksa", key.KSA, "gsa", key.GSA)
+		return &google.Credentials{TokenSource: ts}, nil
+	}
+	credentialCacheMisses.Inc()
+
+	// The token source outlives this reconcile, so detach it from ctx cancellation.
+	var creds *google.Credentials
+	switch mode {
+	case secretspizecomv1alpha1.AuthModeCredentialsSecret:
+		creds, err = m.credentialsSecretGcpCreds(context.WithoutCancel(ctx), log, credentialsJSON)
+	case secretspizecomv1alpha1.AuthModeExternalAccount:
+		creds, err = m.externalAccountGcpCreds(context.WithoutCancel(ctx), log, externalAccount)
+	default:
+		creds, err = m.exchangeGcpCreds(context.WithoutCancel(ctx), log, wifAudience)
+	}
 	if err != nil {
-		log.Error(err, "failed to exchange KSA token for Google credentials")
-		return nil, fmt.Errorf("exchange KSA token for Google credentials: %w", err)
+		return nil, err
 	}
 
-	// STEP 3: If GSA Impersonation is requested, then use creds from STEP 2 to impersonate GSA
-	if gsa := m.getGSA(); gsa != "" {
-		log.Info("GSA impersonation requested; exchanging WIF credentials for impersonated access tokens", "gsa", gsa)
-		impersonatedCreds, err := m.gsaCredsFromGcpCreds(ctx, creds, gsa)
+	// If GSA impersonation is requested, use the base credentials to
+	// impersonate the GSA through its delegation chain.
+	if chain != nil {
+		log.Info("GSA impersonation requested; exchanging base credentials for impersonated access tokens",
+			"gsa", chain.Target, "delegates", chain.Delegates)
+		creds, err = m.gsaCredsFromGcpCreds(context.WithoutCancel(ctx), creds, chain)
 		if err != nil {
 			return nil, err
 		}
-		return impersonatedCreds, nil
 	}
 
-	return creds, nil
+	// Mint the first token now so misconfiguration fails this reconcile instead
+	// of the first Secret Manager call.
+	ts := xoauth2.ReuseTokenSourceWithExpiry(nil, creds.TokenSource, defaultCredentialRefreshBefore)
+	if _, err := ts.Token(); err != nil {
+		log.Error(err, "failed to obtain Google access token")
+		return nil, fmt.Errorf("obtain Google access token: %w", err)
+	}
+	gcpCredentialCache.put(key, ts)
+
+	return &google.Credentials{TokenSource: ts}, nil
 }
 
-// gcpCredsFromK8sToken turns a Kubernetes ServiceAccount JWT plus a Workload
-// Identity Audience into a google.Credentials object that can be passed to
-// Google client libraries (e.g. Secret Manager). The current implementation
-// performs a direct STS token exchange and does not support GSA impersonation.
-func (m *secretMaterializer) gcpCredsFromK8sToken(
+// credentialsSecretGcpCreds builds credentials from the JSON read from the
+// credentials Secret.
+func (m *secretMaterializer) credentialsSecretGcpCreds(
 	ctx context.Context,
-	k8sToken string,
-	wifAudience string,
+	log logr.Logger,
+	data []byte,
 ) (*google.Credentials, error) {
-	log := logf.FromContext(ctx).WithName("gcp_creds_from_k8s").WithValues(
-		"wifAudience", wifAudience,
-	)
-
-	// STEP 1: Exchange the Kubernetes ServiceAccount token for a Google access
-	// token via the Workload Identity Federation provider.
-	log.Info("exchanging Kubernetes ServiceAccount token for Google access token via WIF")
-	stsResp, err := m.exchangeK8sTokenWithSTS(ctx, k8sToken, wifAudience)
+	log.Info("building Google credentials from credentials Secret")
+	creds, err := credentialsFromJSON(ctx, data, m.getUniverseDomain())
 	if err != nil {
-		log.Error(err, "failed to exchange Kubernetes token via STS")
-		return nil, fmt.Errorf("exchange KSA token via STS: %w", err)
+		log.Error(err, "invalid credentials in Secret")
+		return nil, fmt.Errorf("credentials Secret: %w", err)
 	}
-
-	// STEP 2: Convert the STS response into an oauth2.Token with an explicit
-	// expiry timestamp.
-	expiry := time.Now().Add(time.Duration(stsResp.ExpiresIn) * time.Second)
-	token := &xoauth2.Token{
-		AccessToken: stsResp.AccessToken,
-		TokenType:   stsResp.TokenType,
-		Expiry:      expiry,
-	}
-
-	// STEP 3: Wrap the token in a google.Credentials instance so it can be
-	// passed to Google client constructors (e.g. Secret Manager).
-	creds := &google.Credentials{
-		TokenSource: xoauth2.StaticTokenSource(token),
-	}
-	log.Info("successfully constructed google.Credentials from Kubernetes ServiceAccount token")
 	return creds, nil
 }
 
-// exchangeK8sTokenWithSTS exchanges a Kubernetes ServiceAccount JWT for a
-// Google access token using the official STS library.
-func (m *secretMaterializer) exchangeK8sTokenWithSTS(ctx context.Context, k8sToken, wifAudience string) (*sts.GoogleIdentityStsV1ExchangeTokenResponse, error) {
-	// Initialize the STS service.
-	// Note: We use WithoutAuthentication() because we are calling the token
-	// exchange endpoint to *get* credentials. We don't have them yet.
-	stsService, err := sts.NewService(ctx, option.WithoutAuthentication())
+// externalAccountGcpCreds exchanges the subject token from the operator's
+// external_account configuration (file, URL, executable or AWS source) with STS.
+func (m *secretMaterializer) externalAccountGcpCreds(
+	ctx context.Context,
+	log logr.Logger,
+	config externalaccount.Config,
+) (*google.Credentials, error) {
+	log.Info("exchanging external_account subject token via Workload Identity Federation",
+		"audience", config.Audience)
+	creds, err := m.externalAccountCreds(ctx, config)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create STS service: %w", err)
-	}
-
-	// Construct the request using the library's types.
-	req := &sts.GoogleIdentityStsV1ExchangeTokenRequest{
-		GrantType:          "urn:ietf:params:oauth:grant-type:token-exchange",
-		Audience:           wifAudience,
-		Scope:              "https://www.googleapis.com/auth/cloud-platform",
-		RequestedTokenType: "urn:ietf:params:oauth:token-type:access_token",
-		SubjectTokenType:   "urn:ietf:params:oauth:token-type:jwt",
-		SubjectToken:       k8sToken,
+		log.Error(err, "failed to exchange external_account credentials")
+		return nil, fmt.Errorf("exchange external_account credentials: %w", err)
 	}
+	return creds, nil
+}
 
-	// Execute the request.
-	resp, err := stsService.V1.Token(req).Do()
+// exchangeGcpCreds exchanges the tenant KSA token, requested through the
+// TokenRequest API, for Google credentials via Workload Identity Federation.
+// The KSA token is re-requested and re-exchanged whenever the Google access
+// token nears expiry.
+func (m *secretMaterializer) exchangeGcpCreds(
+	ctx context.Context,
+	log logr.Logger,
+	wifAudience string,
+) (*google.Credentials, error) {
+	log.Info("exchanging Kubernetes ServiceAccount token via Workload Identity Federation")
+	creds, err := m.externalAccountCreds(ctx, externalaccount.Config{
+		Audience:             wifAudience,
+		SubjectTokenType:     jwtSubjectTokenType,
+		TokenURL:             m.stsTokenURL(),
+		SubjectTokenSupplier: &ksaSubjectTokenSupplier{m: m.snapshot()},
+	})
 	if err != nil {
-		return nil, fmt.Errorf("STS exchange failed: %w", err)
+		log.Error(err, "failed to exchange KSA token for Google credentials")
+		return nil, fmt.Errorf("exchange KSA token for Google credentials: %w", err)
 	}
-
-	// Map the library response to your internal struct.
-	return resp, nil
+	return creds, nil
 }
 
 // gsaCredsFromGcpCreds uses the provided base Google credentials (derived via
 // Workload Identity Federation) to impersonate the target Google Service
-// Account (GSA). It returns a new *google.Credentials whose TokenSource
-// produces impersonated access tokens suitable for downstream Google client
-// libraries (e.g. Secret Manager).
-func (m *secretMaterializer) gsaCredsFromGcpCreds(ctx context.Context, creds *google.Credentials, gsa string) (*google.Credentials, error) {
+// Account (GSA), through the chain's delegates if any. It returns a new
+// *google.Credentials whose TokenSource produces impersonated access tokens
+// suitable for downstream Google client libraries (e.g. Secret Manager).
+// The first token is minted here so a broken chain is reported with the
+// hop that failed. The chain is validated first, so a malformed chain never
+// reaches IAM.
+func (m *secretMaterializer) gsaCredsFromGcpCreds(ctx context.Context, creds *google.Credentials, chain *impersonationChain) (*google.Credentials, error) {
+	if err := chain.validate(); err != nil {
+		return nil, err
+	}
 	log := logf.FromContext(ctx)
-	ts, err := impersonate.CredentialsTokenSource(
-		ctx,
-		impersonate.CredentialsConfig{
-			TargetPrincipal: gsa,
-			Scopes:          []string{"https://www.googleapis.com/auth/cloud-platform"},
-		},
-		option.WithTokenSource(creds.TokenSource),
-	)
+	svc, err := m.newIAMCredentialsService(ctx, creds.TokenSource)
 	if err != nil {
-		log.Error(err, "failed to impersonate GSA", "gsa", gsa)
-		return nil, fmt.Errorf("impersonate GSA %s: %w", gsa, err)
+		return nil, err
 	}
-	return &google.Credentials{TokenSource: ts}, nil
+	timeout := time.Duration(m.getHTTPRequestTimeoutSeconds()) * time.Second
+	ts := newImpersonationTokenSource(ctx, svc, chain.Target, chain.Delegates, chain.Lifetime, timeout)
+	token, err := ts.Token()
+	if err != nil {
+		if len(chain.Delegates) > 0 {
+			err = diagnoseImpersonationChain(ctx, svc, chain, timeout, err)
+		}
+		log.Error(err, "failed to impersonate GSA", "gsa", chain.Target, "delegates", chain.Delegates)
+		return nil, fmt.Errorf("impersonate GSA %s: %w", chain.Target, err)
+	}
+	return &google.Credentials{
+		TokenSource: xoauth2.ReuseTokenSourceWithExpiry(token, ts, defaultCredentialRefreshBefore),
+	}, nil
 }
@@ -0,0 +1,251 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/go-logr/logr"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+
+	secretspizecomv1alpha1 "github.com/zeraholladay/gsm-operator/api/v1alpha1"
+)
+
+const testWIFAudience = "//iam.googleapis.com/projects/123/locations/global/workloadIdentityPools/pool/providers/provider"
+
+// fakeSTS is an in-process stand-in for the Google STS token endpoint.
+type fakeSTS struct {
+	server    *httptest.Server
+	calls     atomic.Int32
+	expiresIn int
+	audience  string
+
+	mu               sync.Mutex
+	subjectToken     string
+	subjectTokenType string
+}
+
+func newFakeSTS(t *testing.T, expiresIn int) *fakeSTS {
+	t.Helper()
+	f := &fakeSTS{expiresIn: expiresIn, audience: testWIFAudience}
+	f.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/token" {
+			http.NotFound(w, r)
+			return
+		}
+		if err := r.ParseForm(); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if r.PostForm.Get("audience") != f.audience || r.PostForm.Get("subject_token") == "" {
+			http.Error(w, `{"error":"invalid_request"}`, http.StatusBadRequest)
+			return
+		}
+		f.mu.Lock()
+		f.subjectToken = r.PostForm.Get("subject_token")
+		f.subjectTokenType = r.PostForm.Get("subject_token_type")
+		f.mu.Unlock()
+		n := f.calls.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"access_token":      fmt.Sprintf("sts-token-%d", n),
+			"issued_token_type": "urn:ietf:params:oauth:token-type:access_token",
+			"token_type":        "Bearer",
+			"expires_in":        f.expiresIn,
+		})
+	}))
+	t.Cleanup(f.server.Close)
+	return f
+}
+
+// lastSubject returns the subject token and type of the last exchange.
+func (f *fakeSTS) lastSubject() (string, string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.subjectToken, f.subjectTokenType
+}
+
+// newFakeTokenRequestClient returns a clientset whose TokenRequest calls are counted.
+func newFakeTokenRequestClient(calls *atomic.Int32) *fake.Clientset {
+	c := fake.NewClientset()
+	c.PrependReactor("create", "serviceaccounts/token", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		n := calls.Add(1)
+		return true, &authenticationv1.TokenRequest{
+			Status: authenticationv1.TokenRequestStatus{Token: fmt.Sprintf("ksa-token-%d", n)},
+		}, nil
+	})
+	return c
+}
+
+func newSTSTestMaterializer(t *testing.T, sts *fakeSTS, kube kubernetes.Interface) *secretMaterializer {
+	t.Helper()
+	t.Setenv("STS_ENDPOINT", sts.server.URL)
+	return &secretMaterializer{
+		gsmSecret: &secretspizecomv1alpha1.GSMSecret{
+			ObjectMeta: metav1.ObjectMeta{Name: "test-gsmsecret", Namespace: "sts-ns"},
+		},
+		kubeClientFn: func() (kubernetes.Interface, error) { return kube, nil },
+	}
+}
+
+func TestExchangeGcpCreds_ExchangesKSAToken(t *testing.T) {
+	t.Setenv("WIFAUDIENCE", testWIFAudience)
+	var tokenRequests atomic.Int32
+	sts := newFakeSTS(t, 3600)
+	m := newSTSTestMaterializer(t, sts, newFakeTokenRequestClient(&tokenRequests))
+
+	creds, err := m.exchangeGcpCreds(context.Background(), logr.Discard(), testWIFAudience)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	tok, err := creds.TokenSource.Token()
+	if err != nil || tok.AccessToken != "sts-token-1" {
+		t.Fatalf("expected STS token, got %v, %v", tok, err)
+	}
+	subject, subjectType := sts.lastSubject()
+	if subject != "ksa-token-1" || subjectType != jwtSubjectTokenType {
+		t.Errorf("expected the KSA token to be the JWT subject token, got %q (%s)", subject, subjectType)
+	}
+}
+
+func TestExchangeGcpCreds_ReusesTokenUntilNearExpiry(t *testing.T) {
+	t.Setenv("WIFAUDIENCE", testWIFAudience)
+	var tokenRequests atomic.Int32
+	sts := newFakeSTS(t, 3600)
+	m := newSTSTestMaterializer(t, sts, newFakeTokenRequestClient(&tokenRequests))
+
+	creds, err := m.exchangeGcpCreds(context.Background(), logr.Discard(), testWIFAudience)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	for i := 0; i < 3; i++ {
+		tok, err := creds.TokenSource.Token()
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if tok.AccessToken != "sts-token-1" {
+			t.Errorf("expected the initial token to be reused, got %q", tok.AccessToken)
+		}
+	}
+	if got := sts.calls.Load(); got != 1 {
+		t.Errorf("expected 1 STS exchange, got %d", got)
+	}
+	if got := tokenRequests.Load(); got != 1 {
+		t.Errorf("expected 1 TokenRequest, got %d", got)
+	}
+}
+
+func TestExchangeGcpCreds_RefreshesNearExpiry(t *testing.T) {
+	t.Setenv("WIFAUDIENCE", testWIFAudience)
+	t.Setenv("KSA", "sts-ksa")
+	var tokenRequests atomic.Int32
+	// Tokens that expire inside the refresh window are refreshed on every call.
+	sts := newFakeSTS(t, 60)
+	m := newSTSTestMaterializer(t, sts, newFakeTokenRequestClient(&tokenRequests))
+
+	creds, err := m.exchangeGcpCreds(context.Background(), logr.Discard(), testWIFAudience)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	tok, err := creds.TokenSource.Token()
+	if err != nil {
+		t.Fatalf("expected refresh to succeed, got %v", err)
+	}
+	if tok.AccessToken != "sts-token-2" {
+		t.Errorf("expected refreshed token %q, got %q", "sts-token-2", tok.AccessToken)
+	}
+	if got := tokenRequests.Load(); got != 2 {
+		t.Errorf("expected refresh to request a new KSA token, got %d TokenRequests", got)
+	}
+	if got := sts.calls.Load(); got != 2 {
+		t.Errorf("expected 2 STS exchanges, got %d", got)
+	}
+	if subject, _ := sts.lastSubject(); subject != "ksa-token-2" {
+		t.Errorf("expected the refresh to exchange the new KSA token, got %q", subject)
+	}
+}
+
+func TestExchangeGcpCreds_RefreshOutlivesContext(t *testing.T) {
+	t.Setenv("WIFAUDIENCE", testWIFAudience)
+	t.Setenv("KSA", "sts-ksa")
+	var tokenRequests atomic.Int32
+	sts := newFakeSTS(t, 60)
+	m := newSTSTestMaterializer(t, sts, newFakeTokenRequestClient(&tokenRequests))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	creds, err := m.exchangeGcpCreds(ctx, logr.Discard(), testWIFAudience)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	// Long-lived clients keep using the credentials after the reconcile ends.
+	cancel()
+
+	if _, err := creds.TokenSource.Token(); err != nil {
+		t.Fatalf("expected refresh after reconcile context is done, got %v", err)
+	}
+}
+
+func TestGetGcpCreds_CachesRefreshingTokenSource(t *testing.T) {
+	t.Setenv("WIFAUDIENCE", testWIFAudience)
+	t.Setenv("KSA", "sts-ksa")
+	var tokenRequests atomic.Int32
+	sts := newFakeSTS(t, 3600)
+	m := newSTSTestMaterializer(t, sts, newFakeTokenRequestClient(&tokenRequests))
+	t.Cleanup(func() {
+		gcpCredentialCache.evict(credentialCacheKey{Namespace: "sts-ns", KSA: "sts-ksa", Audience: testWIFAudience, Endpoints: m.endpointsKey()})
+	})
+
+	for i := 0; i < 2; i++ {
+		creds, err := m.getGcpCreds(context.Background())
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		tok, err := creds.TokenSource.Token()
+		if err != nil || tok.AccessToken != "sts-token-1" {
+			t.Fatalf("expected cached token, got %v, %v", tok, err)
+		}
+	}
+
+	if got := tokenRequests.Load(); got != 1 {
+		t.Errorf("expected 1 TokenRequest across reconciles, got %d", got)
+	}
+	if got := sts.calls.Load(); got != 1 {
+		t.Errorf("expected 1 STS exchange across reconciles, got %d", got)
+	}
+}
+
+func TestExchangeGcpCreds_STSError(t *testing.T) {
+	t.Setenv("WIFAUDIENCE", testWIFAudience)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"error":"invalid_grant"}`, http.StatusBadRequest)
+	}))
+	t.Cleanup(server.Close)
+
+	t.Setenv("STS_ENDPOINT", server.URL+"/")
+	var tokenRequests atomic.Int32
+	kube := newFakeTokenRequestClient(&tokenRequests)
+	m := &secretMaterializer{
+		gsmSecret: &secretspizecomv1alpha1.GSMSecret{
+			ObjectMeta: metav1.ObjectMeta{Name: "test-gsmsecret", Namespace: "sts-ns"},
+		},
+		kubeClientFn: func() (kubernetes.Interface, error) { return kube, nil },
+	}
+
+	_, err := m.exchangeGcpCreds(context.Background(), logr.Discard(), testWIFAudience)
+	if err == nil || !strings.Contains(err.Error(), "invalid_grant") {
+		t.Fatalf("expected error when STS rejects the exchange, got %v", err)
+	}
+}
@@ -20,13 +20,19 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
+	"os"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	secretmanager "cloud.google.com/go/secretmanager/apiv1"
 
 	"cloud.google.com/go/secretmanager/apiv1/secretmanagerpb"
 	"github.com/kaptinlin/jsonpointer"
 	secretspizecomv1alpha1 "github.com/zeraholladay/gsm-operator/api/v1alpha1"
+	"github.com/zeraholladay/gsm-operator/internal/accesspolicy"
 	"google.golang.org/api/option"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 )
@@ -48,22 +54,69 @@ func (m *secretMaterializer) resolvePayloads(ctx context.Context) error {
 		return nil
 	}
 
-	// STEP 1: Build a Secret Manager client bound to the tenant identity via WIF.
-	client, err := m.newGsmClient(ctx)
-	if err != nil {
-		return err
+	// Refuse reads no GSMAccessPolicy allows before touching Google APIs.
+	if m.policyReader != nil {
+		accesses, err := m.policyAccesses()
+		if err != nil {
+			return err
+		}
+		if err := accesspolicy.Check(ctx, m.policyReader, m.gsmSecret.Namespace, accesses); err != nil {
+			return err
+		}
 	}
-	defer func() {
-		if cerr := client.Close(); cerr != nil {
-			log.Error(cerr, "failed to close Secret Manager client")
+
+	// STEP 1: Get (pooled) Secret Manager clients bound to the tenant identity,
+	// one per quota project the entries are billed to.
+	clients := map[string]*gsmClient{}
+	for _, e := range m.gsmSecret.Spec.Secrets {
+		quotaProject := m.getQuotaProject(e)
+		if _, ok := clients[quotaProject]; ok {
+			continue
+		}
+		client, release, err := m.newGsmClient(ctx, quotaProject)
+		if err != nil {
+			return err
 		}
-	}()
+		defer release()
+		clients[quotaProject] = client
+	}
 
-	// STEP 2: Read each configured GSM secret entry and collect their payloads
+	// STEP 2: When the last sync is intact, confirm with metadata-only reads
+	// that no version moved before reading (and auditing) any payload.
+	if m.knownVersions != nil {
+		unchanged, err := m.checkVersionsUnchanged(ctx, clients)
+		switch {
+		case err != nil:
+			// e.g. the identity may access but not get versions; read payloads instead.
+			log.V(1).Info("GSM version check failed; fetching payloads", "reason", err.Error())
+			versionChecks.WithLabelValues("failed").Inc()
+		case unchanged:
+			log.V(1).Info("GSM versions unchanged since the last sync; skipping payload reads")
+			versionChecks.WithLabelValues("unchanged").Inc()
+			m.unchanged = true
+			m.resolvedVersions = m.knownVersions
+			return nil
+		default:
+			versionChecks.WithLabelValues("changed").Inc()
+		}
+	}
+
+	// STEP 3: Read each configured GSM secret entry and collect their payloads
 	// so they can be materialized into the target Kubernetes Secret.
-	results, err := m.fetchSecretEntriesPayloads(ctx, client)
+	results, err := m.fetchSecretEntriesPayloads(ctx, clients)
 	if err != nil {
 		log.Error(err, "failed to fetch GSM secret entry payloads")
+		// Don't keep handing out a token Secret Manager just rejected, nor
+		// payloads read with it. A PermissionDenied entry fails on its own.
+		if isAuthError(err) {
+			if m.credKey != nil {
+				log.Info("evicting cached Google credentials after authentication error")
+				gcpCredentialCache.evict(*m.credKey)
+			}
+			for _, c := range clients {
+				secretPayloadCache.evictClient(c.key)
+			}
+		}
 		return err
 	}
 
@@ -72,121 +125,319 @@ func (m *secretMaterializer) resolvePayloads(ctx context.Context) error {
 	return nil
 }
 
-// newGsmClient exchanges the Kubernetes ServiceAccount token for Google credentials
-// via Workload Identity Federation and returns a Secret Manager client.
-func (m *secretMaterializer) newGsmClient(ctx context.Context) (*secretmanager.Client, error) {
-	log := logf.FromContext(ctx)
+// policyAccesses describes every read resolvePayloads would perform, for
+// GSMAccessPolicy evaluation.
+func (m *secretMaterializer) policyAccesses() ([]accesspolicy.Access, error) {
+	// Trusted subsystem mode reads as the operator and impersonates nobody.
+	var principals []string
+	if !m.isTrustedSubsystem() {
+		chain, err := m.getImpersonationChain()
+		if err != nil {
+			return nil, err
+		}
+		if chain != nil {
+			principals = chain.hops()
+		}
+	}
+
+	accesses := make([]accesspolicy.Access, 0, len(m.gsmSecret.Spec.Secrets))
+	for _, e := range m.gsmSecret.Spec.Secrets {
+		projectID, err := m.getProjectID(e)
+		if err != nil {
+			return nil, err
+		}
+		accesses = append(accesses, accesspolicy.Access{ProjectID: projectID, SecretID: e.SecretID, Principals: principals})
+	}
+	return accesses, nil
+}
+
+// gsmClient is a pooled Secret Manager client and the key it is pooled
+// under, which also scopes cached payloads to the identity that read them.
+type gsmClient struct {
+	*secretmanager.Client
+	key gsmClientKey
+}
+
+// newGsmClient obtains Google credentials for the GSMSecret's auth mode and
+// returns a pooled Secret Manager client that bills calls to quotaProject, or
+// to the credentials' own project when it is empty.
+// The caller must invoke the returned release func when done with the client.
+func (m *secretMaterializer) newGsmClient(ctx context.Context, quotaProject string) (*gsmClient, func(), error) {
+	log := logf.FromContext(ctx).WithValues("quotaProject", quotaProject)
+
+	endpoint := m.getSecretManagerEndpoint()
+	universeDomain := m.getUniverseDomain()
+	endpointOpts := []option.ClientOption{option.WithUniverseDomain(universeDomain)}
+	if endpoint != "" {
+		endpointOpts = append(endpointOpts, option.WithEndpoint(endpoint))
+	}
+	if quotaProject != "" {
+		endpointOpts = append(endpointOpts, option.WithQuotaProject(quotaProject))
+	}
+	endpointOpts = append(endpointOpts, m.gsmClientOptions...)
 
 	// Is in "Trusted Subsystem" mode?
 	if m.isTrustedSubsystem() {
 		log.Info("using trusted subsystem mode: operator acting as its own IAM principal")
-		client, err := secretmanager.NewClient(ctx)
+		key := gsmClientKey{TrustedSubsystem: true, Endpoint: endpoint, UniverseDomain: universeDomain, QuotaProject: quotaProject}
+		c, release, err := gsmClients.acquire(ctx, key, func() (io.Closer, error) {
+			// The pooled client outlives this reconcile, so don't tie it to ctx.
+			return secretmanager.NewClient(context.Background(), endpointOpts...)
+		})
 		if err != nil {
 			log.Error(err, "failed to create Secret Manager client in trusted subsystem mode")
-			return nil, fmt.Errorf("secretmanager.NewClient (trusted subsystem): %w", err)
+			return nil, nil, fmt.Errorf("secretmanager.NewClient (trusted subsystem): %w", err)
 		}
-		return client, nil
+		return &gsmClient{Client: c.(*secretmanager.Client), key: key}, release, nil
 	}
 
-	// Exchange the KSA token for Google credentials via WIF.
-	log.Info("exchanging Kubernetes ServiceAccount token via Workload Identity Federation")
-	creds, err := m.getGcpCreds(ctx)
-	if err != nil {
-		log.Error(err, "failed to exchange KSA token for Google credentials")
-		return nil, fmt.Errorf("exchange KSA token for Google credentials: %w", err)
+	// Exchange the KSA token or the external_account subject token for Google
+	// credentials, or read them from the credentials Secret. This also fails
+	// fast on misconfiguration before a pooled client is handed out.
+	log.Info("obtaining Google credentials", "authMode", m.authMode())
+	if _, err := m.getGcpCreds(ctx); err != nil {
+		log.Error(err, "failed to obtain Google credentials")
+		return nil, nil, newMaterializeError(errorClassAuth, fmt.Errorf("obtain Google credentials: %w", err))
 	}
 
-	// Build a Secret Manager client bound to the tenant identity.
-	log.Info("creating Google Secret Manager client with federated credentials")
-	client, err := secretmanager.NewClient(ctx, option.WithCredentials(creds))
+	// Reuse (or build) a Secret Manager client bound to the tenant identity. Its
+	// token source reads the credential cache entry getGcpCreds just filled.
+	key := gsmClientKey{Identity: *m.credKey, Endpoint: endpoint, UniverseDomain: universeDomain, QuotaProject: quotaProject}
+	c, release, err := gsmClients.acquire(ctx, key, func() (io.Closer, error) {
+		log.Info("creating Google Secret Manager client with federated credentials")
+		opts := append([]option.ClientOption{option.WithTokenSource(newCachedCredentialsTokenSource(m))}, endpointOpts...)
+		return secretmanager.NewClient(context.Background(), opts...)
+	})
 	if err != nil {
 		log.Error(err, "failed to create Secret Manager client")
-		return nil, fmt.Errorf("secretmanager.NewClient WithCredentials: %w", err)
+		return nil, nil, fmt.Errorf("secretmanager.NewClient WithTokenSource: %w", err)
 	}
 
-	return client, nil
+	return &gsmClient{Client: c.(*secretmanager.Client), key: key}, release, nil
+}
+
+// defaultFetchConcurrency is how many entries of a GSMSecret are fetched at
+// once. Can be overridden via FETCH_CONCURRENCY.
+const defaultFetchConcurrency = 8
+
+// getFetchConcurrency returns the entry fetch limit from FETCH_CONCURRENCY,
+// or the default if not set or invalid.
+func getFetchConcurrency() int {
+	if v := os.Getenv("FETCH_CONCURRENCY"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			return n
+		}
+	}
+	return defaultFetchConcurrency
+}
+
+// entryResult is the outcome of fetching one GSMSecret entry.
+type entryResult struct {
+	payloads []keyedSecretPayload
+	// name is the requested version resource and version what it resolved to.
+	name, version string
+	err           error
+}
+
+// forEachConcurrently calls fn for every index below n, up to
+// FETCH_CONCURRENCY at a time, and returns once every call has.
+func forEachConcurrently(n int, fn func(i int)) {
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for range min(getFetchConcurrency(), n) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				fn(i)
+			}
+		}()
+	}
+	for i := range n {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
 }
 
-// fetchSecretEntriesPayloads reads each configured GSM secret entry from Google
-// Secret Manager and returns the payloads keyed by the target Secret data key.
+// fetchSecretEntriesPayloads reads the configured GSM secret entries from
+// Google Secret Manager, up to FETCH_CONCURRENCY at a time, with the client
+// for each entry's quota project. Entries requesting the same version share
+// one read. It returns the payloads keyed by the target Secret data key, in
+// spec order, or an error describing every failed entry.
 func (m *secretMaterializer) fetchSecretEntriesPayloads(
 	ctx context.Context,
-	client *secretmanager.Client,
+	clients map[string]*gsmClient,
 ) ([]keyedSecretPayload, error) {
-	log := logf.FromContext(ctx)
+	entries := m.gsmSecret.Spec.Secrets
+	results := make([]entryResult, len(entries))
+	reads := newVersionReads()
 
-	results := make([]keyedSecretPayload, 0, len(m.gsmSecret.Spec.Secrets))
+	forEachConcurrently(len(entries), func(i int) {
+		results[i] = m.fetchSecretEntry(ctx, clients, reads, entries[i])
+	})
 
-	for _, e := range m.gsmSecret.Spec.Secrets {
-		// Validation: reject entries that try to use both single key and multi-key forms.
-		if e.Key != "" && len(e.Keys) > 0 {
-			return nil, fmt.Errorf("invalid GSMSecret entry: cannot set both key and keys")
+	payloads := make([]keyedSecretPayload, 0, len(entries))
+	var errs []error
+	for _, r := range results {
+		if r.err != nil {
+			errs = append(errs, r.err)
+			continue
 		}
+		payloads = append(payloads, r.payloads...)
+		m.recordResolvedVersion(r.name, r.version)
+	}
+	if len(errs) > 0 {
+		return nil, aggregateEntryErrors(errs, len(entries))
+	}
+	return payloads, nil
+}
+
+// fetchSecretEntry reads one entry and maps its payload to Secret keys.
+func (m *secretMaterializer) fetchSecretEntry(
+	ctx context.Context,
+	clients map[string]*gsmClient,
+	reads *versionReads,
+	e secretspizecomv1alpha1.GSMSecretEntry,
+) entryResult {
+	log := logf.FromContext(ctx)
 
-		// Fetch the secret payload from GSM for the requested project/secret/version.
-		log.V(1).Info("fetching GSM secret payload",
-			"projectID", e.ProjectID,
+	// Validation: reject entries that try to use both single key and multi-key forms.
+	if e.Key != "" && len(e.Keys) > 0 {
+		return entryResult{err: fmt.Errorf("invalid GSMSecret entry: cannot set both key and keys")}
+	}
+
+	projectID, err := m.getProjectID(e)
+	if err != nil {
+		return entryResult{err: err}
+	}
+
+	// Fetch the secret payload from GSM for the requested project/secret/version.
+	log.V(1).Info("fetching GSM secret payload",
+		"projectID", projectID,
+		"secretID", e.SecretID,
+		"version", e.Version,
+	)
+
+	name := secretVersionName(projectID, e.SecretID, e.Version)
+
+	// After a version check, read the version it resolved an alias to, rather
+	// than a cached payload of the alias that may predate the move.
+	readName := name
+	if version, ok := m.checkedVersions[name]; ok {
+		readName = secretVersionName(projectID, e.SecretID, version)
+	}
+	data, version, err := readSecretVersion(ctx, clients[m.getQuotaProject(e)], reads, projectID, readName)
+	if err != nil {
+		log.Error(err, "failed to fetch GSM secret payload",
+			"projectID", projectID,
 			"secretID", e.SecretID,
 			"version", e.Version,
 		)
+		return entryResult{err: fmt.Errorf("fetch payload for key %q (project=%q, secret=%q, version=%q): %w",
+			e.Key, projectID, e.SecretID, e.Version, err)}
+	}
+	result := entryResult{name: name, version: version}
 
-		name := fmt.Sprintf("projects/%s/secrets/%s/versions/%s", e.ProjectID, e.SecretID, e.Version)
-
-		data, err := accessSecretPayload(ctx, client, name)
+	// Materialize the payload either as a single key or via multi-key mappings.
+	switch {
+	case e.Key != "":
+		payload, err := newKeyedSecretPayload(e.Key, data)
 		if err != nil {
-			log.Error(err, "failed to fetch GSM secret payload",
-				"projectID", e.ProjectID,
-				"secretID", e.SecretID,
-				"version", e.Version,
-			)
-			return nil, fmt.Errorf("fetch payload for key %q (project=%q, secret=%q, version=%q): %w",
-				e.Key, e.ProjectID, e.SecretID, e.Version, err)
+			return entryResult{err: &materializeError{Class: errorClassParse, Err: fmt.Errorf("validate key %q: %w", e.Key, err)}}
 		}
-
-		// Materialize the payload either as a single key or via multi-key mappings.
-		switch {
-		case e.Key != "":
-			payload, err := newKeyedSecretPayload(e.Key, data)
-			if err != nil {
-				return nil, fmt.Errorf("validate key %q: %w", e.Key, err)
-			}
-			results = append(results, payload)
-		case len(e.Keys) > 0:
-			mapped, err := mapKeysToSecretKeyMappings(data, e.Keys)
-			if err != nil {
-				return nil, fmt.Errorf("map key mappings for secret %q: %w", e.SecretID, err)
-			}
-			results = append(results, mapped...)
-		default:
-			// Spec requires exactly one of key or keys.
-			return nil, fmt.Errorf("invalid GSMSecret entry: either key or keys must be set")
+		result.payloads = []keyedSecretPayload{payload}
+	case len(e.Keys) > 0:
+		mapped, err := mapKeysToSecretKeyMappings(data, e.Keys)
+		if err != nil {
+			return entryResult{err: &materializeError{Class: errorClassParse, Err: fmt.Errorf("map key mappings for secret %q: %w", e.SecretID, err)}}
 		}
+		result.payloads = mapped
+	default:
+		// Spec requires exactly one of key or keys.
+		return entryResult{err: fmt.Errorf("invalid GSMSecret entry: either key or keys must be set")}
 	}
+	return result
+}
 
-	return results, nil
+// readSecretVersion returns the payload of the secret version name and the
+// version it resolved to, from this reconcile's earlier read of the same
+// version, the process-wide payload cache, or Secret Manager.
+func readSecretVersion(
+	ctx context.Context,
+	client *gsmClient,
+	reads *versionReads,
+	projectID, name string,
+) ([]byte, string, error) {
+	key := payloadCacheKey{Client: client.key, Name: name}
+	read := reads.get(key)
+	read.once.Do(func() {
+		if data, version, ok := secretPayloadCache.get(key); ok {
+			logf.FromContext(ctx).V(1).Info("using cached GSM secret payload", "resource", name, "version", version)
+			read.data, read.version = data, version
+			return
+		}
+		read.data, read.version, read.err = accessSecretPayload(ctx, client.Client, projectID, name)
+		if read.err != nil {
+			return
+		}
+		secretPayloadCache.put(key, read.data, read.version)
+		// An alias also tells us the payload of the immutable version it
+		// resolved to.
+		if !isPinnedVersion(name) && isPinnedVersion(read.version) {
+			pinned := payloadCacheKey{Client: client.key, Name: name[:strings.LastIndex(name, "/")+1] + read.version}
+			secretPayloadCache.put(pinned, read.data, read.version)
+		}
+	})
+	return read.data, read.version, read.err
 }
 
+// accessSecretPayload reads the secret version name and returns its payload
+// and the version number it resolved to.
 func accessSecretPayload(
 	ctx context.Context,
 	client *secretmanager.Client,
-	name string,
-) ([]byte, error) {
+	projectID, name string,
+) ([]byte, string, error) {
 	log := logf.FromContext(ctx).WithValues(
 		"name", name,
 	)
 
 	log.V(1).Info("accessing GSM secret version", "resource", name)
 
+	if err := secretManagerLimits.wait(ctx, projectID); err != nil {
+		return nil, "", err
+	}
+	start := time.Now()
 	resp, err := client.AccessSecretVersion(ctx, &secretmanagerpb.AccessSecretVersionRequest{
 		Name: name,
 	})
+	observeSecretAccess(projectID, start, resp.GetPayload().GetData(), err)
 	if err != nil {
 		log.Error(err, "failed to access GSM secret version", "resource", name)
-		return nil, fmt.Errorf("AccessSecretVersion(%s): %w", name, err)
+		return nil, "", fmt.Errorf("AccessSecretVersion(%s): %w", name, err)
 	}
 
-	log.V(1).Info("successfully accessed GSM secret version", "resource", name)
-	return resp.GetPayload().GetData(), nil
+	// The response names the numbered version an alias such as "latest" resolved to.
+	resolved := resp.GetName()
+	if resolved == "" {
+		resolved = name
+	}
+	version := resolved[strings.LastIndex(resolved, "/")+1:]
+
+	log.V(1).Info("successfully accessed GSM secret version", "resource", name, "version", version)
+	return resp.GetPayload().GetData(), version, nil
+}
+
+// recordResolvedVersion notes the version the requested version name
+// resolved to, once per name.
+func (m *secretMaterializer) recordResolvedVersion(name, version string) {
+	for _, v := range m.resolvedVersions {
+		if v.Secret == name {
+			return
+		}
+	}
+	m.resolvedVersions = append(m.resolvedVersions, secretspizecomv1alpha1.ResolvedSecretVersion{Secret: name, Version: version})
 }
 
 // mapKeysToSecretKeyMappings expands a multi-key mapping entry into individual keyed payloads.
@@ -0,0 +1,222 @@
+package controller
+
+/*
+Copyright 2025 Zera Holladay.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+import (
+	"context"
+	"io"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	xoauth2 "golang.org/x/oauth2"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// defaultGsmClientIdleTTL is how long an unused Secret Manager client stays
+// open before it is closed. Can be overridden via GSM_CLIENT_IDLE_TTL_SECONDS.
+const defaultGsmClientIdleTTL = 10 * time.Minute
+
+// getGsmClientIdleTTL returns the idle TTL from GSM_CLIENT_IDLE_TTL_SECONDS,
+// or the default if not set or invalid.
+func getGsmClientIdleTTL() time.Duration {
+	if v := os.Getenv("GSM_CLIENT_IDLE_TTL_SECONDS"); v != "" {
+		if seconds, err := strconv.Atoi(v); err == nil && seconds > 0 {
+			return time.Duration(seconds) * time.Second
+		}
+	}
+	return defaultGsmClientIdleTTL
+}
+
+// gsmClientKey identifies a pooled Secret Manager client by the identity it
+// authenticates as and the endpoint it talks to.
+type gsmClientKey struct {
+	// TrustedSubsystem is set when the client uses the operator's own identity.
+	TrustedSubsystem bool
+	// Identity is the federated identity used outside trusted subsystem mode.
+	Identity credentialCacheKey
+	// Endpoint is the Secret Manager endpoint; empty means the library default.
+	Endpoint string
+	// UniverseDomain is the Google Cloud universe the client is bound to.
+	UniverseDomain string
+	// QuotaProject is the x-goog-user-project the client sends, if any.
+	QuotaProject string
+}
+
+// pooledClient is a shared client plus the bookkeeping needed to close it
+// once nobody has used it for a while.
+type pooledClient struct {
+	client io.Closer
+	// ready is closed once client or err is set. Callers that find the entry
+	// while it is still being created wait on it outside the pool lock.
+	ready    chan struct{}
+	err      error
+	inUse    int
+	lastUsed time.Time
+}
+
+// gsmClientPool shares Secret Manager clients (and their gRPC connections)
+// between reconciles. It is safe for concurrent use.
+type gsmClientPool struct {
+	mu      sync.Mutex
+	entries map[gsmClientKey]*pooledClient
+	idleTTL func() time.Duration
+	now     func() time.Time
+}
+
+// gsmClients is shared by every reconcile in the process.
+var gsmClients = newGsmClientPool(getGsmClientIdleTTL)
+
+func newGsmClientPool(idleTTL func() time.Duration) *gsmClientPool {
+	return &gsmClientPool{
+		entries: map[gsmClientKey]*pooledClient{},
+		idleTTL: idleTTL,
+		now:     time.Now,
+	}
+}
+
+// acquire returns the pooled client for key, creating it with newClient when
+// missing. The caller must invoke the returned release func when done.
+// Clients are dialed and closed outside the pool lock, so a slow endpoint
+// only delays the reconciles that need it.
+func (p *gsmClientPool) acquire(ctx context.Context, key gsmClientKey, newClient func() (io.Closer, error)) (io.Closer, func(), error) {
+	p.mu.Lock()
+	idle := p.takeIdleLocked()
+	entry, ok := p.entries[key]
+	if !ok {
+		// Reserve the key so concurrent callers wait for this client
+		// instead of dialing their own.
+		entry = &pooledClient{ready: make(chan struct{})}
+		p.entries[key] = entry
+	}
+	entry.inUse++
+	entry.lastUsed = p.now()
+	p.mu.Unlock()
+
+	closeIdleClients(ctx, idle)
+
+	if !ok {
+		c, err := newClient()
+		p.mu.Lock()
+		entry.client, entry.err = c, err
+		if err != nil && p.entries[key] == entry {
+			delete(p.entries, key)
+		}
+		p.mu.Unlock()
+		close(entry.ready)
+	}
+
+	var once sync.Once
+	release := func() {
+		once.Do(func() {
+			p.mu.Lock()
+			defer p.mu.Unlock()
+			entry.inUse--
+			entry.lastUsed = p.now()
+		})
+	}
+
+	select {
+	case <-entry.ready:
+	case <-ctx.Done():
+		release()
+		return nil, nil, ctx.Err()
+	}
+	if entry.err != nil {
+		release()
+		return nil, nil, entry.err
+	}
+	return entry.client, release, nil
+}
+
+// takeIdleLocked removes and returns the entries that have been unused for
+// longer than the idle TTL, for the caller to close once p.mu is released.
+// Entries still being created are in use, so they are never taken.
+func (p *gsmClientPool) takeIdleLocked() map[gsmClientKey]*pooledClient {
+	ttl := p.idleTTL()
+	var idle map[gsmClientKey]*pooledClient
+	for key, entry := range p.entries {
+		if entry.inUse > 0 || p.now().Sub(entry.lastUsed) < ttl {
+			continue
+		}
+		delete(p.entries, key)
+		if idle == nil {
+			idle = map[gsmClientKey]*pooledClient{}
+		}
+		idle[key] = entry
+	}
+	return idle
+}
+
+// closeIdleClients closes the clients returned by takeIdleLocked.
+func closeIdleClients(ctx context.Context, idle map[gsmClientKey]*pooledClient) {
+	log := logf.FromContext(ctx)
+	for key, entry := range idle {
+		log.V(1).Info("closing idle Secret Manager client", "endpoint", key.Endpoint, "trustedSubsystem", key.TrustedSubsystem)
+		if err := entry.client.Close(); err != nil {
+			log.Error(err, "failed to close idle Secret Manager client")
+		}
+	}
+}
+
+// cachedCredentialsTokenSource is the TokenSource behind pooled WIF clients.
+// It reads the refreshing token source cached under the identity the client
+// was created for, so a long-lived client keeps working after the token it
+// was created with expires. Only when that entry has been evicted, such as
+// after a rejected token, does it resolve the credentials again through
+// getGcpCreds, which for a credentials Secret means reading it again.
+type cachedCredentialsTokenSource struct {
+	m *secretMaterializer
+
+	mu  sync.Mutex
+	key credentialCacheKey
+}
+
+// Token implements oauth2.TokenSource.
+func (s *cachedCredentialsTokenSource) Token() (*xoauth2.Token, error) {
+	s.mu.Lock()
+	key := s.key
+	s.mu.Unlock()
+	if ts, ok := gcpCredentialCache.get(key); ok {
+		return ts.Token()
+	}
+
+	timeout := time.Duration(s.m.getHTTPRequestTimeoutSeconds()) * time.Second
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	// getGcpCreds records the cache key on its receiver, and concurrent
+	// fetches call Token in parallel, so work on a copy of the snapshot.
+	m := *s.m
+	creds, err := m.getGcpCreds(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// A rotated credentials Secret resolves to a new key; follow it.
+	s.mu.Lock()
+	s.key = *m.credKey
+	s.mu.Unlock()
+	return creds.TokenSource.Token()
+}
+
+// newCachedCredentialsTokenSource returns a token source for the identity
+// getGcpCreds last resolved on m. It snapshots the materializer so the token
+// source does not hold on to the reconcile's GSMSecret object.
+func newCachedCredentialsTokenSource(m *secretMaterializer) *cachedCredentialsTokenSource {
+	return &cachedCredentialsTokenSource{m: m.snapshot(), key: *m.credKey}
+}
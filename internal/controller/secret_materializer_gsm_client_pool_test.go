@@ -0,0 +1,294 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	secretspizecomv1alpha1 "github.com/zeraholladay/gsm-operator/api/v1alpha1"
+)
+
+// fakeCloser records whether Close was called.
+type fakeCloser struct {
+	closed bool
+}
+
+func (f *fakeCloser) Close() error {
+	f.closed = true
+	return nil
+}
+
+func newTestPool(ttl time.Duration) (*gsmClientPool, *time.Time) {
+	now := time.Now()
+	p := newGsmClientPool(func() time.Duration { return ttl })
+	p.now = func() time.Time { return now }
+	return p, &now
+}
+
+func TestGsmClientPool_ReusesClientForSameKey(t *testing.T) {
+	p, _ := newTestPool(time.Minute)
+	ctx := context.Background()
+	key := gsmClientKey{Identity: credentialCacheKey{Namespace: "ns", KSA: "default", Audience: "aud"}}
+
+	created := 0
+	newClient := func() (io.Closer, error) {
+		created++
+		return &fakeCloser{}, nil
+	}
+
+	c1, release1, err := p.acquire(ctx, key, newClient)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	release1()
+	c2, release2, err := p.acquire(ctx, key, newClient)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	release2()
+
+	if created != 1 {
+		t.Errorf("expected 1 client to be created, got %d", created)
+	}
+	if c1 != c2 {
+		t.Error("expected the same client to be returned for the same key")
+	}
+}
+
+func TestGsmClientPool_SeparateClientsPerKey(t *testing.T) {
+	p, _ := newTestPool(time.Minute)
+	ctx := context.Background()
+
+	created := 0
+	newClient := func() (io.Closer, error) {
+		created++
+		return &fakeCloser{}, nil
+	}
+
+	keys := []gsmClientKey{
+		{TrustedSubsystem: true},
+		{Identity: credentialCacheKey{Namespace: "a", KSA: "default", Audience: "aud"}},
+		{Identity: credentialCacheKey{Namespace: "b", KSA: "default", Audience: "aud"}},
+		{Identity: credentialCacheKey{Namespace: "b", KSA: "default", Audience: "aud"}, Endpoint: "psc.example:443"},
+	}
+	for _, key := range keys {
+		_, release, err := p.acquire(ctx, key, newClient)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		release()
+	}
+
+	if created != len(keys) {
+		t.Errorf("expected %d clients, got %d", len(keys), created)
+	}
+}
+
+func TestGsmClientPool_ClosesIdleClients(t *testing.T) {
+	p, now := newTestPool(time.Minute)
+	ctx := context.Background()
+
+	idle := &fakeCloser{}
+	_, release, _ := p.acquire(ctx, gsmClientKey{Endpoint: "idle"}, func() (io.Closer, error) { return idle, nil })
+	release()
+
+	*now = now.Add(2 * time.Minute)
+
+	// Any acquire sweeps idle clients.
+	_, release, _ = p.acquire(ctx, gsmClientKey{Endpoint: "other"}, func() (io.Closer, error) { return &fakeCloser{}, nil })
+	release()
+
+	if !idle.closed {
+		t.Error("expected idle client to be closed")
+	}
+	if _, ok := p.entries[gsmClientKey{Endpoint: "idle"}]; ok {
+		t.Error("expected idle client to be removed from the pool")
+	}
+}
+
+func TestGsmClientPool_DoesNotCloseClientsInUse(t *testing.T) {
+	p, now := newTestPool(time.Minute)
+	ctx := context.Background()
+
+	busy := &fakeCloser{}
+	_, release, _ := p.acquire(ctx, gsmClientKey{Endpoint: "busy"}, func() (io.Closer, error) { return busy, nil })
+
+	*now = now.Add(2 * time.Minute)
+	_, releaseOther, _ := p.acquire(ctx, gsmClientKey{Endpoint: "other"}, func() (io.Closer, error) { return &fakeCloser{}, nil })
+	releaseOther()
+
+	if busy.closed {
+		t.Error("expected in-use client to stay open")
+	}
+
+	// Releasing twice must not drive the counter negative.
+	release()
+	release()
+	if got := p.entries[gsmClientKey{Endpoint: "busy"}].inUse; got != 0 {
+		t.Errorf("expected inUse 0 after release, got %d", got)
+	}
+}
+
+func TestGsmClientPool_CreateError(t *testing.T) {
+	p, _ := newTestPool(time.Minute)
+
+	_, _, err := p.acquire(context.Background(), gsmClientKey{}, func() (io.Closer, error) {
+		return nil, errors.New("dial failed")
+	})
+	if err == nil {
+		t.Fatal("expected error from client constructor")
+	}
+	if len(p.entries) != 0 {
+		t.Error("expected failed client not to be pooled")
+	}
+}
+
+func TestGsmClientPool_DialsOutsideLock(t *testing.T) {
+	p, _ := newTestPool(time.Minute)
+	ctx := context.Background()
+	slowKey := gsmClientKey{Endpoint: "slow.example.com:443"}
+
+	var created atomic.Int32
+	dialing := make(chan struct{})
+	unblock := make(chan struct{})
+	slowClient := &fakeCloser{}
+	type result struct {
+		client io.Closer
+		err    error
+	}
+	results := make(chan result, 2)
+	acquireSlow := func() {
+		c, release, err := p.acquire(ctx, slowKey, func() (io.Closer, error) {
+			created.Add(1)
+			close(dialing)
+			<-unblock
+			return slowClient, nil
+		})
+		if err == nil {
+			release()
+		}
+		results <- result{c, err}
+	}
+	go acquireSlow()
+	<-dialing
+	go acquireSlow()
+
+	// Another key is served while the slow dial is in flight.
+	done := make(chan error, 1)
+	go func() {
+		_, release, err := p.acquire(ctx, gsmClientKey{Endpoint: "fast.example.com:443"}, func() (io.Closer, error) {
+			return &fakeCloser{}, nil
+		})
+		if err == nil {
+			release()
+		}
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected acquire for another key not to wait for a slow dial")
+	}
+
+	close(unblock)
+	for range 2 {
+		r := <-results
+		if r.err != nil || r.client != slowClient {
+			t.Fatalf("expected the shared slow client, got %v, %v", r.client, r.err)
+		}
+	}
+	if n := created.Load(); n != 1 {
+		t.Errorf("expected concurrent callers to share one dial, got %d", n)
+	}
+}
+
+func TestGsmClientPool_WaitHonorsContext(t *testing.T) {
+	p, _ := newTestPool(time.Minute)
+	key := gsmClientKey{Endpoint: "slow.example.com:443"}
+	dialing := make(chan struct{})
+	unblock := make(chan struct{})
+	defer close(unblock)
+	go func() {
+		_, release, err := p.acquire(context.Background(), key, func() (io.Closer, error) {
+			close(dialing)
+			<-unblock
+			return &fakeCloser{}, nil
+		})
+		if err == nil {
+			release()
+		}
+	}()
+	<-dialing
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, _, err := p.acquire(ctx, key, func() (io.Closer, error) {
+		t.Error("expected the in-flight dial to be reused")
+		return nil, nil
+	}); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+}
+
+func TestGetGsmClientIdleTTL(t *testing.T) {
+	t.Setenv("GSM_CLIENT_IDLE_TTL_SECONDS", "")
+	if got := getGsmClientIdleTTL(); got != defaultGsmClientIdleTTL {
+		t.Errorf("expected default %v, got %v", defaultGsmClientIdleTTL, got)
+	}
+
+	t.Setenv("GSM_CLIENT_IDLE_TTL_SECONDS", "30")
+	if got := getGsmClientIdleTTL(); got != 30*time.Second {
+		t.Errorf("expected 30s, got %v", got)
+	}
+
+	t.Setenv("GSM_CLIENT_IDLE_TTL_SECONDS", "-1")
+	if got := getGsmClientIdleTTL(); got != defaultGsmClientIdleTTL {
+		t.Errorf("expected default for invalid value, got %v", got)
+	}
+}
+
+func TestCachedCredentialsTokenSource_UsesCredentialCache(t *testing.T) {
+	t.Setenv("WIFAUDIENCE", "aud")
+	t.Setenv("KSA", "pool-ksa")
+
+	key := credentialCacheKey{Namespace: "pool-ns", KSA: "pool-ksa", Audience: "aud", Endpoints: (&secretMaterializer{}).endpointsKey()}
+	gcpCredentialCache.put(key, mockTokenSource{token: "first"})
+	t.Cleanup(func() { gcpCredentialCache.evict(key) })
+
+	gsmSecret := &secretspizecomv1alpha1.GSMSecret{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-gsmsecret", Namespace: "pool-ns"},
+	}
+	ts := newCachedCredentialsTokenSource(&secretMaterializer{gsmSecret: gsmSecret, credKey: &key})
+
+	// The snapshot must not alias the reconcile's object.
+	if ts.m.gsmSecret == gsmSecret {
+		t.Error("expected token source to hold a copy of the GSMSecret")
+	}
+
+	// Cached tokens are served without resolving the credentials again, so
+	// they are not counted as cache hits.
+	hits := testutil.ToFloat64(credentialCacheHits)
+	tok, err := ts.Token()
+	if err != nil || tok.AccessToken != "first" {
+		t.Fatalf("expected cached token, got %v, %v", tok, err)
+	}
+	if got := testutil.ToFloat64(credentialCacheHits) - hits; got != 0 {
+		t.Errorf("expected no cache hit to be recorded, got %v", got)
+	}
+
+	// A refreshed cache entry is picked up by the same token source.
+	gcpCredentialCache.put(key, mockTokenSource{token: "second"})
+	tok, err = ts.Token()
+	if err != nil || tok.AccessToken != "second" {
+		t.Fatalf("expected refreshed token, got %v, %v", tok, err)
+	}
+}
@@ -0,0 +1,269 @@
+package controller
+
+/*
+Copyright 2025 Zera Holladay.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	xoauth2 "golang.org/x/oauth2"
+	"google.golang.org/api/iamcredentials/v1"
+	"google.golang.org/api/option"
+
+	secretspizecomv1alpha1 "github.com/zeraholladay/gsm-operator/api/v1alpha1"
+)
+
+// maxImpersonationLifetime is the longest token lifetime IAM will grant.
+const maxImpersonationLifetime = 12 * time.Hour
+
+// gsaEmailRegex matches Google service account emails, including the
+// *.gserviceaccount.com forms used by default compute and App Engine accounts.
+var gsaEmailRegex = regexp.MustCompile(`^[a-z0-9][a-z0-9.-]*@([a-z0-9-]+\.)*gserviceaccount\.com$`)
+
+// impersonationChain is the ordered list of GSAs walked to reach the target:
+// the federated identity impersonates Delegates[0], which impersonates
+// Delegates[1], and so on until the last hop impersonates Target.
+type impersonationChain struct {
+	Target    string
+	Delegates []string
+	Lifetime  time.Duration
+}
+
+// hops returns every principal in the chain, ending with the target.
+func (c *impersonationChain) hops() []string {
+	return append(append([]string{}, c.Delegates...), c.Target)
+}
+
+// getImpersonationChain returns the validated impersonation chain for the
+// GSMSecret, or nil when no GSA is configured.
+func (m *secretMaterializer) getImpersonationChain() (*impersonationChain, error) {
+	gsa := m.getGSA()
+	delegates := m.getGSADelegates()
+	lifetime, err := m.getImpersonationLifetime()
+	if err != nil {
+		return nil, err
+	}
+	if gsa == "" {
+		if len(delegates) > 0 || lifetime != 0 {
+			if m.store != nil {
+				return nil, fmt.Errorf("store impersonation delegates and lifetime require serviceAccount")
+			}
+			return nil, fmt.Errorf("annotations %q and %q require annotation %q",
+				secretspizecomv1alpha1.AnnotationGSADelegates,
+				secretspizecomv1alpha1.AnnotationImpersonationLifetime,
+				secretspizecomv1alpha1.AnnotationGSA)
+		}
+		return nil, nil
+	}
+
+	chain := &impersonationChain{Target: gsa, Delegates: delegates, Lifetime: lifetime}
+	if err := chain.validate(); err != nil {
+		return nil, err
+	}
+	return chain, nil
+}
+
+// validate checks that every hop is a service account email that appears
+// only once in the chain.
+func (c *impersonationChain) validate() error {
+	hops := c.hops()
+	seen := map[string]int{}
+	for i, hop := range hops {
+		if !gsaEmailRegex.MatchString(hop) {
+			return fmt.Errorf("impersonation hop %d of %d: %q is not a service account email", i+1, len(hops), hop)
+		}
+		if prev, ok := seen[hop]; ok {
+			return fmt.Errorf("impersonation hop %d of %d: %q already appears at hop %d", i+1, len(hops), hop, prev)
+		}
+		seen[hop] = i + 1
+	}
+	return nil
+}
+
+// validateImpersonationLifetime checks d against the limits IAM accepts.
+func validateImpersonationLifetime(d time.Duration) error {
+	if d <= 0 || d > maxImpersonationLifetime {
+		return fmt.Errorf("lifetime %s must be greater than 0 and at most %s", d, maxImpersonationLifetime)
+	}
+	return nil
+}
+
+// getGSADelegates returns the ordered delegation chain from the store or the
+// annotation.
+func (m *secretMaterializer) getGSADelegates() []string {
+	if m.store != nil {
+		if m.store.Impersonation == nil {
+			return nil
+		}
+		var delegates []string
+		for _, d := range m.store.Impersonation.Delegates {
+			delegates = append(delegates, strings.TrimSpace(d))
+		}
+		return delegates
+	}
+	raw := strings.TrimSpace(m.gsmSecret.GetAnnotations()[secretspizecomv1alpha1.AnnotationGSADelegates])
+	if raw == "" {
+		return nil
+	}
+	var delegates []string
+	for _, d := range strings.Split(raw, ",") {
+		delegates = append(delegates, strings.TrimSpace(d))
+	}
+	return delegates
+}
+
+// getImpersonationLifetime returns the requested impersonated token lifetime,
+// or zero to use the IAM default of one hour.
+func (m *secretMaterializer) getImpersonationLifetime() (time.Duration, error) {
+	if m.store != nil {
+		if m.store.Impersonation == nil || m.store.Impersonation.Lifetime == nil {
+			return 0, nil
+		}
+		if err := validateImpersonationLifetime(m.store.Impersonation.Lifetime.Duration); err != nil {
+			return 0, fmt.Errorf("store impersonation: %w", err)
+		}
+		return m.store.Impersonation.Lifetime.Duration, nil
+	}
+	raw := strings.TrimSpace(m.gsmSecret.GetAnnotations()[secretspizecomv1alpha1.AnnotationImpersonationLifetime])
+	if raw == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(raw)
+	if err != nil {
+		return 0, fmt.Errorf("annotation %q: %w", secretspizecomv1alpha1.AnnotationImpersonationLifetime, err)
+	}
+	if err := validateImpersonationLifetime(d); err != nil {
+		return 0, fmt.Errorf("annotation %q: %w", secretspizecomv1alpha1.AnnotationImpersonationLifetime, err)
+	}
+	return d, nil
+}
+
+// impersonationTokenSource mints impersonated tokens for a chain by calling
+// the IAM Credentials generateAccessToken method. The impersonate package is
+// not used because it always calls iamcredentials.googleapis.com, whatever
+// endpoint option it is given. Each call is bounded by timeout, since ctx
+// outlives the reconcile that created the source.
+type impersonationTokenSource struct {
+	ctx       context.Context
+	svc       *iamcredentials.Service
+	target    string
+	delegates []string
+	lifetime  time.Duration
+	timeout   time.Duration
+}
+
+// Token implements oauth2.TokenSource.
+func (s *impersonationTokenSource) Token() (*xoauth2.Token, error) {
+	req := &iamcredentials.GenerateAccessTokenRequest{
+		Scope: []string{"https://www.googleapis.com/auth/cloud-platform"},
+	}
+	for _, d := range s.delegates {
+		req.Delegates = append(req.Delegates, serviceAccountResourceName(d))
+	}
+	if s.lifetime > 0 {
+		req.Lifetime = fmt.Sprintf("%ds", int64(s.lifetime.Seconds()))
+	}
+	ctx, cancel := context.WithTimeout(s.ctx, s.timeout)
+	defer cancel()
+	if err := iamCredentialsLimits.wait(ctx, ""); err != nil {
+		return nil, err
+	}
+	start := time.Now()
+	resp, err := s.svc.Projects.ServiceAccounts.GenerateAccessToken(serviceAccountResourceName(s.target), req).Context(ctx).Do()
+	observeTokenExchange(tokenStepImpersonation, start, err != nil)
+	if err != nil {
+		return nil, err
+	}
+	expiry, err := time.Parse(time.RFC3339, resp.ExpireTime)
+	if err != nil {
+		return nil, fmt.Errorf("parse impersonated token expiry: %w", err)
+	}
+	return &xoauth2.Token{AccessToken: resp.AccessToken, TokenType: "Bearer", Expiry: expiry}, nil
+}
+
+// serviceAccountResourceName returns the IAM resource name of a GSA email.
+func serviceAccountResourceName(email string) string {
+	return "projects/-/serviceAccounts/" + email
+}
+
+// newIAMCredentialsService returns an IAM Credentials client that calls with
+// base as the caller's credentials, through the configured endpoint.
+func (m *secretMaterializer) newIAMCredentialsService(ctx context.Context, base xoauth2.TokenSource) (*iamcredentials.Service, error) {
+	opts := []option.ClientOption{
+		option.WithTokenSource(base),
+		option.WithUniverseDomain(m.getUniverseDomain()),
+	}
+	if endpoint := m.getIAMCredentialsEndpoint(); endpoint != "" {
+		opts = append(opts, option.WithEndpoint(endpoint+"/"))
+	}
+	if quotaProject := m.getDefaultQuotaProject(); quotaProject != "" {
+		opts = append(opts, option.WithQuotaProject(quotaProject))
+	}
+	svc, err := iamcredentials.NewService(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("create IAM Credentials client: %w", err)
+	}
+	return svc, nil
+}
+
+// newImpersonationTokenSource returns a token source impersonating target
+// through delegates with svc, giving each call at most timeout.
+func newImpersonationTokenSource(
+	ctx context.Context,
+	svc *iamcredentials.Service,
+	target string,
+	delegates []string,
+	lifetime time.Duration,
+	timeout time.Duration,
+) *impersonationTokenSource {
+	return &impersonationTokenSource{
+		ctx:       ctx,
+		svc:       svc,
+		target:    target,
+		delegates: delegates,
+		lifetime:  lifetime,
+		timeout:   timeout,
+	}
+}
+
+// diagnoseImpersonationChain walks the chain one hop at a time to find the
+// first principal the previous hop cannot impersonate. It returns err
+// annotated with that hop, or err unchanged if every hop succeeds on retry.
+// Each probe is bounded by timeout.
+func diagnoseImpersonationChain(
+	ctx context.Context,
+	svc *iamcredentials.Service,
+	chain *impersonationChain,
+	timeout time.Duration,
+	err error,
+) error {
+	hops := chain.hops()
+	for i, hop := range hops {
+		probe := newImpersonationTokenSource(ctx, svc, hop, hops[:i], 0, timeout)
+		if _, probeErr := probe.Token(); probeErr != nil {
+			caller := "federated identity"
+			if i > 0 {
+				caller = hops[i-1]
+			}
+			return fmt.Errorf("impersonation hop %d of %d (%s → %s) failed: %w", i+1, len(hops), caller, hop, probeErr)
+		}
+	}
+	return err
+}
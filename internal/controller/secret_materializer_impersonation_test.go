@@ -0,0 +1,311 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"golang.org/x/oauth2/google"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	secretspizecomv1alpha1 "github.com/zeraholladay/gsm-operator/api/v1alpha1"
+)
+
+const (
+	tenantGSA = "tenant@tenant-proj.iam.gserviceaccount.com"
+	brokerGSA = "broker@broker-proj.iam.gserviceaccount.com"
+	readerGSA = "reader@data-proj.iam.gserviceaccount.com"
+)
+
+// fakeIAMCredentials is an in-process stand-in for the IAM Credentials
+// generateAccessToken endpoint. The federated identity may impersonate the
+// first principal in allowed, and each principal may impersonate the next.
+type fakeIAMCredentials struct {
+	server  *httptest.Server
+	allowed []string
+
+	mu       sync.Mutex
+	requests []fakeIAMRequest
+}
+
+type fakeIAMRequest struct {
+	Target        string
+	Delegates     []string
+	Lifetime      string
+	Authorization string
+	UserProject   string
+}
+
+func newFakeIAMCredentials(t *testing.T, allowed ...string) *fakeIAMCredentials {
+	t.Helper()
+	f := &fakeIAMCredentials{allowed: allowed}
+	f.server = httptest.NewServer(http.HandlerFunc(f.serveHTTP))
+	t.Cleanup(f.server.Close)
+	return f
+}
+
+func (f *fakeIAMCredentials) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	const prefix = "/v1/projects/-/serviceAccounts/"
+	if !strings.HasPrefix(r.URL.Path, prefix) || !strings.HasSuffix(r.URL.Path, ":generateAccessToken") {
+		http.NotFound(w, r)
+		return
+	}
+	target := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, prefix), ":generateAccessToken")
+
+	var body struct {
+		Delegates []string `json:"delegates"`
+		Lifetime  string   `json:"lifetime"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	req := fakeIAMRequest{Target: target, Lifetime: body.Lifetime, Authorization: r.Header.Get("Authorization"), UserProject: r.Header.Get("X-Goog-User-Project")}
+	for _, d := range body.Delegates {
+		req.Delegates = append(req.Delegates, strings.TrimPrefix(d, "projects/-/serviceAccounts/"))
+	}
+	f.mu.Lock()
+	f.requests = append(f.requests, req)
+	f.mu.Unlock()
+
+	// Every hop of delegates..., target must follow the allowed chain.
+	for i, hop := range append(append([]string{}, req.Delegates...), target) {
+		if i >= len(f.allowed) || f.allowed[i] != hop {
+			http.Error(w, `{"error":{"code":403,"status":"PERMISSION_DENIED"}}`, http.StatusForbidden)
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]string{
+		"accessToken": "impersonated-" + target,
+		"expireTime":  time.Now().Add(time.Hour).UTC().Format(time.RFC3339),
+	})
+}
+
+func (f *fakeIAMCredentials) lastRequest() fakeIAMRequest {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.requests[len(f.requests)-1]
+}
+
+func newChainTestMaterializer(annotations map[string]string) *secretMaterializer {
+	return &secretMaterializer{
+		gsmSecret: &secretspizecomv1alpha1.GSMSecret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "test-gsmsecret",
+				Namespace:   "default",
+				Annotations: annotations,
+			},
+		},
+	}
+}
+
+func TestGetImpersonationChain(t *testing.T) {
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		want        *impersonationChain
+		wantErr     string
+	}{
+		{
+			name: "no GSA",
+		},
+		{
+			name:        "GSA only",
+			annotations: map[string]string{secretspizecomv1alpha1.AnnotationGSA: readerGSA},
+			want:        &impersonationChain{Target: readerGSA},
+		},
+		{
+			name: "delegates and lifetime",
+			annotations: map[string]string{
+				secretspizecomv1alpha1.AnnotationGSA:                   readerGSA,
+				secretspizecomv1alpha1.AnnotationGSADelegates:          tenantGSA + ", " + brokerGSA,
+				secretspizecomv1alpha1.AnnotationImpersonationLifetime: "30m",
+			},
+			want: &impersonationChain{Target: readerGSA, Delegates: []string{tenantGSA, brokerGSA}, Lifetime: 30 * time.Minute},
+		},
+		{
+			name:        "delegates without GSA",
+			annotations: map[string]string{secretspizecomv1alpha1.AnnotationGSADelegates: tenantGSA},
+			wantErr:     "require annotation",
+		},
+		{
+			name: "invalid delegate",
+			annotations: map[string]string{
+				secretspizecomv1alpha1.AnnotationGSA:          readerGSA,
+				secretspizecomv1alpha1.AnnotationGSADelegates: tenantGSA + ",not-an-email",
+			},
+			wantErr: `impersonation hop 2 of 3: "not-an-email" is not a service account email`,
+		},
+		{
+			name: "empty delegate",
+			annotations: map[string]string{
+				secretspizecomv1alpha1.AnnotationGSA:          readerGSA,
+				secretspizecomv1alpha1.AnnotationGSADelegates: tenantGSA + ",,",
+			},
+			wantErr: "impersonation hop 2 of 4",
+		},
+		{
+			name: "duplicate hop",
+			annotations: map[string]string{
+				secretspizecomv1alpha1.AnnotationGSA:          readerGSA,
+				secretspizecomv1alpha1.AnnotationGSADelegates: readerGSA,
+			},
+			wantErr: "already appears at hop 1",
+		},
+		{
+			name: "lifetime too long",
+			annotations: map[string]string{
+				secretspizecomv1alpha1.AnnotationGSA:                   readerGSA,
+				secretspizecomv1alpha1.AnnotationImpersonationLifetime: "13h",
+			},
+			wantErr: "at most 12h",
+		},
+		{
+			name: "lifetime not a duration",
+			annotations: map[string]string{
+				secretspizecomv1alpha1.AnnotationGSA:                   readerGSA,
+				secretspizecomv1alpha1.AnnotationImpersonationLifetime: "1 hour",
+			},
+			wantErr: "impersonation-lifetime",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := newChainTestMaterializer(tt.annotations).getImpersonationChain()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if (got == nil) != (tt.want == nil) {
+				t.Fatalf("expected chain %+v, got %+v", tt.want, got)
+			}
+			if got != nil && (got.Target != tt.want.Target ||
+				strings.Join(got.Delegates, ",") != strings.Join(tt.want.Delegates, ",") ||
+				got.Lifetime != tt.want.Lifetime) {
+				t.Errorf("expected chain %+v, got %+v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestGsaCredsFromGcpCreds_DelegationChain(t *testing.T) {
+	iam := newFakeIAMCredentials(t, tenantGSA, brokerGSA, readerGSA)
+	m := newChainTestMaterializer(nil)
+	t.Setenv("IAM_CREDENTIALS_ENDPOINT", iam.server.URL)
+
+	chain := &impersonationChain{Target: readerGSA, Delegates: []string{tenantGSA, brokerGSA}, Lifetime: 20 * time.Minute}
+	creds, err := m.gsaCredsFromGcpCreds(context.Background(), &google.Credentials{TokenSource: mockTokenSource{token: "federated"}}, chain)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tok, err := creds.TokenSource.Token()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if tok.AccessToken != "impersonated-"+readerGSA {
+		t.Errorf("expected token for %s, got %q", readerGSA, tok.AccessToken)
+	}
+
+	req := iam.lastRequest()
+	if req.Target != readerGSA || strings.Join(req.Delegates, ",") != tenantGSA+","+brokerGSA {
+		t.Errorf("expected delegates to be passed in order, got %+v", req)
+	}
+	if req.Lifetime != "1200s" {
+		t.Errorf("expected lifetime 1200s, got %q", req.Lifetime)
+	}
+}
+
+func TestGsaCredsFromGcpCreds_ReportsFailingHop(t *testing.T) {
+	// The broker cannot impersonate the reader, so hop 3 is broken.
+	iam := newFakeIAMCredentials(t, tenantGSA, brokerGSA)
+	m := newChainTestMaterializer(nil)
+	t.Setenv("IAM_CREDENTIALS_ENDPOINT", iam.server.URL)
+
+	chain := &impersonationChain{Target: readerGSA, Delegates: []string{tenantGSA, brokerGSA}}
+	_, err := m.gsaCredsFromGcpCreds(context.Background(), &google.Credentials{TokenSource: mockTokenSource{token: "federated"}}, chain)
+	if err == nil {
+		t.Fatal("expected error for broken delegation chain")
+	}
+	want := "impersonation hop 3 of 3 (" + brokerGSA + " → " + readerGSA + ") failed"
+	if !strings.Contains(err.Error(), want) {
+		t.Errorf("expected error containing %q, got %v", want, err)
+	}
+}
+
+func TestGsaCredsFromGcpCreds_ReportsFirstHop(t *testing.T) {
+	iam := newFakeIAMCredentials(t)
+	m := newChainTestMaterializer(nil)
+	t.Setenv("IAM_CREDENTIALS_ENDPOINT", iam.server.URL)
+
+	chain := &impersonationChain{Target: readerGSA, Delegates: []string{tenantGSA}}
+	_, err := m.gsaCredsFromGcpCreds(context.Background(), &google.Credentials{TokenSource: mockTokenSource{token: "federated"}}, chain)
+	want := "impersonation hop 1 of 2 (federated identity → " + tenantGSA + ") failed"
+	if err == nil || !strings.Contains(err.Error(), want) {
+		t.Errorf("expected error containing %q, got %v", want, err)
+	}
+}
+
+func TestDiagnoseImpersonationChain_AllHopsSucceed(t *testing.T) {
+	iam := newFakeIAMCredentials(t, tenantGSA, readerGSA)
+	m := newChainTestMaterializer(nil)
+	t.Setenv("IAM_CREDENTIALS_ENDPOINT", iam.server.URL)
+
+	orig := errors.New("transient")
+	chain := &impersonationChain{Target: readerGSA, Delegates: []string{tenantGSA}}
+	svc, err := m.newIAMCredentialsService(context.Background(), mockTokenSource{token: "federated"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := diagnoseImpersonationChain(context.Background(), svc, chain, time.Minute, orig); err != orig {
+		t.Errorf("expected original error when every hop succeeds, got %v", err)
+	}
+}
+
+func TestImpersonationTokenSource_TimesOut(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	t.Cleanup(server.Close)
+	t.Cleanup(func() { close(release) })
+	m := newChainTestMaterializer(nil)
+	t.Setenv("IAM_CREDENTIALS_ENDPOINT", server.URL)
+
+	svc, err := m.newIAMCredentialsService(context.Background(), mockTokenSource{token: "federated"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// The source's context never ends; only the per-call timeout stops it.
+	ts := newImpersonationTokenSource(context.WithoutCancel(context.Background()), svc, readerGSA, nil, 0, 50*time.Millisecond)
+	done := make(chan error, 1)
+	go func() {
+		_, err := ts.Token()
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("expected a deadline error, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Token did not return after its timeout")
+	}
+}
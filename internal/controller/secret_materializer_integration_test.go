@@ -0,0 +1,697 @@
+package controller
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"fmt"
+	"math/big"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/secretmanager/apiv1/secretmanagerpb"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"google.golang.org/api/option"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	secretspizecomv1alpha1 "github.com/zeraholladay/gsm-operator/api/v1alpha1"
+)
+
+// The integration tests run the whole auth chain, from the KSA TokenRequest
+// through STS, IAM Credentials and Secret Manager, against in-process fakes
+// reached through the configurable endpoints. Run them alone with
+// "make test-integration".
+
+// fakeSecretManager is an in-process Secret Manager gRPC server over TLS. It
+// serves versions from secrets, resolving aliases through aliases, and
+// records the bearer token and quota project of each access.
+type fakeSecretManager struct {
+	secretmanagerpb.UnimplementedSecretManagerServiceServer
+
+	addr    string
+	secrets map[string]string
+	// aliases maps version names such as .../versions/latest to the
+	// numbered version they resolve to.
+	aliases map[string]string
+	// denyGet makes GetSecretVersion fail, as for an identity that may only
+	// access versions.
+	denyGet bool
+
+	// delay, when set, is how long each call takes.
+	delay time.Duration
+
+	mu            sync.Mutex
+	authorization []string
+	// userProjects maps each version name read to its x-goog-user-project.
+	userProjects map[string]string
+	// inFlight and maxInFlight count concurrent calls.
+	inFlight, maxInFlight int
+	// gets counts GetSecretVersion calls.
+	gets int
+}
+
+func newFakeSecretManager(t *testing.T, secrets map[string]string) (*fakeSecretManager, option.ClientOption) {
+	t.Helper()
+	cert, pool := newLocalhostCert(t)
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	f := &fakeSecretManager{addr: lis.Addr().String(), secrets: secrets, userProjects: map[string]string{}}
+	server := grpc.NewServer(grpc.Creds(credentials.NewTLS(&tls.Config{Certificates: []tls.Certificate{cert}})))
+	secretmanagerpb.RegisterSecretManagerServiceServer(server, f)
+	go func() { _ = server.Serve(lis) }()
+	t.Cleanup(server.Stop)
+
+	trust := option.WithGRPCDialOption(grpc.WithTransportCredentials(credentials.NewTLS(&tls.Config{RootCAs: pool})))
+	return f, trust
+}
+
+func (f *fakeSecretManager) AccessSecretVersion(
+	ctx context.Context,
+	req *secretmanagerpb.AccessSecretVersionRequest,
+) (*secretmanagerpb.AccessSecretVersionResponse, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	f.mu.Lock()
+	f.authorization = append(f.authorization, strings.Join(md.Get("authorization"), ","))
+	f.userProjects[req.GetName()] = strings.Join(md.Get("x-goog-user-project"), ",")
+	f.inFlight++
+	f.maxInFlight = max(f.maxInFlight, f.inFlight)
+	f.mu.Unlock()
+	defer func() {
+		f.mu.Lock()
+		f.inFlight--
+		f.mu.Unlock()
+	}()
+	time.Sleep(f.delay)
+
+	name := f.resolve(req.GetName())
+	value, ok := f.secrets[name]
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "secret version %s not found", req.GetName())
+	}
+	return &secretmanagerpb.AccessSecretVersionResponse{
+		Name:    name,
+		Payload: &secretmanagerpb.SecretPayload{Data: []byte(value)},
+	}, nil
+}
+
+func (f *fakeSecretManager) GetSecretVersion(
+	_ context.Context,
+	req *secretmanagerpb.GetSecretVersionRequest,
+) (*secretmanagerpb.SecretVersion, error) {
+	f.mu.Lock()
+	f.gets++
+	denied := f.denyGet
+	f.mu.Unlock()
+	if denied {
+		return nil, status.Error(codes.PermissionDenied, "permission secretmanager.versions.get denied")
+	}
+
+	name := f.resolve(req.GetName())
+	if _, ok := f.secrets[name]; !ok {
+		return nil, status.Errorf(codes.NotFound, "secret version %s not found", req.GetName())
+	}
+	return &secretmanagerpb.SecretVersion{Name: name, State: secretmanagerpb.SecretVersion_ENABLED}, nil
+}
+
+// resolve returns the numbered version an alias points to.
+func (f *fakeSecretManager) resolve(name string) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if target, ok := f.aliases[name]; ok {
+		return target
+	}
+	return name
+}
+
+// calls returns the number of AccessSecretVersion and GetSecretVersion calls.
+func (f *fakeSecretManager) calls() (accesses, gets int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.authorization), f.gets
+}
+
+// lastAuthorization returns the Authorization metadata of the last call.
+func (f *fakeSecretManager) lastAuthorization() string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if len(f.authorization) == 0 {
+		return ""
+	}
+	return f.authorization[len(f.authorization)-1]
+}
+
+// newLocalhostCert returns a self-signed certificate for 127.0.0.1 and a pool
+// trusting it.
+func newLocalhostCert(t *testing.T) (tls.Certificate, *x509.CertPool) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "fake-secretmanager"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		IsCA:         true,
+
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("failed to create certificate: %v", err)
+	}
+	leaf, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("failed to parse certificate: %v", err)
+	}
+	pool := x509.NewCertPool()
+	pool.AddCert(leaf)
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key, Leaf: leaf}, pool
+}
+
+func newIntegrationGSMSecret(namespace string, entries ...secretspizecomv1alpha1.GSMSecretEntry) *secretspizecomv1alpha1.GSMSecret {
+	return &secretspizecomv1alpha1.GSMSecret{
+		ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: namespace},
+		Spec: secretspizecomv1alpha1.GSMSecretSpec{
+			TargetSecret: secretspizecomv1alpha1.GSMSecretTargetSecret{Name: "app"},
+			Secrets:      entries,
+		},
+	}
+}
+
+func payloadValues(payloads []keyedSecretPayload) map[string]string {
+	got := map[string]string{}
+	for _, p := range payloads {
+		got[p.Key] = string(p.Value)
+	}
+	return got
+}
+
+func TestIntegration_WorkloadIdentityFederationThroughStoreEndpoints(t *testing.T) {
+	t.Setenv("MODE", "")
+	t.Setenv("KSA", "")
+	sts := newFakeSTS(t, 3600)
+	iam := newFakeIAMCredentials(t, brokerGSA, readerGSA)
+	gsm, trust := newFakeSecretManager(t, map[string]string{
+		"projects/data-proj/secrets/db-password/versions/3": "s3cr3t",
+	})
+	var tokenRequests atomic.Int32
+	kube := newFakeTokenRequestClient(&tokenRequests)
+
+	m := &secretMaterializer{
+		gsmSecret: newIntegrationGSMSecret("integration-wif", secretspizecomv1alpha1.GSMSecretEntry{
+			Key: "DB_PASSWORD", SecretID: "db-password", Version: "3",
+		}),
+		store: &secretspizecomv1alpha1.GSMSecretStoreSpec{
+			AuthMode:           secretspizecomv1alpha1.AuthModeWorkloadIdentityFederation,
+			Audience:           testWIFAudience,
+			ServiceAccountName: "reader",
+			Impersonation: &secretspizecomv1alpha1.GSMSecretStoreImpersonation{
+				ServiceAccount: readerGSA,
+				Delegates:      []string{brokerGSA},
+			},
+			DefaultProjectID:       "data-proj",
+			Endpoint:               gsm.addr,
+			STSEndpoint:            sts.server.URL,
+			IAMCredentialsEndpoint: iam.server.URL,
+		},
+		kubeClientFn:     func() (kubernetes.Interface, error) { return kube, nil },
+		gsmClientOptions: []option.ClientOption{trust},
+	}
+	t.Cleanup(func() { gcpCredentialCache.evict(*m.credKey) })
+
+	if err := m.resolvePayloads(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := payloadValues(m.payloads); got["DB_PASSWORD"] != "s3cr3t" {
+		t.Errorf("unexpected payloads %v", got)
+	}
+	if len(m.resolvedVersions) != 1 || m.resolvedVersions[0].Version != "3" {
+		t.Errorf("expected the entry to resolve to version 3, got %+v", m.resolvedVersions)
+	}
+
+	// Each hop presented the token minted by the previous one.
+	if subject, _ := sts.lastSubject(); subject != "ksa-token-1" {
+		t.Errorf("expected STS to exchange the KSA token, got %q", subject)
+	}
+	req := iam.lastRequest()
+	if req.Authorization != "Bearer sts-token-1" || req.Target != readerGSA || strings.Join(req.Delegates, ",") != brokerGSA {
+		t.Errorf("expected IAM Credentials to be called with the federated token, got %+v", req)
+	}
+	if got := gsm.lastAuthorization(); got != "Bearer impersonated-"+readerGSA {
+		t.Errorf("expected Secret Manager to be called with the impersonated token, got %q", got)
+	}
+}
+
+func TestIntegration_MetricsCoverEveryCall(t *testing.T) {
+	t.Setenv("MODE", "")
+	t.Setenv("KSA", "")
+	sts := newFakeSTS(t, 3600)
+	iam := newFakeIAMCredentials(t, readerGSA)
+	gsm, trust := newFakeSecretManager(t, map[string]string{
+		"projects/metrics-proj/secrets/db-password/versions/1": "s3cr3t",
+	})
+	var tokenRequests atomic.Int32
+	kube := newFakeTokenRequestClient(&tokenRequests)
+
+	m := &secretMaterializer{
+		gsmSecret: newIntegrationGSMSecret("integration-metrics",
+			secretspizecomv1alpha1.GSMSecretEntry{Key: "DB_PASSWORD", SecretID: "db-password", Version: "1"},
+			secretspizecomv1alpha1.GSMSecretEntry{Key: "MISSING", SecretID: "missing", Version: "1"},
+		),
+		store: &secretspizecomv1alpha1.GSMSecretStoreSpec{
+			Audience:               testWIFAudience,
+			Impersonation:          &secretspizecomv1alpha1.GSMSecretStoreImpersonation{ServiceAccount: readerGSA},
+			DefaultProjectID:       "metrics-proj",
+			Endpoint:               gsm.addr,
+			STSEndpoint:            sts.server.URL,
+			IAMCredentialsEndpoint: iam.server.URL,
+		},
+		kubeClientFn:     func() (kubernetes.Interface, error) { return kube, nil },
+		gsmClientOptions: []option.ClientOption{trust},
+	}
+	t.Cleanup(func() { gcpCredentialCache.evict(*m.credKey) })
+
+	steps := []string{tokenStepTokenRequest, tokenStepSTS, tokenStepImpersonation}
+	before := map[string]uint64{}
+	for _, step := range steps {
+		before[step] = histogramSampleCount(t, tokenExchangeDuration, step)
+	}
+	payloads := histogramSampleCount(t, secretPayloadBytes, "metrics-proj")
+
+	if err := m.resolvePayloads(context.Background()); err == nil {
+		t.Fatal("expected the missing version to fail the fetch")
+	}
+
+	for _, step := range steps {
+		if got := histogramSampleCount(t, tokenExchangeDuration, step); got != before[step]+1 {
+			t.Errorf("expected one %s call to be observed, got %d after %d", step, got, before[step])
+		}
+	}
+	if got := testutil.ToFloat64(secretAccessTotal.WithLabelValues("metrics-proj", codes.OK.String())); got != 1 {
+		t.Errorf("expected one OK access, got %v", got)
+	}
+	if got := testutil.ToFloat64(secretAccessTotal.WithLabelValues("metrics-proj", codes.NotFound.String())); got != 1 {
+		t.Errorf("expected one NotFound access, got %v", got)
+	}
+	if got := histogramSampleCount(t, secretPayloadBytes, "metrics-proj"); got != payloads+1 {
+		t.Errorf("expected only the successful payload to be measured, got %d after %d", got, payloads)
+	}
+}
+
+func TestIntegration_ExternalAccountThroughOperatorEndpoints(t *testing.T) {
+	sts := newFakeSTS(t, 3600)
+	sts.audience = testExternalAudience
+	configPath, _ := writeExternalAccountConfig(t, sts)
+	gsm, trust := newFakeSecretManager(t, map[string]string{
+		"projects/eks-proj/secrets/api-key/versions/latest": "k3y",
+	})
+
+	// The configuration's token_url is replaced by the operator's STS endpoint.
+	t.Setenv("MODE", "EXTERNAL_ACCOUNT")
+	t.Setenv("EXTERNAL_ACCOUNT_CONFIG", configPath)
+	t.Setenv("STS_ENDPOINT", sts.server.URL)
+	t.Setenv("SECRET_MANAGER_ENDPOINT", gsm.addr)
+
+	m := &secretMaterializer{
+		gsmSecret: newIntegrationGSMSecret("integration-external", secretspizecomv1alpha1.GSMSecretEntry{
+			Key: "API_KEY", ProjectID: "eks-proj", SecretID: "api-key", Version: "latest",
+		}),
+		gsmClientOptions: []option.ClientOption{trust},
+	}
+	t.Cleanup(func() { gcpCredentialCache.evict(*m.credKey) })
+
+	if err := m.resolvePayloads(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := payloadValues(m.payloads); got["API_KEY"] != "k3y" {
+		t.Errorf("unexpected payloads %v", got)
+	}
+	if subject, _ := sts.lastSubject(); subject != "oidc-token-1" {
+		t.Errorf("expected STS to exchange the file subject token, got %q", subject)
+	}
+	if got := gsm.lastAuthorization(); got != "Bearer sts-token-1" {
+		t.Errorf("expected Secret Manager to be called with the federated token, got %q", got)
+	}
+}
+
+func TestIntegration_QuotaProjects(t *testing.T) {
+	t.Setenv("MODE", "")
+	t.Setenv("KSA", "")
+	t.Setenv("QUOTA_PROJECT_ID", "")
+	sts := newFakeSTS(t, 3600)
+	iam := newFakeIAMCredentials(t, readerGSA)
+	gsm, trust := newFakeSecretManager(t, map[string]string{
+		"projects/data-proj/secrets/db-password/versions/1": "s3cr3t",
+		"projects/data-proj/secrets/api-key/versions/1":     "k3y",
+	})
+	var tokenRequests atomic.Int32
+	kube := newFakeTokenRequestClient(&tokenRequests)
+
+	m := &secretMaterializer{
+		gsmSecret: newIntegrationGSMSecret("integration-quota",
+			secretspizecomv1alpha1.GSMSecretEntry{Key: "DB_PASSWORD", SecretID: "db-password", Version: "1"},
+			secretspizecomv1alpha1.GSMSecretEntry{Key: "API_KEY", SecretID: "api-key", Version: "1", QuotaProjectID: "team-billing"},
+		),
+		store: &secretspizecomv1alpha1.GSMSecretStoreSpec{
+			Audience:               testWIFAudience,
+			Impersonation:          &secretspizecomv1alpha1.GSMSecretStoreImpersonation{ServiceAccount: readerGSA},
+			DefaultProjectID:       "data-proj",
+			QuotaProjectID:         "team-quota",
+			Endpoint:               gsm.addr,
+			STSEndpoint:            sts.server.URL,
+			IAMCredentialsEndpoint: iam.server.URL,
+		},
+		kubeClientFn:     func() (kubernetes.Interface, error) { return kube, nil },
+		gsmClientOptions: []option.ClientOption{trust},
+	}
+	t.Cleanup(func() { gcpCredentialCache.evict(*m.credKey) })
+
+	if err := m.resolvePayloads(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := payloadValues(m.payloads); got["DB_PASSWORD"] != "s3cr3t" || got["API_KEY"] != "k3y" {
+		t.Errorf("unexpected payloads %v", got)
+	}
+	if got := iam.lastRequest().UserProject; got != "team-quota" {
+		t.Errorf("expected impersonation to be billed to the store quota project, got %q", got)
+	}
+	gsm.mu.Lock()
+	defer gsm.mu.Unlock()
+	if got := gsm.userProjects["projects/data-proj/secrets/db-password/versions/1"]; got != "team-quota" {
+		t.Errorf("expected db-password to be billed to the store quota project, got %q", got)
+	}
+	if got := gsm.userProjects["projects/data-proj/secrets/api-key/versions/1"]; got != "team-billing" {
+		t.Errorf("expected api-key to be billed to the entry quota project, got %q", got)
+	}
+}
+
+func TestIntegration_SecretManagerRejectsUnknownVersion(t *testing.T) {
+	t.Setenv("MODE", "")
+	t.Setenv("KSA", "")
+	sts := newFakeSTS(t, 3600)
+	gsm, trust := newFakeSecretManager(t, map[string]string{})
+	var tokenRequests atomic.Int32
+	kube := newFakeTokenRequestClient(&tokenRequests)
+
+	m := &secretMaterializer{
+		gsmSecret: newIntegrationGSMSecret("integration-missing", secretspizecomv1alpha1.GSMSecretEntry{
+			Key: "MISSING", ProjectID: "data-proj", SecretID: "missing", Version: "1",
+		}),
+		store: &secretspizecomv1alpha1.GSMSecretStoreSpec{
+			Audience:    testWIFAudience,
+			Endpoint:    gsm.addr,
+			STSEndpoint: sts.server.URL,
+		},
+		kubeClientFn:     func() (kubernetes.Interface, error) { return kube, nil },
+		gsmClientOptions: []option.ClientOption{trust},
+	}
+	t.Cleanup(func() { gcpCredentialCache.evict(*m.credKey) })
+
+	err := m.resolvePayloads(context.Background())
+	if got := classifyError(err); got == nil || got.Class != errorClassNotFound {
+		t.Fatalf("expected a SecretNotFound error from Secret Manager, got %v", err)
+	}
+}
+
+func TestIntegration_PayloadParseErrorIsPermanent(t *testing.T) {
+	t.Setenv("MODE", "")
+	t.Setenv("KSA", "")
+	t.Setenv("RESYNC_JITTER_PERCENT", "0")
+	sts := newFakeSTS(t, 3600)
+	gsm, trust := newFakeSecretManager(t, map[string]string{
+		"projects/data-proj/secrets/config/versions/1": "not json",
+	})
+	var tokenRequests atomic.Int32
+	kube := newFakeTokenRequestClient(&tokenRequests)
+
+	m := &secretMaterializer{
+		gsmSecret: newIntegrationGSMSecret("integration-parse", secretspizecomv1alpha1.GSMSecretEntry{
+			Keys:      []secretspizecomv1alpha1.SecretKeyMapping{{Key: "USER", Value: "/user"}},
+			ProjectID: "data-proj", SecretID: "config", Version: "1",
+		}),
+		store: &secretspizecomv1alpha1.GSMSecretStoreSpec{
+			Audience:    testWIFAudience,
+			Endpoint:    gsm.addr,
+			STSEndpoint: sts.server.URL,
+		},
+		kubeClientFn:     func() (kubernetes.Interface, error) { return kube, nil },
+		gsmClientOptions: []option.ClientOption{trust},
+	}
+	t.Cleanup(func() { gcpCredentialCache.evict(*m.credKey) })
+
+	err := m.resolvePayloads(context.Background())
+	reason, result, retErr := fetchErrorResult(err)
+	if reason != string(errorClassParse) || retErr != nil || result.RequeueAfter != getResyncInterval() {
+		t.Errorf("expected ParseFailed without backoff, got reason=%q result=%+v err=%v", reason, result, retErr)
+	}
+}
+
+func TestIntegration_FetchesEntriesConcurrently(t *testing.T) {
+	t.Setenv("MODE", "")
+	t.Setenv("KSA", "")
+	t.Setenv("FETCH_CONCURRENCY", "3")
+	sts := newFakeSTS(t, 3600)
+	secrets := map[string]string{}
+	var entries []secretspizecomv1alpha1.GSMSecretEntry
+	for i := range 9 {
+		secretID := fmt.Sprintf("secret-%d", i)
+		secrets["projects/data-proj/secrets/"+secretID+"/versions/1"] = strconv.Itoa(i)
+		entries = append(entries, secretspizecomv1alpha1.GSMSecretEntry{
+			Key: fmt.Sprintf("KEY_%d", i), ProjectID: "data-proj", SecretID: secretID, Version: "1",
+		})
+	}
+	gsm, trust := newFakeSecretManager(t, secrets)
+	gsm.delay = 20 * time.Millisecond
+	var tokenRequests atomic.Int32
+	kube := newFakeTokenRequestClient(&tokenRequests)
+
+	m := &secretMaterializer{
+		gsmSecret: newIntegrationGSMSecret("integration-concurrent", entries...),
+		store: &secretspizecomv1alpha1.GSMSecretStoreSpec{
+			Audience:    testWIFAudience,
+			Endpoint:    gsm.addr,
+			STSEndpoint: sts.server.URL,
+		},
+		kubeClientFn:     func() (kubernetes.Interface, error) { return kube, nil },
+		gsmClientOptions: []option.ClientOption{trust},
+	}
+	t.Cleanup(func() { gcpCredentialCache.evict(*m.credKey) })
+
+	if err := m.resolvePayloads(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for i, p := range m.payloads {
+		if p.Key != fmt.Sprintf("KEY_%d", i) || string(p.Value) != strconv.Itoa(i) {
+			t.Fatalf("expected payloads in spec order, got %q=%q at %d", p.Key, p.Value, i)
+		}
+	}
+	for i, v := range m.resolvedVersions {
+		if v.Secret != fmt.Sprintf("projects/data-proj/secrets/secret-%d/versions/1", i) {
+			t.Fatalf("expected resolved versions in spec order, got %q at %d", v.Secret, i)
+		}
+	}
+	gsm.mu.Lock()
+	defer gsm.mu.Unlock()
+	if gsm.maxInFlight < 2 || gsm.maxInFlight > 3 {
+		t.Errorf("expected between 2 and FETCH_CONCURRENCY=3 concurrent calls, got %d", gsm.maxInFlight)
+	}
+}
+
+func TestIntegration_ReportsEveryBrokenEntry(t *testing.T) {
+	t.Setenv("MODE", "")
+	t.Setenv("KSA", "")
+	sts := newFakeSTS(t, 3600)
+	gsm, trust := newFakeSecretManager(t, map[string]string{
+		"projects/data-proj/secrets/ok/versions/1":     "fine",
+		"projects/data-proj/secrets/config/versions/1": "not json",
+	})
+	var tokenRequests atomic.Int32
+	kube := newFakeTokenRequestClient(&tokenRequests)
+
+	m := &secretMaterializer{
+		gsmSecret: newIntegrationGSMSecret("integration-broken",
+			secretspizecomv1alpha1.GSMSecretEntry{Key: "MISSING", ProjectID: "data-proj", SecretID: "missing", Version: "1"},
+			secretspizecomv1alpha1.GSMSecretEntry{Key: "OK", ProjectID: "data-proj", SecretID: "ok", Version: "1"},
+			secretspizecomv1alpha1.GSMSecretEntry{
+				Keys:      []secretspizecomv1alpha1.SecretKeyMapping{{Key: "USER", Value: "/user"}},
+				ProjectID: "data-proj", SecretID: "config", Version: "1",
+			},
+		),
+		store: &secretspizecomv1alpha1.GSMSecretStoreSpec{
+			Audience:    testWIFAudience,
+			Endpoint:    gsm.addr,
+			STSEndpoint: sts.server.URL,
+		},
+		kubeClientFn:     func() (kubernetes.Interface, error) { return kube, nil },
+		gsmClientOptions: []option.ClientOption{trust},
+	}
+	t.Cleanup(func() { gcpCredentialCache.evict(*m.credKey) })
+
+	err := m.resolvePayloads(context.Background())
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	msg := err.Error()
+	if !strings.HasPrefix(msg, "2 of 3 entries failed: ") ||
+		!strings.Contains(msg, `secret="missing"`) || !strings.Contains(msg, `map key mappings for secret "config"`) {
+		t.Errorf("expected both broken entries to be reported, got %q", msg)
+	}
+	if strings.Index(msg, `secret="missing"`) > strings.Index(msg, `secret "config"`) {
+		t.Errorf("expected failures in spec order, got %q", msg)
+	}
+	if m.payloads != nil {
+		t.Errorf("expected no payloads when an entry fails, got %v", m.payloads)
+	}
+}
+
+func TestIntegration_DeduplicatesVersionReads(t *testing.T) {
+	t.Setenv("MODE", "")
+	t.Setenv("KSA", "")
+	sts := newFakeSTS(t, 3600)
+	gsm, trust := newFakeSecretManager(t, map[string]string{
+		"projects/data-proj/secrets/config/versions/4": `{"user":"app","password":"hunter2"}`,
+	})
+	var tokenRequests atomic.Int32
+	kube := newFakeTokenRequestClient(&tokenRequests)
+
+	newMaterializer := func(name string) *secretMaterializer {
+		gsmSecret := newIntegrationGSMSecret("integration-dedupe",
+			secretspizecomv1alpha1.GSMSecretEntry{Key: "CONFIG", ProjectID: "data-proj", SecretID: "config", Version: "4"},
+			secretspizecomv1alpha1.GSMSecretEntry{
+				Keys:      []secretspizecomv1alpha1.SecretKeyMapping{{Key: "USER", Value: "/user"}},
+				ProjectID: "data-proj", SecretID: "config", Version: "4",
+			},
+		)
+		gsmSecret.Name = name
+		return &secretMaterializer{
+			gsmSecret: gsmSecret,
+			store: &secretspizecomv1alpha1.GSMSecretStoreSpec{
+				Audience:    testWIFAudience,
+				Endpoint:    gsm.addr,
+				STSEndpoint: sts.server.URL,
+			},
+			kubeClientFn:     func() (kubernetes.Interface, error) { return kube, nil },
+			gsmClientOptions: []option.ClientOption{trust},
+		}
+	}
+
+	first := newMaterializer("first")
+	t.Cleanup(func() { gcpCredentialCache.evict(*first.credKey) })
+	if err := first.resolvePayloads(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := payloadValues(first.payloads); got["USER"] != `"app"` || !strings.Contains(got["CONFIG"], "hunter2") {
+		t.Errorf("unexpected payloads %v", got)
+	}
+
+	// A second GSMSecret with the same identity is served from the cache.
+	second := newMaterializer("second")
+	if err := second.resolvePayloads(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := payloadValues(second.payloads); got["USER"] != `"app"` {
+		t.Errorf("unexpected payloads %v", got)
+	}
+
+	gsm.mu.Lock()
+	defer gsm.mu.Unlock()
+	if len(gsm.authorization) != 1 {
+		t.Errorf("expected one AccessSecretVersion call for both entries and GSMSecrets, got %d", len(gsm.authorization))
+	}
+}
+
+func TestIntegration_SkipsPayloadReadsWhenVersionsUnchanged(t *testing.T) {
+	t.Setenv("MODE", "")
+	t.Setenv("KSA", "")
+	sts := newFakeSTS(t, 3600)
+	gsm, trust := newFakeSecretManager(t, map[string]string{
+		"projects/data-proj/secrets/api-key/versions/1": "old",
+		"projects/data-proj/secrets/api-key/versions/2": "new",
+		"projects/data-proj/secrets/db/versions/5":      "pinned",
+	})
+	const latest = "projects/data-proj/secrets/api-key/versions/latest"
+	gsm.aliases = map[string]string{latest: "projects/data-proj/secrets/api-key/versions/1"}
+	var tokenRequests atomic.Int32
+	kube := newFakeTokenRequestClient(&tokenRequests)
+
+	// Each resync builds a new materializer from the versions in status.
+	resync := func(known []secretspizecomv1alpha1.ResolvedSecretVersion) *secretMaterializer {
+		t.Helper()
+		m := &secretMaterializer{
+			gsmSecret: newIntegrationGSMSecret("integration-versions",
+				secretspizecomv1alpha1.GSMSecretEntry{Key: "API_KEY", ProjectID: "data-proj", SecretID: "api-key", Version: "latest"},
+				secretspizecomv1alpha1.GSMSecretEntry{Key: "DB", ProjectID: "data-proj", SecretID: "db", Version: "5"},
+			),
+			store: &secretspizecomv1alpha1.GSMSecretStoreSpec{
+				Audience:    testWIFAudience,
+				Endpoint:    gsm.addr,
+				STSEndpoint: sts.server.URL,
+			},
+			kubeClientFn:     func() (kubernetes.Interface, error) { return kube, nil },
+			gsmClientOptions: []option.ClientOption{trust},
+			knownVersions:    known,
+		}
+		if err := m.resolvePayloads(context.Background()); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		t.Cleanup(func() { gcpCredentialCache.evict(*m.credKey) })
+		return m
+	}
+
+	first := resync(nil)
+	if first.unchanged || payloadValues(first.payloads)["API_KEY"] != "old" {
+		t.Fatalf("expected a full sync without known versions, got %v", payloadValues(first.payloads))
+	}
+	accesses, gets := gsm.calls()
+	if accesses != 2 || gets != 0 {
+		t.Fatalf("expected 2 accesses and no lookups, got %d and %d", accesses, gets)
+	}
+
+	second := resync(first.resolvedVersions)
+	if !second.unchanged || second.payloads != nil {
+		t.Fatalf("expected no payloads to be read when versions did not move, got %v", payloadValues(second.payloads))
+	}
+	if accesses, gets = gsm.calls(); accesses != 2 || gets != 2 {
+		t.Errorf("expected 2 lookups and no further accesses, got %d accesses and %d lookups", accesses, gets)
+	}
+
+	// latest moves to version 2: the new version is read, even though the
+	// payload of latest is still cached.
+	gsm.mu.Lock()
+	gsm.aliases[latest] = "projects/data-proj/secrets/api-key/versions/2"
+	gsm.mu.Unlock()
+	third := resync(second.resolvedVersions)
+	if third.unchanged || payloadValues(third.payloads)["API_KEY"] != "new" {
+		t.Errorf("expected the moved alias to be read again, got %v", payloadValues(third.payloads))
+	}
+
+	// An identity that may not get versions falls back to reading payloads.
+	gsm.mu.Lock()
+	gsm.denyGet = true
+	gsm.mu.Unlock()
+	if fourth := resync(third.resolvedVersions); fourth.unchanged || payloadValues(fourth.payloads)["DB"] != "pinned" {
+		t.Errorf("expected payloads to be read when the version check fails, got %v", payloadValues(fourth.payloads))
+	}
+}
@@ -90,9 +90,11 @@ func (m *secretMaterializer) requestKSAToken(ctx context.Context) (string, error
 
 	// STEP 5: Ask the Kubernetes API to mint a short-lived token for the
 	// target ServiceAccount.
+	start := time.Now()
 	resp, err := client.CoreV1().
 		ServiceAccounts(namespace).
 		CreateToken(ctx, ksa, tokenReq, metav1.CreateOptions{})
+	observeTokenExchange(tokenStepTokenRequest, start, err != nil)
 	if err != nil {
 		// STEP 6: Shape common errors into more actionable messages.
 		if apierrors.IsForbidden(err) {
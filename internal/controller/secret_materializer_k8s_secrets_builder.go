@@ -23,6 +23,7 @@ import (
 	corev1 "k8s.io/api/core/v1"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
 
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 )
@@ -47,16 +48,27 @@ func (m *secretMaterializer) buildOpaqueSecret(ctx context.Context) (*corev1.Sec
 		data[p.Key] = p.Value
 	}
 
-	return &corev1.Secret{
+	secret := &corev1.Secret{
 		TypeMeta: metav1.TypeMeta{
 			Kind:       "Secret",
 			APIVersion: "v1",
 		},
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      m.gsmSecret.Spec.TargetSecret.Name,
-			Namespace: m.gsmSecret.Namespace,
+			Namespace: targetNamespace(m.gsmSecret),
 		},
 		Type: corev1.SecretTypeOpaque,
 		Data: data,
-	}, nil
+	}
+
+	// In immutable mode every distinct payload set gets its own Secret, named
+	// after a hash of its contents so identical payloads map to the same name.
+	if m.gsmSecret.Spec.TargetSecret.Immutable {
+		secret.Name = immutableSecretName(secret.Name, secret.Type, data)
+		secret.Immutable = ptr.To(true)
+		secret.Labels = map[string]string{labelOwnerUID: string(m.gsmSecret.UID)}
+		log.Info("using content-hashed immutable Secret name", "secret", secret.Name)
+	}
+
+	return secret, nil
 }
@@ -0,0 +1,234 @@
+package controller
+
+/*
+Copyright 2025 Zera Holladay.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+import (
+	"container/list"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultPayloadCacheMaxBytes bounds the payload bytes held by the cache.
+	// Can be overridden via PAYLOAD_CACHE_MAX_BYTES; 0 disables the cache.
+	defaultPayloadCacheMaxBytes = 16 << 20
+
+	// defaultPayloadCacheAliasTTL is how long a payload read through an alias
+	// such as "latest" is reused. Can be overridden via
+	// PAYLOAD_CACHE_LATEST_TTL_SECONDS.
+	defaultPayloadCacheAliasTTL = 30 * time.Second
+
+	// defaultPayloadCachePinnedTTL is how long a pinned version is reused.
+	// The payload cannot change, but the version can be disabled or the
+	// identity's access revoked, so it is read again after this long. Can be
+	// overridden via PAYLOAD_CACHE_PINNED_TTL_SECONDS.
+	defaultPayloadCachePinnedTTL = 10 * time.Minute
+)
+
+// getPayloadCacheMaxBytes returns the cache bound from PAYLOAD_CACHE_MAX_BYTES,
+// or the default if not set or invalid.
+func getPayloadCacheMaxBytes() int {
+	if v := os.Getenv("PAYLOAD_CACHE_MAX_BYTES"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+			return n
+		}
+	}
+	return defaultPayloadCacheMaxBytes
+}
+
+// getPayloadCacheAliasTTL returns the alias TTL from
+// PAYLOAD_CACHE_LATEST_TTL_SECONDS, or the default if not set or invalid.
+func getPayloadCacheAliasTTL() time.Duration {
+	if v := os.Getenv("PAYLOAD_CACHE_LATEST_TTL_SECONDS"); v != "" {
+		if seconds, err := strconv.Atoi(v); err == nil && seconds > 0 {
+			return time.Duration(seconds) * time.Second
+		}
+	}
+	return defaultPayloadCacheAliasTTL
+}
+
+// getPayloadCachePinnedTTL returns the pinned version TTL from
+// PAYLOAD_CACHE_PINNED_TTL_SECONDS, or the default if not set or invalid.
+func getPayloadCachePinnedTTL() time.Duration {
+	if v := os.Getenv("PAYLOAD_CACHE_PINNED_TTL_SECONDS"); v != "" {
+		if seconds, err := strconv.Atoi(v); err == nil && seconds > 0 {
+			return time.Duration(seconds) * time.Second
+		}
+	}
+	return defaultPayloadCachePinnedTTL
+}
+
+// payloadCacheKey identifies a secret version as read by one pooled client,
+// so a payload is only ever served to the identity that was allowed to read it.
+type payloadCacheKey struct {
+	Client gsmClientKey
+	// Name is the requested version resource, e.g.
+	// projects/p/secrets/s/versions/3 or .../versions/latest.
+	Name string
+}
+
+// payloadCacheEntry is one cached payload and the version it resolved to.
+type payloadCacheEntry struct {
+	key     payloadCacheKey
+	data    []byte
+	version string
+	expires time.Time
+}
+
+// payloadCache is a process-wide, size-bounded LRU cache of Secret Manager
+// payloads. Aliases such as "latest" expire after a short TTL, and pinned
+// (numeric) versions after a longer one, so a disabled version or revoked
+// grant is noticed without a restart. Evicted payloads are
+// zeroed, and callers only ever see copies. It is safe for concurrent use.
+type payloadCache struct {
+	mu sync.Mutex
+	// lru holds *payloadCacheEntry, most recently used first.
+	lru       *list.List
+	entries   map[payloadCacheKey]*list.Element
+	size      int
+	maxBytes  func() int
+	aliasTTL  func() time.Duration
+	pinnedTTL func() time.Duration
+	now       func() time.Time
+}
+
+// secretPayloadCache is shared by every reconcile in the process.
+var secretPayloadCache = newPayloadCache(getPayloadCacheMaxBytes, getPayloadCacheAliasTTL, getPayloadCachePinnedTTL)
+
+func newPayloadCache(maxBytes func() int, aliasTTL, pinnedTTL func() time.Duration) *payloadCache {
+	return &payloadCache{
+		lru:       list.New(),
+		entries:   map[payloadCacheKey]*list.Element{},
+		maxBytes:  maxBytes,
+		aliasTTL:  aliasTTL,
+		pinnedTTL: pinnedTTL,
+		now:       time.Now,
+	}
+}
+
+// isPinnedVersion reports whether the version resource name ends in a
+// version number rather than an alias.
+func isPinnedVersion(name string) bool {
+	_, err := strconv.ParseUint(name[strings.LastIndex(name, "/")+1:], 10, 64)
+	return err == nil
+}
+
+// get returns a copy of the cached payload for key and the version it
+// resolved to.
+func (c *payloadCache) get(key payloadCacheKey) ([]byte, string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.entries[key]
+	if !ok {
+		payloadCacheRequests.WithLabelValues("miss").Inc()
+		return nil, "", false
+	}
+	entry := el.Value.(*payloadCacheEntry)
+	if !c.now().Before(entry.expires) {
+		c.removeLocked(el)
+		payloadCacheRequests.WithLabelValues("miss").Inc()
+		return nil, "", false
+	}
+	c.lru.MoveToFront(el)
+	payloadCacheRequests.WithLabelValues("hit").Inc()
+	return append([]byte(nil), entry.data...), entry.version, true
+}
+
+// put stores a copy of data for key, evicting the least recently used
+// payloads to stay within the size bound.
+func (c *payloadCache) put(key payloadCacheKey, data []byte, version string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.entries[key]; ok {
+		c.removeLocked(el)
+	}
+	maxBytes := c.maxBytes()
+	if maxBytes == 0 || len(data) > maxBytes {
+		return
+	}
+
+	ttl := c.aliasTTL()
+	if isPinnedVersion(key.Name) {
+		ttl = c.pinnedTTL()
+	}
+	entry := &payloadCacheEntry{key: key, data: append([]byte(nil), data...), version: version, expires: c.now().Add(ttl)}
+	c.entries[key] = c.lru.PushFront(entry)
+	c.size += len(entry.data)
+
+	for c.size > maxBytes {
+		c.removeLocked(c.lru.Back())
+		payloadCacheEvictions.Inc()
+	}
+}
+
+// evictClient drops every payload read by the client under key, e.g. after
+// Secret Manager rejected its credentials.
+func (c *payloadCache) evictClient(key gsmClientKey) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for k, el := range c.entries {
+		if k.Client == key {
+			c.removeLocked(el)
+		}
+	}
+}
+
+// removeLocked unlinks el and zeroes its payload. c.mu must be held.
+func (c *payloadCache) removeLocked(el *list.Element) {
+	entry := c.lru.Remove(el).(*payloadCacheEntry)
+	delete(c.entries, entry.key)
+	c.size -= len(entry.data)
+	clear(entry.data)
+}
+
+// versionRead is one AccessSecretVersion call shared by every entry of a
+// reconcile that requests the same version.
+type versionRead struct {
+	once    sync.Once
+	data    []byte
+	version string
+	err     error
+}
+
+// versionReads deduplicates secret version reads within one reconcile.
+type versionReads struct {
+	mu    sync.Mutex
+	reads map[payloadCacheKey]*versionRead
+}
+
+func newVersionReads() *versionReads {
+	return &versionReads{reads: map[payloadCacheKey]*versionRead{}}
+}
+
+// get returns the shared read for key, creating it on first use.
+func (r *versionReads) get(key payloadCacheKey) *versionRead {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	read, ok := r.reads[key]
+	if !ok {
+		read = &versionRead{}
+		r.reads[key] = read
+	}
+	return read
+}
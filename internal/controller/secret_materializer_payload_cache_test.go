@@ -0,0 +1,186 @@
+/*
+Copyright 2025 Zera Holladay.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"testing"
+	"time"
+)
+
+func newTestPayloadCache(maxBytes int) (*payloadCache, *time.Time) {
+	now := time.Now()
+	c := newPayloadCache(
+		func() int { return maxBytes },
+		func() time.Duration { return 30 * time.Second },
+		func() time.Duration { return 10 * time.Minute },
+	)
+	c.now = func() time.Time { return now }
+	return c, &now
+}
+
+func testPayloadKey(identity, name string) payloadCacheKey {
+	return payloadCacheKey{Client: gsmClientKey{Identity: credentialCacheKey{GSA: identity}}, Name: name}
+}
+
+func TestPayloadCache_GetReturnsCopies(t *testing.T) {
+	c, _ := newTestPayloadCache(1024)
+	key := testPayloadKey("reader", "projects/p/secrets/s/versions/3")
+
+	data := []byte("s3cr3t")
+	c.put(key, data, "3")
+	data[0] = 'X'
+
+	got, version, ok := c.get(key)
+	if !ok || string(got) != "s3cr3t" || version != "3" {
+		t.Fatalf("expected cached copy of the payload, got %q %q %v", got, version, ok)
+	}
+	got[0] = 'Y'
+	if again, _, _ := c.get(key); string(again) != "s3cr3t" {
+		t.Errorf("expected callers not to alias the cached payload, got %q", again)
+	}
+}
+
+func TestPayloadCache_ScopedToIdentity(t *testing.T) {
+	c, _ := newTestPayloadCache(1024)
+	c.put(testPayloadKey("reader", "projects/p/secrets/s/versions/3"), []byte("s3cr3t"), "3")
+
+	if _, _, ok := c.get(testPayloadKey("other", "projects/p/secrets/s/versions/3")); ok {
+		t.Error("expected a payload read by one identity not to be served to another")
+	}
+}
+
+func TestPayloadCache_Expires(t *testing.T) {
+	c, now := newTestPayloadCache(1024)
+	pinned := testPayloadKey("reader", "projects/p/secrets/s/versions/3")
+	latest := testPayloadKey("reader", "projects/p/secrets/s/versions/latest")
+	c.put(pinned, []byte("v3"), "3")
+	c.put(latest, []byte("v3"), "3")
+
+	*now = now.Add(29 * time.Second)
+	if _, _, ok := c.get(latest); !ok {
+		t.Error("expected latest to be cached within its TTL")
+	}
+	*now = now.Add(time.Second)
+	if _, _, ok := c.get(latest); ok {
+		t.Error("expected latest to expire after its TTL")
+	}
+	if _, _, ok := c.get(pinned); !ok {
+		t.Error("expected a pinned version to outlive the alias TTL")
+	}
+	*now = now.Add(10*time.Minute - 30*time.Second)
+	if _, _, ok := c.get(pinned); ok {
+		t.Error("expected a pinned version to expire after its TTL")
+	}
+}
+
+func TestPayloadCache_EvictsLeastRecentlyUsedAndZeroes(t *testing.T) {
+	c, _ := newTestPayloadCache(8)
+	a := testPayloadKey("reader", "projects/p/secrets/a/versions/1")
+	b := testPayloadKey("reader", "projects/p/secrets/b/versions/1")
+	d := testPayloadKey("reader", "projects/p/secrets/d/versions/1")
+
+	c.put(a, []byte("aaaa"), "1")
+	c.put(b, []byte("bbbb"), "1")
+	// Touching a makes b the least recently used.
+	c.get(a)
+	stored := c.entries[b].Value.(*payloadCacheEntry).data
+	c.put(d, []byte("dddd"), "1")
+
+	if _, _, ok := c.get(b); ok {
+		t.Error("expected the least recently used payload to be evicted")
+	}
+	if _, _, ok := c.get(a); !ok {
+		t.Error("expected the recently used payload to be kept")
+	}
+	if string(stored) != "\x00\x00\x00\x00" {
+		t.Errorf("expected the evicted payload to be zeroed, got %q", stored)
+	}
+	if c.size != 8 {
+		t.Errorf("expected 8 cached bytes, got %d", c.size)
+	}
+}
+
+func TestPayloadCache_OversizedAndDisabled(t *testing.T) {
+	c, _ := newTestPayloadCache(4)
+	key := testPayloadKey("reader", "projects/p/secrets/s/versions/1")
+	c.put(key, []byte("too long"), "1")
+	if _, _, ok := c.get(key); ok || c.size != 0 {
+		t.Error("expected a payload larger than the bound not to be cached")
+	}
+
+	disabled, _ := newTestPayloadCache(0)
+	disabled.put(key, nil, "1")
+	if _, _, ok := disabled.get(key); ok {
+		t.Error("expected PAYLOAD_CACHE_MAX_BYTES=0 to disable the cache")
+	}
+}
+
+func TestPayloadCache_EvictClient(t *testing.T) {
+	c, _ := newTestPayloadCache(1024)
+	reader := testPayloadKey("reader", "projects/p/secrets/s/versions/1")
+	other := testPayloadKey("other", "projects/p/secrets/s/versions/1")
+	c.put(reader, []byte("r"), "1")
+	c.put(other, []byte("o"), "1")
+
+	c.evictClient(reader.Client)
+	if _, _, ok := c.get(reader); ok {
+		t.Error("expected the client's payloads to be evicted")
+	}
+	if _, _, ok := c.get(other); !ok {
+		t.Error("expected other clients' payloads to be kept")
+	}
+}
+
+func TestIsPinnedVersion(t *testing.T) {
+	for name, want := range map[string]bool{
+		"projects/p/secrets/s/versions/3":      true,
+		"projects/p/secrets/s/versions/latest": false,
+		"projects/p/secrets/s/versions/prod":   false,
+		"12":                                   true,
+	} {
+		if got := isPinnedVersion(name); got != want {
+			t.Errorf("isPinnedVersion(%q) = %v, want %v", name, got, want)
+		}
+	}
+}
+
+func TestGetPayloadCacheSettings(t *testing.T) {
+	t.Setenv("PAYLOAD_CACHE_MAX_BYTES", "0")
+	if got := getPayloadCacheMaxBytes(); got != 0 {
+		t.Errorf("expected 0 to disable the cache, got %d", got)
+	}
+	t.Setenv("PAYLOAD_CACHE_MAX_BYTES", "-1")
+	if got := getPayloadCacheMaxBytes(); got != defaultPayloadCacheMaxBytes {
+		t.Errorf("expected default for invalid value, got %d", got)
+	}
+	t.Setenv("PAYLOAD_CACHE_LATEST_TTL_SECONDS", "5")
+	if got := getPayloadCacheAliasTTL(); got != 5*time.Second {
+		t.Errorf("expected 5s, got %s", got)
+	}
+	t.Setenv("PAYLOAD_CACHE_LATEST_TTL_SECONDS", "0")
+	if got := getPayloadCacheAliasTTL(); got != defaultPayloadCacheAliasTTL {
+		t.Errorf("expected default for invalid value, got %s", got)
+	}
+	t.Setenv("PAYLOAD_CACHE_PINNED_TTL_SECONDS", "60")
+	if got := getPayloadCachePinnedTTL(); got != time.Minute {
+		t.Errorf("expected 1m, got %s", got)
+	}
+	t.Setenv("PAYLOAD_CACHE_PINNED_TTL_SECONDS", "x")
+	if got := getPayloadCachePinnedTTL(); got != defaultPayloadCachePinnedTTL {
+		t.Errorf("expected default for invalid value, got %s", got)
+	}
+}
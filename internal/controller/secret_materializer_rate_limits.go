@@ -0,0 +1,134 @@
+package controller
+
+/*
+Copyright 2025 Zera Holladay.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+const (
+	// defaultSecretManagerQPS is the rate of Secret Manager calls per GCP
+	// project. Can be overridden via GSM_QPS.
+	defaultSecretManagerQPS = 100
+
+	// defaultSTSQPS is the rate of STS token exchanges. Can be overridden via
+	// STS_QPS.
+	defaultSTSQPS = 20
+
+	// defaultIAMCredentialsQPS is the rate of IAM Credentials
+	// generateAccessToken calls. Can be overridden via IAM_CREDENTIALS_QPS.
+	defaultIAMCredentialsQPS = 20
+)
+
+// Rate limiter names, the values of the limiter label.
+const (
+	limiterSecretManager  = "secret_manager"
+	limiterSTS            = "sts"
+	limiterIAMCredentials = "iam_credentials"
+)
+
+// getQPS returns the rate from the env var name, or def if not set or invalid.
+func getQPS(name string, def int) int {
+	if v := os.Getenv(name); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			return n
+		}
+	}
+	return def
+}
+
+// rateLimiters is a set of process-wide token buckets, one per key, each
+// allowing qps calls per second with bursts of up to qps calls. A bucket's
+// rate is read when the bucket is first used. It is safe for concurrent use.
+type rateLimiters struct {
+	name string
+	qps  func() int
+
+	mu      sync.Mutex
+	buckets map[string]*rate.Limiter
+}
+
+var (
+	// secretManagerLimits is keyed by the GCP project of the secret.
+	secretManagerLimits = newRateLimiters(limiterSecretManager, func() int { return getQPS("GSM_QPS", defaultSecretManagerQPS) })
+	// stsLimits and iamCredentialsLimits have a single, unkeyed bucket.
+	stsLimits            = newRateLimiters(limiterSTS, func() int { return getQPS("STS_QPS", defaultSTSQPS) })
+	iamCredentialsLimits = newRateLimiters(limiterIAMCredentials, func() int { return getQPS("IAM_CREDENTIALS_QPS", defaultIAMCredentialsQPS) })
+)
+
+func newRateLimiters(name string, qps func() int) *rateLimiters {
+	return &rateLimiters{name: name, qps: qps, buckets: map[string]*rate.Limiter{}}
+}
+
+// wait blocks until the bucket for key has a token, or fails when ctx ends
+// first, and records how long the call was held back.
+func (l *rateLimiters) wait(ctx context.Context, key string) error {
+	l.mu.Lock()
+	bucket, ok := l.buckets[key]
+	if !ok {
+		qps := l.qps()
+		bucket = rate.NewLimiter(rate.Limit(qps), qps)
+		l.buckets[key] = bucket
+	}
+	l.mu.Unlock()
+
+	start := time.Now()
+	err := bucket.Wait(ctx)
+	rateLimitWait.WithLabelValues(l.name).Observe(time.Since(start).Seconds())
+	if err != nil {
+		return fmt.Errorf("wait for %s rate limit: %w", l.name, err)
+	}
+	return nil
+}
+
+// tokenExchangeTransport rate limits and times the STS and IAM Credentials
+// calls the externalaccount library makes through the context's HTTP client.
+// Other requests, such as credential source fetches, pass through as is.
+type tokenExchangeTransport struct {
+	base     http.RoundTripper
+	tokenURL string
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *tokenExchangeTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var step string
+	var limits *rateLimiters
+	switch {
+	case req.URL.String() == t.tokenURL:
+		step, limits = tokenStepSTS, stsLimits
+	case strings.HasSuffix(req.URL.Path, ":generateAccessToken"):
+		step, limits = tokenStepImpersonation, iamCredentialsLimits
+	default:
+		return t.base.RoundTrip(req)
+	}
+	if err := limits.wait(req.Context(), ""); err != nil {
+		return nil, err
+	}
+	start := time.Now()
+	resp, err := t.base.RoundTrip(req)
+	observeTokenExchange(step, start, err != nil || resp.StatusCode >= http.StatusBadRequest)
+	return resp, err
+}
@@ -0,0 +1,65 @@
+package controller
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRateLimiters_ThrottlesPerKey(t *testing.T) {
+	limits := newRateLimiters("test", func() int { return 20 })
+	ctx := context.Background()
+
+	// The burst passes at once.
+	start := time.Now()
+	for range 20 {
+		if err := limits.wait(ctx, "proj-a"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed > 40*time.Millisecond {
+		t.Errorf("expected the burst not to wait, took %v", elapsed)
+	}
+
+	// Another project has its own bucket.
+	start = time.Now()
+	if err := limits.wait(ctx, "proj-b"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 40*time.Millisecond {
+		t.Errorf("expected another project not to wait, took %v", elapsed)
+	}
+
+	// The next call on the exhausted bucket waits for a token (50ms at 20 QPS).
+	start = time.Now()
+	if err := limits.wait(ctx, "proj-a"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 30*time.Millisecond {
+		t.Errorf("expected the call to wait for a token, took %v", elapsed)
+	}
+}
+
+func TestRateLimiters_ContextEnds(t *testing.T) {
+	limits := newRateLimiters("test", func() int { return 1 })
+	if err := limits.wait(context.Background(), ""); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	err := limits.wait(ctx, "")
+	if err == nil || !strings.Contains(err.Error(), "wait for test rate limit") {
+		t.Errorf("expected a rate limit error when the context ends first, got %v", err)
+	}
+}
+
+func TestGetQPS(t *testing.T) {
+	for value, want := range map[string]int{"": 7, "50": 50, "0": 7, "-1": 7, "fast": 7} {
+		t.Setenv("TEST_QPS", value)
+		if got := getQPS("TEST_QPS", 7); got != want {
+			t.Errorf("TEST_QPS=%q: expected %d, got %d", value, want, got)
+		}
+	}
+}
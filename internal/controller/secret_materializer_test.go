@@ -7,6 +7,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	secretspizecomv1alpha1 "github.com/zeraholladay/gsm-operator/api/v1alpha1"
+	"github.com/zeraholladay/gsm-operator/internal/ksaapproval"
 )
 
 func TestGetTokenExpSecondsDefault(t *testing.T) {
@@ -80,8 +81,8 @@ func TestGetKSADefault(t *testing.T) {
 		},
 	}
 
-	if got := m.getKSA(); got != defaultKSAName {
-		t.Fatalf("expected default KSA %q, got %q", defaultKSAName, got)
+	if got := m.getKSA(); got != ksaapproval.DefaultServiceAccount {
+		t.Fatalf("expected default KSA %q, got %q", ksaapproval.DefaultServiceAccount, got)
 	}
 }
 
@@ -0,0 +1,115 @@
+package controller
+
+/*
+Copyright 2025 Zera Holladay.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	secretmanager "cloud.google.com/go/secretmanager/apiv1"
+	"cloud.google.com/go/secretmanager/apiv1/secretmanagerpb"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// secretVersionName returns the resource name of a secret version.
+func secretVersionName(projectID, secretID, version string) string {
+	return fmt.Sprintf("projects/%s/secrets/%s/versions/%s", projectID, secretID, version)
+}
+
+// versionCheck is one GetSecretVersion call made by checkVersionsUnchanged.
+type versionCheck struct {
+	client          *gsmClient
+	projectID, name string
+	version         string
+	err             error
+}
+
+// checkVersionsUnchanged resolves every entry with GetSecretVersion, which
+// reads metadata only, and reports whether each still resolves to the enabled
+// version recorded in knownVersions. The versions it resolved are kept in
+// checkedVersions. It stops at entries the last sync did not record, such as
+// after a store's default project changed.
+func (m *secretMaterializer) checkVersionsUnchanged(ctx context.Context, clients map[string]*gsmClient) (bool, error) {
+	known := make(map[string]string, len(m.knownVersions))
+	for _, v := range m.knownVersions {
+		known[v.Secret] = v.Version
+	}
+
+	var checks []*versionCheck
+	seen := map[payloadCacheKey]bool{}
+	for _, e := range m.gsmSecret.Spec.Secrets {
+		projectID, err := m.getProjectID(e)
+		if err != nil {
+			return false, err
+		}
+		name := secretVersionName(projectID, e.SecretID, e.Version)
+		if _, ok := known[name]; !ok {
+			return false, nil
+		}
+		client := clients[m.getQuotaProject(e)]
+		key := payloadCacheKey{Client: client.key, Name: name}
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		checks = append(checks, &versionCheck{client: client, projectID: projectID, name: name})
+	}
+
+	forEachConcurrently(len(checks), func(i int) {
+		c := checks[i]
+		c.version, c.err = getSecretVersion(ctx, c.client.Client, c.projectID, c.name)
+	})
+
+	unchanged := true
+	m.checkedVersions = make(map[string]string, len(checks))
+	for _, c := range checks {
+		if c.err != nil {
+			return false, c.err
+		}
+		m.checkedVersions[c.name] = c.version
+		if c.version != known[c.name] {
+			unchanged = false
+		}
+	}
+	return unchanged, nil
+}
+
+// getSecretVersion returns the number of the enabled version name resolves to.
+func getSecretVersion(ctx context.Context, client *secretmanager.Client, projectID, name string) (string, error) {
+	log := logf.FromContext(ctx).WithValues("name", name)
+
+	if err := secretManagerLimits.wait(ctx, projectID); err != nil {
+		return "", err
+	}
+	v, err := client.GetSecretVersion(ctx, &secretmanagerpb.GetSecretVersionRequest{Name: name})
+	observeVersionLookup(projectID, err)
+	if err != nil {
+		return "", fmt.Errorf("GetSecretVersion(%s): %w", name, err)
+	}
+	if v.GetState() != secretmanagerpb.SecretVersion_ENABLED {
+		return "", fmt.Errorf("secret version %s is %s", v.GetName(), v.GetState())
+	}
+
+	resolved := v.GetName()
+	if resolved == "" {
+		resolved = name
+	}
+	version := resolved[strings.LastIndex(resolved, "/")+1:]
+	log.V(1).Info("resolved GSM secret version", "version", version)
+	return version, nil
+}
@@ -0,0 +1,113 @@
+/*
+Copyright 2025 Zera Holladay.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package ksaapproval checks that the author of a GSMSecret may mint tokens
+// for the Kubernetes ServiceAccount the operator would use on their behalf.
+package ksaapproval
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	secretspizecomv1alpha1 "github.com/zeraholladay/gsm-operator/api/v1alpha1"
+)
+
+// DefaultServiceAccount is the KSA used when none is configured.
+const DefaultServiceAccount = "default"
+
+// Required reports whether GSMSecrets must carry an approved KSA before the
+// controller requests tokens for it. It defaults to true, so a GSMSecret
+// never gets tokens for a KSA its author could not mint tokens for; set
+// REQUIRE_KSA_APPROVAL=false to opt out when the admission webhook is not
+// deployed. An unparsable value keeps approval required.
+func Required() bool {
+	if v := os.Getenv("REQUIRE_KSA_APPROVAL"); v != "" {
+		if required, err := strconv.ParseBool(v); err == nil {
+			return required
+		}
+	}
+	return true
+}
+
+// ServiceAccount returns the KSA the operator requests tokens for on behalf
+// of gsmSecret: the store's serviceAccountName, then the KSA env var, then,
+// without a store, the GSMSecret's ksa annotation, then
+// DefaultServiceAccount.
+func ServiceAccount(
+	gsmSecret *secretspizecomv1alpha1.GSMSecret,
+	store *secretspizecomv1alpha1.GSMSecretStoreSpec,
+) string {
+	if store != nil && store.ServiceAccountName != "" {
+		return store.ServiceAccountName
+	}
+	// Override the KSA via env var if your GKE RBAC requires a specific ServiceAccount (e.g., gsm-reader).
+	if v := os.Getenv("KSA"); v != "" {
+		return v
+	}
+	if store == nil {
+		if v := strings.TrimSpace(gsmSecret.GetAnnotations()[secretspizecomv1alpha1.AnnotationKSA]); v != "" {
+			return v
+		}
+	}
+	return DefaultServiceAccount
+}
+
+// Review asks the API server whether user may create serviceaccounts/token
+// for ksa in namespace, which is what the operator does on the user's behalf.
+// It returns nil when allowed.
+func Review(ctx context.Context, c client.Client, user authenticationv1.UserInfo, namespace, ksa string) error {
+	sar := &authorizationv1.SubjectAccessReview{
+		Spec: authorizationv1.SubjectAccessReviewSpec{
+			User:   user.Username,
+			UID:    user.UID,
+			Groups: user.Groups,
+			ResourceAttributes: &authorizationv1.ResourceAttributes{
+				Namespace:   namespace,
+				Verb:        "create",
+				Resource:    "serviceaccounts",
+				Subresource: "token",
+				Name:        ksa,
+			},
+		},
+	}
+	if len(user.Extra) > 0 {
+		sar.Spec.Extra = make(map[string]authorizationv1.ExtraValue, len(user.Extra))
+		for k, v := range user.Extra {
+			sar.Spec.Extra[k] = authorizationv1.ExtraValue(v)
+		}
+	}
+
+	if err := c.Create(ctx, sar); err != nil {
+		return fmt.Errorf("create SubjectAccessReview: %w", err)
+	}
+	if !sar.Status.Allowed {
+		msg := fmt.Sprintf("user %q may not create serviceaccounts/token for ServiceAccount %q in namespace %q",
+			user.Username, ksa, namespace)
+		if sar.Status.Reason != "" {
+			msg += ": " + sar.Status.Reason
+		}
+		return errors.New(msg)
+	}
+	return nil
+}
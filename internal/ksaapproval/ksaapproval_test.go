@@ -0,0 +1,94 @@
+/*
+Copyright 2025 Zera Holladay.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ksaapproval
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+)
+
+// newSARClient returns a client whose SubjectAccessReviews allow only the
+// given user to create tokens for the given KSA, recording the last review.
+func newSARClient(user, ksa string, last *authorizationv1.SubjectAccessReviewSpec) client.Client {
+	scheme := runtime.NewScheme()
+	_ = clientgoscheme.AddToScheme(scheme)
+	return fake.NewClientBuilder().WithScheme(scheme).WithInterceptorFuncs(interceptor.Funcs{
+		Create: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.CreateOption) error {
+			sar, ok := obj.(*authorizationv1.SubjectAccessReview)
+			if !ok {
+				return c.Create(ctx, obj, opts...)
+			}
+			*last = sar.Spec
+			ra := sar.Spec.ResourceAttributes
+			sar.Status.Allowed = sar.Spec.User == user && ra.Name == ksa &&
+				ra.Verb == "create" && ra.Resource == "serviceaccounts" && ra.Subresource == "token"
+			if !sar.Status.Allowed {
+				sar.Status.Reason = "RBAC: no matching rule"
+			}
+			return nil
+		},
+	}).Build()
+}
+
+func TestRequired(t *testing.T) {
+	t.Setenv("REQUIRE_KSA_APPROVAL", "")
+	if !Required() {
+		t.Error("expected approval to be required by default")
+	}
+	t.Setenv("REQUIRE_KSA_APPROVAL", "false")
+	if Required() {
+		t.Error("expected approval to be optional when opted out")
+	}
+	t.Setenv("REQUIRE_KSA_APPROVAL", "sometimes")
+	if !Required() {
+		t.Error("expected an invalid value to keep approval required")
+	}
+}
+
+func TestReview(t *testing.T) {
+	var last authorizationv1.SubjectAccessReviewSpec
+	c := newSARClient("alice", "reader", &last)
+	alice := authenticationv1.UserInfo{
+		Username: "alice",
+		Groups:   []string{"team-a"},
+		Extra:    map[string]authenticationv1.ExtraValue{"scopes": {"x"}},
+	}
+
+	if err := Review(context.Background(), c, alice, "team-a", "reader"); err != nil {
+		t.Fatalf("expected review to pass, got %v", err)
+	}
+	if last.ResourceAttributes.Namespace != "team-a" || last.Groups[0] != "team-a" || last.Extra["scopes"][0] != "x" {
+		t.Errorf("expected the requesting user and namespace to be reviewed, got %+v", last)
+	}
+
+	err := Review(context.Background(), c, alice, "team-a", "admin")
+	if err == nil || !strings.Contains(err.Error(), `may not create serviceaccounts/token for ServiceAccount "admin"`) {
+		t.Errorf("expected denial for another KSA, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "RBAC: no matching rule") {
+		t.Errorf("expected the SubjectAccessReview reason in the error, got %v", err)
+	}
+}
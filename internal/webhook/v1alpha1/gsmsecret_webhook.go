@@ -0,0 +1,296 @@
+/*
+Copyright 2025 Zera Holladay.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	secretspizecomv1alpha1 "github.com/zeraholladay/gsm-operator/api/v1alpha1"
+	"github.com/zeraholladay/gsm-operator/internal/accesspolicy"
+	"github.com/zeraholladay/gsm-operator/internal/authmode"
+	"github.com/zeraholladay/gsm-operator/internal/ksaapproval"
+)
+
+// log is for logging in this package.
+var gsmsecretlog = logf.Log.WithName("gsmsecret-resource")
+
+// SetupGSMSecretWebhookWithManager registers the webhook for GSMSecret in the manager.
+func SetupGSMSecretWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).For(&secretspizecomv1alpha1.GSMSecret{}).
+		WithValidator(&GSMSecretCustomValidator{Client: mgr.GetClient()}).
+		WithDefaulter(&GSMSecretCustomDefaulter{Client: mgr.GetClient()}).
+		Complete()
+}
+
+// +kubebuilder:webhook:path=/mutate-secrets-gsm-operator-io-v1alpha1-gsmsecret,mutating=true,failurePolicy=fail,sideEffects=None,groups=secrets.gsm-operator.io,resources=gsmsecrets,verbs=create;update,versions=v1alpha1,name=mgsmsecret-v1alpha1.kb.io,admissionReviewVersions=v1
+
+// GSMSecretCustomDefaulter records which KSA the requesting user may mint
+// tokens for. When KSA approval is required, it runs a SubjectAccessReview
+// for serviceaccounts/token on the KSA the operator would use, and stores the
+// result in the approved-ksa annotation. The controller refuses to request
+// tokens for any other KSA.
+// +kubebuilder:rbac:groups=authorization.k8s.io,resources=subjectaccessreviews,verbs=create
+type GSMSecretCustomDefaulter struct {
+	Client client.Client
+}
+
+var _ webhook.CustomDefaulter = &GSMSecretCustomDefaulter{}
+
+// Default implements webhook.CustomDefaulter so a webhook will be registered for the type GSMSecret.
+func (d *GSMSecretCustomDefaulter) Default(ctx context.Context, obj runtime.Object) error {
+	gsmsecret, ok := obj.(*secretspizecomv1alpha1.GSMSecret)
+	if !ok {
+		return fmt.Errorf("expected a GSMSecret object but got %T", obj)
+	}
+	gsmsecretlog.V(1).Info("Defaulting for GSMSecret", "name", gsmsecret.GetName())
+
+	if !ksaapproval.Required() || gsmsecret.DeletionTimestamp != nil {
+		return nil
+	}
+	req, err := admission.RequestFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	// Whatever the user sent is replaced below; only a fresh check may set it.
+	approved := gsmsecret.GetAnnotations()[secretspizecomv1alpha1.AnnotationApprovedKSA]
+	delete(gsmsecret.Annotations, secretspizecomv1alpha1.AnnotationApprovedKSA)
+
+	store, err := getStore(ctx, d.Client, gsmsecret)
+	if err != nil {
+		return fmt.Errorf("cannot determine the ServiceAccount to approve: %w", err)
+	}
+	ksa, ok := effectiveKSA(gsmsecret, store)
+	if !ok {
+		// Trusted subsystem mode never requests tokens for a tenant KSA.
+		return nil
+	}
+
+	// Keep an approval already granted for this KSA only on updates that
+	// leave the spec alone, such as the operator adding its finalizer. Any
+	// spec change is reviewed against the user making it.
+	if req.Operation == admissionv1.Update && approved == ksa {
+		var old secretspizecomv1alpha1.GSMSecret
+		if err := json.Unmarshal(req.OldObject.Raw, &old); err != nil {
+			return fmt.Errorf("decode old GSMSecret: %w", err)
+		}
+		if old.GetAnnotations()[secretspizecomv1alpha1.AnnotationApprovedKSA] == ksa &&
+			equality.Semantic.DeepEqual(old.Spec, gsmsecret.Spec) {
+			setAnnotation(gsmsecret, secretspizecomv1alpha1.AnnotationApprovedKSA, ksa)
+			return nil
+		}
+	}
+
+	if err := ksaapproval.Review(ctx, d.Client, req.UserInfo, gsmsecret.Namespace, ksa); err != nil {
+		return err
+	}
+	setAnnotation(gsmsecret, secretspizecomv1alpha1.AnnotationApprovedKSA, ksa)
+	return nil
+}
+
+func setAnnotation(obj client.Object, key, value string) {
+	annotations := obj.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[key] = value
+	obj.SetAnnotations(annotations)
+}
+
+// effectiveKSA returns the KSA the controller would request tokens for. It
+// returns false in every mode but Workload Identity Federation, where no
+// tenant KSA is used.
+func effectiveKSA(
+	gsmsecret *secretspizecomv1alpha1.GSMSecret,
+	store *secretspizecomv1alpha1.GSMSecretStoreSpec,
+) (string, bool) {
+	if authmode.Resolve(gsmsecret, store) != secretspizecomv1alpha1.AuthModeWorkloadIdentityFederation {
+		return "", false
+	}
+	return ksaapproval.ServiceAccount(gsmsecret, store), true
+}
+
+// +kubebuilder:webhook:path=/validate-secrets-gsm-operator-io-v1alpha1-gsmsecret,mutating=false,failurePolicy=fail,sideEffects=None,groups=secrets.gsm-operator.io,resources=gsmsecrets,verbs=create;update,versions=v1alpha1,name=vgsmsecret-v1alpha1.kb.io,admissionReviewVersions=v1
+
+// GSMSecretCustomValidator rejects GSMSecrets whose auth mode AUTH_MODE_POLICY
+// does not allow in their namespace, and, when policy enforcement is enabled,
+// whose reads no GSMAccessPolicy allows. The controller enforces the same
+// policies, so the webhook only moves the error to admission time.
+type GSMSecretCustomValidator struct {
+	Client client.Reader
+}
+
+var _ webhook.CustomValidator = &GSMSecretCustomValidator{}
+
+// ValidateCreate implements webhook.CustomValidator so a webhook will be registered for the type GSMSecret.
+func (v *GSMSecretCustomValidator) ValidateCreate(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	gsmsecret, ok := obj.(*secretspizecomv1alpha1.GSMSecret)
+	if !ok {
+		return nil, fmt.Errorf("expected a GSMSecret object but got %T", obj)
+	}
+	gsmsecretlog.V(1).Info("Validation for GSMSecret upon creation", "name", gsmsecret.GetName())
+	return v.validate(ctx, gsmsecret)
+}
+
+// ValidateUpdate implements webhook.CustomValidator so a webhook will be registered for the type GSMSecret.
+func (v *GSMSecretCustomValidator) ValidateUpdate(ctx context.Context, oldObj, newObj runtime.Object) (admission.Warnings, error) {
+	gsmsecret, ok := newObj.(*secretspizecomv1alpha1.GSMSecret)
+	if !ok {
+		return nil, fmt.Errorf("expected a GSMSecret object for the newObj but got %T", newObj)
+	}
+	gsmsecretlog.V(1).Info("Validation for GSMSecret upon update", "name", gsmsecret.GetName())
+	return v.validate(ctx, gsmsecret)
+}
+
+// ValidateDelete implements webhook.CustomValidator so a webhook will be registered for the type GSMSecret.
+func (v *GSMSecretCustomValidator) ValidateDelete(_ context.Context, _ runtime.Object) (admission.Warnings, error) {
+	return nil, nil
+}
+
+func (v *GSMSecretCustomValidator) validate(ctx context.Context, gsmsecret *secretspizecomv1alpha1.GSMSecret) (admission.Warnings, error) {
+	modePolicy, err := authmode.LoadPolicy()
+	if err != nil {
+		return nil, err
+	}
+	if gsmsecret.DeletionTimestamp != nil {
+		return nil, nil
+	}
+
+	store, err := getStore(ctx, v.Client, gsmsecret)
+	if err != nil {
+		// The store may be created later; the controller re-checks then.
+		return admission.Warnings{err.Error()}, nil
+	}
+
+	if mode := authmode.Resolve(gsmsecret, store); !modePolicy.Allows(mode, gsmsecret.Namespace) {
+		return nil, &authmode.NotAllowedError{Namespace: gsmsecret.Namespace, Mode: mode}
+	}
+	if !accesspolicy.Enabled() {
+		return nil, nil
+	}
+
+	err = accesspolicy.Check(ctx, v.Client, gsmsecret.Namespace, policyAccesses(gsmsecret, store))
+	var denied *accesspolicy.DeniedError
+	if errors.As(err, &denied) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("evaluate GSMAccessPolicies: %w", err)
+	}
+	return nil, nil
+}
+
+// getStore returns the spec of the store referenced by gsmsecret, or nil when
+// it has no storeRef.
+func getStore(
+	ctx context.Context,
+	c client.Reader,
+	gsmsecret *secretspizecomv1alpha1.GSMSecret,
+) (*secretspizecomv1alpha1.GSMSecretStoreSpec, error) {
+	ref := gsmsecret.Spec.StoreRef
+	if ref == nil {
+		return nil, nil
+	}
+	var err error
+	if ref.Kind == secretspizecomv1alpha1.ClusterGSMSecretStoreKind {
+		var store secretspizecomv1alpha1.ClusterGSMSecretStore
+		if err = c.Get(ctx, types.NamespacedName{Name: ref.Name}, &store); err == nil {
+			return &store.Spec, nil
+		}
+	} else {
+		var store secretspizecomv1alpha1.GSMSecretStore
+		if err = c.Get(ctx, types.NamespacedName{Namespace: gsmsecret.Namespace, Name: ref.Name}, &store); err == nil {
+			return &store.Spec, nil
+		}
+	}
+	if apierrors.IsNotFound(err) {
+		return nil, fmt.Errorf("store %q not found; policies will be checked once it exists", ref.Name)
+	}
+	return nil, fmt.Errorf("get store %q: %w", ref.Name, err)
+}
+
+// policyAccesses mirrors the reads the controller would perform. Entries
+// without a resolvable project are skipped; the controller reports them.
+func policyAccesses(
+	gsmsecret *secretspizecomv1alpha1.GSMSecret,
+	store *secretspizecomv1alpha1.GSMSecretStoreSpec,
+) []accesspolicy.Access {
+	principals := impersonatedPrincipals(gsmsecret, store)
+
+	var accesses []accesspolicy.Access
+	for _, e := range gsmsecret.Spec.Secrets {
+		projectID := e.ProjectID
+		if projectID == "" && store != nil {
+			projectID = store.DefaultProjectID
+		}
+		if projectID == "" {
+			continue
+		}
+		accesses = append(accesses, accesspolicy.Access{ProjectID: projectID, SecretID: e.SecretID, Principals: principals})
+	}
+	return accesses
+}
+
+// impersonatedPrincipals returns the delegates and target GSA, in order.
+func impersonatedPrincipals(
+	gsmsecret *secretspizecomv1alpha1.GSMSecret,
+	store *secretspizecomv1alpha1.GSMSecretStoreSpec,
+) []string {
+	if authmode.Resolve(gsmsecret, store) == secretspizecomv1alpha1.AuthModeTrustedSubsystem {
+		return nil
+	}
+
+	var target string
+	var delegates []string
+	if store != nil {
+		if store.Impersonation != nil {
+			target = store.Impersonation.ServiceAccount
+			delegates = store.Impersonation.Delegates
+		}
+	} else {
+		ann := gsmsecret.GetAnnotations()
+		target = ann[secretspizecomv1alpha1.AnnotationGSA]
+		if raw := strings.TrimSpace(ann[secretspizecomv1alpha1.AnnotationGSADelegates]); raw != "" {
+			delegates = strings.Split(raw, ",")
+		}
+	}
+	target = strings.TrimSpace(target)
+	if target == "" {
+		return nil
+	}
+
+	var principals []string
+	for _, d := range delegates {
+		principals = append(principals, strings.TrimSpace(d))
+	}
+	return append(principals, target)
+}
@@ -0,0 +1,390 @@
+/*
+Copyright 2025 Zera Holladay.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	secretspizecomv1alpha1 "github.com/zeraholladay/gsm-operator/api/v1alpha1"
+)
+
+const (
+	teamReaderGSA = "reader@team-prod.iam.gserviceaccount.com"
+	teamBrokerGSA = "broker@team-prod.iam.gserviceaccount.com"
+)
+
+func newValidator(objs ...client.Object) *GSMSecretCustomValidator {
+	scheme := runtime.NewScheme()
+	_ = clientgoscheme.AddToScheme(scheme)
+	_ = secretspizecomv1alpha1.AddToScheme(scheme)
+	objs = append(objs,
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team", Labels: map[string]string{"team": "a"}}},
+		&secretspizecomv1alpha1.GSMAccessPolicy{
+			ObjectMeta: metav1.ObjectMeta{Name: "team-a"},
+			Spec: secretspizecomv1alpha1.GSMAccessPolicySpec{
+				NamespaceSelector: metav1.LabelSelector{MatchLabels: map[string]string{"team": "a"}},
+				ProjectIDs:        []string{"team-prod"},
+				SecretIDs:         []string{"app-*"},
+				Principals:        []string{"*@team-prod.iam.gserviceaccount.com"},
+			},
+		},
+	)
+	return &GSMSecretCustomValidator{Client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(objs...).Build()}
+}
+
+func newGSMSecret(projectID, secretID string, annotations map[string]string) *secretspizecomv1alpha1.GSMSecret {
+	return &secretspizecomv1alpha1.GSMSecret{
+		ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "team", Annotations: annotations},
+		Spec: secretspizecomv1alpha1.GSMSecretSpec{
+			TargetSecret: secretspizecomv1alpha1.GSMSecretTargetSecret{Name: "app"},
+			Secrets: []secretspizecomv1alpha1.GSMSecretEntry{
+				{Key: "K", ProjectID: projectID, SecretID: secretID, Version: "latest"},
+			},
+		},
+	}
+}
+
+func TestValidate_DisabledAllowsEverything(t *testing.T) {
+	t.Setenv("ENFORCE_ACCESS_POLICY", "")
+	v := newValidator()
+	if _, err := v.ValidateCreate(context.Background(), newGSMSecret("elsewhere", "root", nil)); err != nil {
+		t.Errorf("expected no validation without enforcement, got %v", err)
+	}
+}
+
+func TestValidate_EnforcesPolicy(t *testing.T) {
+	t.Setenv("ENFORCE_ACCESS_POLICY", "true")
+	t.Setenv("MODE", "")
+
+	tests := []struct {
+		name    string
+		gsm     *secretspizecomv1alpha1.GSMSecret
+		wantErr string
+	}{
+		{
+			name: "allowed",
+			gsm:  newGSMSecret("team-prod", "app-db", nil),
+		},
+		{
+			name: "allowed chain",
+			gsm: newGSMSecret("team-prod", "app-db", map[string]string{
+				secretspizecomv1alpha1.AnnotationGSA:          teamReaderGSA,
+				secretspizecomv1alpha1.AnnotationGSADelegates: teamBrokerGSA,
+			}),
+		},
+		{
+			name:    "secret denied",
+			gsm:     newGSMSecret("team-prod", "root-key", nil),
+			wantErr: "projects/team-prod/secrets/root-key",
+		},
+		{
+			name: "delegate denied",
+			gsm: newGSMSecret("team-prod", "app-db", map[string]string{
+				secretspizecomv1alpha1.AnnotationGSA:          teamReaderGSA,
+				secretspizecomv1alpha1.AnnotationGSADelegates: "broker@shared.iam.gserviceaccount.com",
+			}),
+			wantErr: "broker@shared.iam.gserviceaccount.com",
+		},
+	}
+
+	v := newValidator()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := v.ValidateCreate(context.Background(), tt.gsm)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+			if _, err := v.ValidateUpdate(context.Background(), tt.gsm, tt.gsm); err == nil {
+				t.Error("expected update to be denied as well")
+			}
+		})
+	}
+}
+
+func TestValidate_UsesStore(t *testing.T) {
+	t.Setenv("ENFORCE_ACCESS_POLICY", "true")
+	t.Setenv("MODE", "")
+
+	store := &secretspizecomv1alpha1.GSMSecretStore{
+		ObjectMeta: metav1.ObjectMeta{Name: "shared", Namespace: "team"},
+		Spec: secretspizecomv1alpha1.GSMSecretStoreSpec{
+			DefaultProjectID: "team-prod",
+			Impersonation:    &secretspizecomv1alpha1.GSMSecretStoreImpersonation{ServiceAccount: "reader@other.iam.gserviceaccount.com"},
+		},
+	}
+	v := newValidator(store)
+
+	gsm := newGSMSecret("", "app-db", nil)
+	gsm.Spec.StoreRef = &secretspizecomv1alpha1.GSMSecretStoreRef{Name: "shared"}
+	_, err := v.ValidateCreate(context.Background(), gsm)
+	if err == nil || !strings.Contains(err.Error(), "projects/team-prod/secrets/app-db as reader@other.iam.gserviceaccount.com") {
+		t.Errorf("expected the store's project and GSA to be checked, got %v", err)
+	}
+
+	// A store that does not exist yet only produces a warning.
+	gsm.Spec.StoreRef.Name = "missing"
+	warnings, err := v.ValidateCreate(context.Background(), gsm)
+	if err != nil || len(warnings) != 1 {
+		t.Errorf("expected a warning for a missing store, got warnings=%v err=%v", warnings, err)
+	}
+}
+
+func TestValidate_AuthModePolicy(t *testing.T) {
+	t.Setenv("ENFORCE_ACCESS_POLICY", "")
+	t.Setenv("MODE", "")
+	t.Setenv("AUTH_MODE_POLICY", "TrustedSubsystem=platform-*;WorkloadIdentityFederation=*")
+
+	store := &secretspizecomv1alpha1.ClusterGSMSecretStore{
+		ObjectMeta: metav1.ObjectMeta{Name: "operator"},
+		Spec:       secretspizecomv1alpha1.GSMSecretStoreSpec{AuthMode: secretspizecomv1alpha1.AuthModeTrustedSubsystem},
+	}
+	v := newValidator(store)
+
+	// Workload Identity Federation is allowed everywhere.
+	if _, err := v.ValidateCreate(context.Background(), newGSMSecret("elsewhere", "root", nil)); err != nil {
+		t.Errorf("expected WIF to be allowed in team, got %v", err)
+	}
+
+	// The operator identity is reserved for platform namespaces, whether
+	// chosen in the spec or through a cluster store.
+	gsm := newGSMSecret("team-prod", "app-db", nil)
+	gsm.Spec.AuthMode = secretspizecomv1alpha1.AuthModeTrustedSubsystem
+	if _, err := v.ValidateCreate(context.Background(), gsm); err == nil || !strings.Contains(err.Error(), "auth mode TrustedSubsystem is not allowed") {
+		t.Errorf("expected spec authMode to be denied, got %v", err)
+	}
+	gsm = newGSMSecret("team-prod", "app-db", nil)
+	gsm.Spec.StoreRef = &secretspizecomv1alpha1.GSMSecretStoreRef{Name: "operator", Kind: secretspizecomv1alpha1.ClusterGSMSecretStoreKind}
+	if _, err := v.ValidateCreate(context.Background(), gsm); err == nil || !strings.Contains(err.Error(), "auth mode TrustedSubsystem is not allowed") {
+		t.Errorf("expected store authMode to be denied, got %v", err)
+	}
+
+	gsm.Namespace = "platform-ingress"
+	if _, err := v.ValidateCreate(context.Background(), gsm); err != nil {
+		t.Errorf("expected TrustedSubsystem to be allowed in a platform namespace, got %v", err)
+	}
+}
+
+// newDefaulter returns a defaulter whose SubjectAccessReviews allow alice to
+// mint tokens for the "reader" KSA only, and counts the reviews made.
+func newDefaulter(reviews *int, objs ...client.Object) *GSMSecretCustomDefaulter {
+	scheme := runtime.NewScheme()
+	_ = clientgoscheme.AddToScheme(scheme)
+	_ = secretspizecomv1alpha1.AddToScheme(scheme)
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(objs...).WithInterceptorFuncs(interceptor.Funcs{
+		Create: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.CreateOption) error {
+			sar, ok := obj.(*authorizationv1.SubjectAccessReview)
+			if !ok {
+				return c.Create(ctx, obj, opts...)
+			}
+			*reviews++
+			sar.Status.Allowed = sar.Spec.User == "alice" && sar.Spec.ResourceAttributes.Name == "reader"
+			return nil
+		},
+	}).Build()
+	return &GSMSecretCustomDefaulter{Client: c}
+}
+
+func admissionContext(t *testing.T, op admissionv1.Operation, user string, old *secretspizecomv1alpha1.GSMSecret) context.Context {
+	t.Helper()
+	req := admission.Request{AdmissionRequest: admissionv1.AdmissionRequest{
+		Operation: op,
+		UserInfo:  authenticationv1.UserInfo{Username: user},
+	}}
+	if old != nil {
+		raw, err := json.Marshal(old)
+		if err != nil {
+			t.Fatalf("failed to marshal old object: %v", err)
+		}
+		req.OldObject.Raw = raw
+	}
+	return admission.NewContextWithRequest(context.Background(), req)
+}
+
+func TestDefault_ApprovesKSA(t *testing.T) {
+	t.Setenv("REQUIRE_KSA_APPROVAL", "true")
+	t.Setenv("MODE", "")
+	t.Setenv("KSA", "")
+
+	tests := []struct {
+		name     string
+		user     string
+		ksa      string
+		approved string
+		wantErr  bool
+	}{
+		{name: "allowed user", user: "alice", ksa: "reader", approved: "reader"},
+		{name: "denied KSA", user: "alice", ksa: "admin", wantErr: true},
+		{name: "denied user", user: "mallory", ksa: "reader", wantErr: true},
+		{name: "forged annotation is not trusted", user: "mallory", ksa: "reader", approved: "reader", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var reviews int
+			d := newDefaulter(&reviews)
+			gsm := newGSMSecret("team-prod", "app-db", map[string]string{
+				secretspizecomv1alpha1.AnnotationKSA:         tt.ksa,
+				secretspizecomv1alpha1.AnnotationApprovedKSA: tt.approved,
+			})
+			err := d.Default(admissionContext(t, admissionv1.Create, tt.user, nil), gsm)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected the request to be denied")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := gsm.Annotations[secretspizecomv1alpha1.AnnotationApprovedKSA]; got != tt.ksa {
+				t.Errorf("expected approved KSA %q, got %q", tt.ksa, got)
+			}
+			if reviews != 1 {
+				t.Errorf("expected one SubjectAccessReview, got %d", reviews)
+			}
+		})
+	}
+}
+
+func TestDefault_UpdateReviewsSpecChanges(t *testing.T) {
+	t.Setenv("REQUIRE_KSA_APPROVAL", "true")
+	t.Setenv("MODE", "")
+	t.Setenv("KSA", "")
+
+	annotations := map[string]string{
+		secretspizecomv1alpha1.AnnotationKSA:         "reader",
+		secretspizecomv1alpha1.AnnotationApprovedKSA: "reader",
+	}
+	old := newGSMSecret("team-prod", "app-db", annotations)
+
+	// Metadata-only updates, such as adding a finalizer, keep the approval.
+	var reviews int
+	d := newDefaulter(&reviews)
+	gsm := newGSMSecret("team-prod", "app-db", annotations)
+	gsm.Finalizers = []string{"secrets.gsm-operator.io/cleanup"}
+	if err := d.Default(admissionContext(t, admissionv1.Update, "bob", old), gsm); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if reviews != 0 || gsm.Annotations[secretspizecomv1alpha1.AnnotationApprovedKSA] != "reader" {
+		t.Errorf("expected approval to be kept without a review, got reviews=%d annotations=%v", reviews, gsm.Annotations)
+	}
+
+	// A spec change is reviewed against the user making it.
+	gsm = newGSMSecret("team-prod", "app-other", annotations)
+	if err := d.Default(admissionContext(t, admissionv1.Update, "bob", old), gsm); err == nil {
+		t.Error("expected a spec change by a user who cannot approve the KSA to be denied")
+	}
+	reviews = 0
+	gsm = newGSMSecret("team-prod", "app-other", annotations)
+	if err := d.Default(admissionContext(t, admissionv1.Update, "alice", old), gsm); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if reviews != 1 || gsm.Annotations[secretspizecomv1alpha1.AnnotationApprovedKSA] != "reader" {
+		t.Errorf("expected a fresh review to approve the KSA, got reviews=%d annotations=%v", reviews, gsm.Annotations)
+	}
+
+	// Changing the KSA needs a fresh review.
+	gsm = newGSMSecret("team-prod", "app-db", map[string]string{
+		secretspizecomv1alpha1.AnnotationKSA:         "admin",
+		secretspizecomv1alpha1.AnnotationApprovedKSA: "admin",
+	})
+	if err := d.Default(admissionContext(t, admissionv1.Update, "bob", old), gsm); err == nil {
+		t.Error("expected a KSA change to be reviewed and denied")
+	}
+}
+
+func TestDefault_StoreAndTrustedSubsystem(t *testing.T) {
+	t.Setenv("REQUIRE_KSA_APPROVAL", "true")
+	t.Setenv("MODE", "")
+	t.Setenv("KSA", "")
+
+	store := &secretspizecomv1alpha1.GSMSecretStore{
+		ObjectMeta: metav1.ObjectMeta{Name: "shared", Namespace: "team"},
+		Spec:       secretspizecomv1alpha1.GSMSecretStoreSpec{ServiceAccountName: "reader"},
+	}
+	var reviews int
+	d := newDefaulter(&reviews, store)
+
+	// The store's ServiceAccount is reviewed, not the ignored annotation.
+	gsm := newGSMSecret("team-prod", "app-db", map[string]string{secretspizecomv1alpha1.AnnotationKSA: "admin"})
+	gsm.Spec.StoreRef = &secretspizecomv1alpha1.GSMSecretStoreRef{Name: "shared"}
+	if err := d.Default(admissionContext(t, admissionv1.Create, "alice", nil), gsm); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if gsm.Annotations[secretspizecomv1alpha1.AnnotationApprovedKSA] != "reader" {
+		t.Errorf("expected the store KSA to be approved, got %v", gsm.Annotations)
+	}
+
+	// Trusted subsystem mode uses no tenant KSA, so nothing is reviewed.
+	t.Setenv("MODE", "TRUSTED_SUBSYSTEM")
+	reviews = 0
+	gsm = newGSMSecret("team-prod", "app-db", map[string]string{secretspizecomv1alpha1.AnnotationApprovedKSA: "forged"})
+	if err := d.Default(admissionContext(t, admissionv1.Create, "mallory", nil), gsm); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if reviews != 0 {
+		t.Errorf("expected no review in trusted subsystem mode, got %d", reviews)
+	}
+	if _, ok := gsm.Annotations[secretspizecomv1alpha1.AnnotationApprovedKSA]; ok {
+		t.Errorf("expected a user-supplied approval to be removed, got %v", gsm.Annotations)
+	}
+}
+
+func TestDefault_ExternalAccountStoreSkipsReview(t *testing.T) {
+	t.Setenv("REQUIRE_KSA_APPROVAL", "true")
+	t.Setenv("MODE", "")
+
+	store := &secretspizecomv1alpha1.GSMSecretStore{
+		ObjectMeta: metav1.ObjectMeta{Name: "external", Namespace: "team"},
+		Spec:       secretspizecomv1alpha1.GSMSecretStoreSpec{AuthMode: secretspizecomv1alpha1.AuthModeExternalAccount},
+	}
+	var reviews int
+	d := newDefaulter(&reviews, store)
+
+	// The operator's external_account identity is used, not a tenant KSA.
+	gsm := newGSMSecret("team", "app-db", nil)
+	gsm.Spec.StoreRef = &secretspizecomv1alpha1.GSMSecretStoreRef{Name: "external"}
+	if err := d.Default(admissionContext(t, admissionv1.Create, "alice", nil), gsm); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if reviews != 0 {
+		t.Errorf("expected no review in ExternalAccount mode, got %d", reviews)
+	}
+	if _, ok := gsm.Annotations[secretspizecomv1alpha1.AnnotationApprovedKSA]; ok {
+		t.Errorf("expected no approval annotation, got %v", gsm.Annotations)
+	}
+}
@@ -0,0 +1,76 @@
+/*
+Copyright 2025 Zera Holladay.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"context"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	secretspizecomv1alpha1 "github.com/zeraholladay/gsm-operator/api/v1alpha1"
+	"github.com/zeraholladay/gsm-operator/internal/authmode"
+)
+
+// log is for logging in this package.
+var gsmsecretstorelog = logf.Log.WithName("gsmsecretstore-resource")
+
+// SetupGSMSecretStoreWebhookWithManager registers the webhook for GSMSecretStore in the manager.
+func SetupGSMSecretStoreWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).For(&secretspizecomv1alpha1.GSMSecretStore{}).
+		WithValidator(&GSMSecretStoreCustomValidator{}).
+		Complete()
+}
+
+// +kubebuilder:webhook:path=/validate-secrets-gsm-operator-io-v1alpha1-gsmsecretstore,mutating=false,failurePolicy=fail,sideEffects=None,groups=secrets.gsm-operator.io,resources=gsmsecretstores,verbs=create;update,versions=v1alpha1,name=vgsmsecretstore-v1alpha1.kb.io,admissionReviewVersions=v1
+
+// GSMSecretStoreCustomValidator rejects namespaced stores that would use the
+// operator's identity. Tenants can edit GSMSecretStores, so those settings
+// are only accepted on ClusterGSMSecretStores. The store controller reports
+// the same error, so the webhook only moves it to admission time.
+type GSMSecretStoreCustomValidator struct{}
+
+var _ webhook.CustomValidator = &GSMSecretStoreCustomValidator{}
+
+// ValidateCreate implements webhook.CustomValidator so a webhook will be registered for the type GSMSecretStore.
+func (v *GSMSecretStoreCustomValidator) ValidateCreate(_ context.Context, obj runtime.Object) (admission.Warnings, error) {
+	store, ok := obj.(*secretspizecomv1alpha1.GSMSecretStore)
+	if !ok {
+		return nil, fmt.Errorf("expected a GSMSecretStore object but got %T", obj)
+	}
+	gsmsecretstorelog.V(1).Info("Validation for GSMSecretStore upon creation", "name", store.GetName())
+	return nil, authmode.CheckNamespacedStore(&store.Spec)
+}
+
+// ValidateUpdate implements webhook.CustomValidator so a webhook will be registered for the type GSMSecretStore.
+func (v *GSMSecretStoreCustomValidator) ValidateUpdate(_ context.Context, _, newObj runtime.Object) (admission.Warnings, error) {
+	store, ok := newObj.(*secretspizecomv1alpha1.GSMSecretStore)
+	if !ok {
+		return nil, fmt.Errorf("expected a GSMSecretStore object for the newObj but got %T", newObj)
+	}
+	gsmsecretstorelog.V(1).Info("Validation for GSMSecretStore upon update", "name", store.GetName())
+	return nil, authmode.CheckNamespacedStore(&store.Spec)
+}
+
+// ValidateDelete implements webhook.CustomValidator so a webhook will be registered for the type GSMSecretStore.
+func (v *GSMSecretStoreCustomValidator) ValidateDelete(_ context.Context, _ runtime.Object) (admission.Warnings, error) {
+	return nil, nil
+}
@@ -0,0 +1,74 @@
+/*
+Copyright 2025 Zera Holladay.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	secretspizecomv1alpha1 "github.com/zeraholladay/gsm-operator/api/v1alpha1"
+)
+
+func TestGSMSecretStoreValidator_RejectsOperatorIdentityModes(t *testing.T) {
+	v := &GSMSecretStoreCustomValidator{}
+	for _, mode := range []secretspizecomv1alpha1.GSMSecretStoreAuthMode{
+		secretspizecomv1alpha1.AuthModeTrustedSubsystem,
+		secretspizecomv1alpha1.AuthModeExternalAccount,
+	} {
+		store := &secretspizecomv1alpha1.GSMSecretStore{
+			ObjectMeta: metav1.ObjectMeta{Name: "store", Namespace: "team"},
+			Spec:       secretspizecomv1alpha1.GSMSecretStoreSpec{AuthMode: mode},
+		}
+		if _, err := v.ValidateCreate(context.Background(), store); err == nil || !strings.Contains(err.Error(), "only allowed on a ClusterGSMSecretStore") {
+			t.Errorf("create with %s: expected rejection, got %v", mode, err)
+		}
+		if _, err := v.ValidateUpdate(context.Background(), store, store); err == nil {
+			t.Errorf("update with %s: expected rejection", mode)
+		}
+	}
+}
+
+func TestGSMSecretStoreValidator_AllowsTenantModes(t *testing.T) {
+	v := &GSMSecretStoreCustomValidator{}
+	for _, mode := range []secretspizecomv1alpha1.GSMSecretStoreAuthMode{
+		"",
+		secretspizecomv1alpha1.AuthModeWorkloadIdentityFederation,
+		secretspizecomv1alpha1.AuthModeCredentialsSecret,
+	} {
+		store := &secretspizecomv1alpha1.GSMSecretStore{
+			ObjectMeta: metav1.ObjectMeta{Name: "store", Namespace: "team"},
+			Spec:       secretspizecomv1alpha1.GSMSecretStoreSpec{AuthMode: mode},
+		}
+		if _, err := v.ValidateCreate(context.Background(), store); err != nil {
+			t.Errorf("mode %q: unexpected error: %v", mode, err)
+		}
+	}
+}
+
+func TestGSMSecretStoreValidator_RejectsEndpointOverrides(t *testing.T) {
+	v := &GSMSecretStoreCustomValidator{}
+	store := &secretspizecomv1alpha1.GSMSecretStore{
+		ObjectMeta: metav1.ObjectMeta{Name: "store", Namespace: "team"},
+		Spec:       secretspizecomv1alpha1.GSMSecretStoreSpec{STSEndpoint: "https://sts.attacker.example.com"},
+	}
+	if _, err := v.ValidateCreate(context.Background(), store); err == nil || !strings.Contains(err.Error(), "stsEndpoint") {
+		t.Fatalf("expected endpoint override to be rejected, got %v", err)
+	}
+}